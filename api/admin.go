@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/event"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+//go:generate moq -out mock/generation.go -pkg mock . GenerationJob
+//go:generate moq -out mock/urlupdater.go -pkg mock . URLUpdater
+//go:generate moq -out mock/robotsreloader.go -pkg mock . RobotsReloader
+
+// ErrGenerationRunning is returned when the full sitemap generation is triggered while it is already running
+var ErrGenerationRunning = errors.New("full sitemap generation is already running")
+
+// GenerationJob defines the methods to trigger and inspect the full sitemap generation job
+type GenerationJob interface {
+	Trigger() error
+	Status() (GenerationStatus, error)
+}
+
+// URLUpdater defines the methods to add and remove individual pages in the sitemaps,
+// adding going through the same path as content published events
+type URLUpdater interface {
+	Handle(ctx context.Context, cfg *config.Config, contentPublished *event.ContentPublished) error
+	Remove(ctx context.Context, cfg *config.Config, path string) error
+}
+
+// RobotsReloader defines the method to reload the robots rules, keeping the previous ones if the new ones are invalid
+type RobotsReloader interface {
+	Reload() error
+}
+
+// GenerationStatus describes the state of the full sitemap generation job
+type GenerationStatus struct {
+	Running      bool              `json:"running"`
+	Schedule     string            `json:"schedule,omitempty"`
+	LastRun      *time.Time        `json:"last_run,omitempty"`
+	NextRun      *time.Time        `json:"next_run,omitempty"`
+	LastDuration string            `json:"last_duration,omitempty"`
+	URLCounts    sitemap.URLCounts `json:"url_counts,omitempty"`
+	LastError    string            `json:"last_error,omitempty"`
+}
+
+// SetupAdmin adds the admin endpoints, authenticated with the given token, to the api.
+// The endpoints are not added if no token is configured.
+func (api *API) SetupAdmin(ctx context.Context, authToken string, job GenerationJob, urlUpdater URLUpdater, robots RobotsReloader) {
+	if authToken == "" {
+		log.Warn(ctx, "no admin auth token configured, admin endpoints are disabled")
+		return
+	}
+	api.authToken = authToken
+	api.generationJob = job
+	api.urlUpdater = urlUpdater
+	api.robotsReloader = robots
+
+	api.Router.HandleFunc("/admin/full-sitemap", api.authenticate(api.GenerationStatusHandler)).Methods(http.MethodGet)
+	api.Router.HandleFunc("/admin/full-sitemap", api.authenticate(api.TriggerGenerationHandler)).Methods(http.MethodPost)
+	api.Router.HandleFunc("/admin/urls", api.authenticate(api.AddURLHandler)).Methods(http.MethodPost)
+	api.Router.HandleFunc("/admin/urls", api.authenticate(api.RemoveURLHandler)).Methods(http.MethodDelete)
+	api.Router.HandleFunc("/admin/robots", api.authenticate(api.ReloadRobotsHandler)).Methods(http.MethodPost)
+}
+
+// authenticate only calls the wrapped handler if the request carries the admin auth token as a bearer token
+func (api *API) authenticate(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(api.authToken)) != 1 {
+			log.Info(req.Context(), "unauthorised admin request", log.Data{"path": req.URL.Path, "method": req.Method})
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler(w, req)
+	}
+}
+
+// GenerationStatusHandler reports the state of the full sitemap generation job
+func (api *API) GenerationStatusHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	status, err := api.generationJob.Status()
+	if err != nil {
+		log.Error(ctx, "failed to get full sitemap generation status", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(ctx, w, http.StatusOK, status)
+}
+
+// TriggerGenerationHandler starts the full sitemap generation job, unless it is already running
+func (api *API) TriggerGenerationHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	err := api.generationJob.Trigger()
+	if errors.Is(err, ErrGenerationRunning) {
+		log.Info(ctx, "full sitemap generation from admin endpoint - job is already running")
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Error(ctx, "failed to trigger full sitemap generation", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	log.Info(ctx, "full sitemap generation triggered from admin endpoint")
+
+	status, err := api.generationJob.Status()
+	if err != nil {
+		log.Error(ctx, "failed to get full sitemap generation status", err)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeJSON(ctx, w, http.StatusAccepted, status)
+}
+
+// ReloadRobotsHandler reloads the robots rules, rejecting invalid ones
+func (api *API) ReloadRobotsHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	if err := api.robotsReloader.Reload(); err != nil {
+		log.Error(ctx, "rejected robots config reload from admin endpoint, keeping the previous rules", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	log.Info(ctx, "robots config reloaded from admin endpoint")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON writes body to the response as JSON with the given status code
+func writeJSON(ctx context.Context, w http.ResponseWriter, status int, body interface{}) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		log.Error(ctx, "failed to marshal response body", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "failed to write response body", err)
+	}
+}
@@ -0,0 +1,160 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/api"
+	"github.com/ONSdigital/dp-sitemap/api/mock"
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testAuthToken = "test-token"
+
+var authHeader = map[string]string{"Authorization": "Bearer " + testAuthToken}
+
+func TestGenerationAdmin(t *testing.T) {
+	lastRun := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	status := api.GenerationStatus{
+		Running:      false,
+		LastRun:      &lastRun,
+		LastDuration: "1m0s",
+		URLCounts:    sitemap.URLCounts{config.English: 10, config.Welsh: 5},
+	}
+
+	Convey("Given an api with admin endpoints", t, func() {
+		job := &mock.GenerationJobMock{
+			TriggerFunc: func() error { return nil },
+			StatusFunc:  func() (api.GenerationStatus, error) { return status, nil },
+		}
+		a := newTestAPI(newStoreMock(map[string]string{}))
+		a.SetupAdmin(ctx, testAuthToken, job, &mock.URLUpdaterMock{}, &mock.RobotsReloaderMock{})
+
+		Convey("When the generation status is requested without a token", func() {
+			w := doRequest(a, http.MethodGet, "/admin/full-sitemap", nil)
+
+			Convey("Then unauthorised is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(job.StatusCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the generation is triggered with the wrong token", func() {
+			w := doRequest(a, http.MethodPost, "/admin/full-sitemap", map[string]string{"Authorization": "Bearer wrong"})
+
+			Convey("Then unauthorised is returned and the job is not triggered", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(job.TriggerCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the generation status is requested", func() {
+			w := doRequest(a, http.MethodGet, "/admin/full-sitemap", authHeader)
+
+			Convey("Then the status is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+				var body map[string]interface{}
+				So(json.Unmarshal(w.Body.Bytes(), &body), ShouldBeNil)
+				So(body["running"], ShouldEqual, false)
+				So(body["last_run"], ShouldEqual, "2024-01-02T03:04:05Z")
+				So(body["last_duration"], ShouldEqual, "1m0s")
+				So(body["url_counts"], ShouldResemble, map[string]interface{}{"en": float64(10), "cy": float64(5)})
+				So(body, ShouldNotContainKey, "last_error")
+			})
+		})
+
+		Convey("When the generation status cannot be retrieved", func() {
+			job.StatusFunc = func() (api.GenerationStatus, error) { return api.GenerationStatus{}, errors.New("job not found") }
+			w := doRequest(a, http.MethodGet, "/admin/full-sitemap", authHeader)
+
+			Convey("Then an internal server error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+
+		Convey("When the generation is triggered", func() {
+			w := doRequest(a, http.MethodPost, "/admin/full-sitemap", authHeader)
+
+			Convey("Then the job is triggered and accepted is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusAccepted)
+				So(job.TriggerCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When the generation is triggered while already running", func() {
+			job.TriggerFunc = func() error { return api.ErrGenerationRunning }
+			w := doRequest(a, http.MethodPost, "/admin/full-sitemap", authHeader)
+
+			Convey("Then conflict is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusConflict)
+			})
+		})
+
+		Convey("When triggering the generation fails", func() {
+			job.TriggerFunc = func() error { return errors.New("scheduler error") }
+			w := doRequest(a, http.MethodPost, "/admin/full-sitemap", authHeader)
+
+			Convey("Then an internal server error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+	})
+
+	Convey("Given an api with no admin auth token configured", t, func() {
+		job := &mock.GenerationJobMock{}
+		a := newTestAPI(newStoreMock(map[string]string{}))
+		a.SetupAdmin(ctx, "", job, &mock.URLUpdaterMock{}, &mock.RobotsReloaderMock{})
+
+		Convey("When the generation is triggered", func() {
+			w := doRequest(a, http.MethodPost, "/admin/full-sitemap", map[string]string{"Authorization": "Bearer "})
+
+			Convey("Then the endpoint is not available", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
+
+func TestRobotsAdmin(t *testing.T) {
+	Convey("Given an api with admin endpoints", t, func() {
+		robots := &mock.RobotsReloaderMock{
+			ReloadFunc: func() error { return nil },
+		}
+		a := newTestAPI(newStoreMock(map[string]string{}))
+		a.SetupAdmin(ctx, testAuthToken, &mock.GenerationJobMock{}, &mock.URLUpdaterMock{}, robots)
+
+		Convey("When the robots config is reloaded without a token", func() {
+			w := doRequest(a, http.MethodPost, "/admin/robots", nil)
+
+			Convey("Then it is unauthorised and the config is not reloaded", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(robots.ReloadCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the robots config is reloaded", func() {
+			w := doRequest(a, http.MethodPost, "/admin/robots", authHeader)
+
+			Convey("Then it is reloaded", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(robots.ReloadCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When the reloaded robots config is invalid", func() {
+			robots.ReloadFunc = func() error { return errors.New("invalid robot_en.json: invalid host [www.ons.gov.uk/path]") }
+			w := doRequest(a, http.MethodPost, "/admin/robots", authHeader)
+
+			Convey("Then it is rejected with the problems found", func() {
+				So(w.Code, ShouldEqual, http.StatusUnprocessableEntity)
+				So(w.Body.String(), ShouldContainSubstring, "invalid host [www.ons.gov.uk/path]")
+			})
+		})
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/gorilla/mux"
+)
+
+const (
+	contentTypeXML  = "application/xml; charset=utf-8"
+	contentTypeText = "text/plain; charset=utf-8"
+)
+
+// API provides a struct to wrap the api around
+type API struct {
+	Router         *mux.Router
+	store          sitemap.FileStore
+	sitemapFiles   sitemap.FileResolver
+	robotsStore    sitemap.FileStore
+	robotsFiles    sitemap.Files
+	hostNames      map[config.Language]string
+	cfg            *config.Config
+	authToken      string
+	generationJob  GenerationJob
+	urlUpdater     URLUpdater
+	robotsReloader RobotsReloader
+}
+
+// Setup function sets up the api and returns an api, serving the sitemaps from store and the robots files from robotsStore
+func Setup(ctx context.Context, r *mux.Router, cfg *config.Config, store sitemap.FileStore, sitemapFiles sitemap.FileResolver, robotsStore sitemap.FileStore, robotsFiles sitemap.Files) *API {
+	api := &API{
+		Router:       r,
+		cfg:          cfg,
+		store:        store,
+		sitemapFiles: sitemapFiles,
+		robotsStore:  robotsStore,
+		robotsFiles:  robotsFiles,
+		hostNames: map[config.Language]string{
+			config.English: cfg.DpOnsURLHostNameEn,
+			config.Welsh:   cfg.DpOnsURLHostNameCy,
+		},
+	}
+
+	r.HandleFunc("/sitemap.xml", api.SitemapHandler).Methods(http.MethodGet, http.MethodHead)
+	r.HandleFunc("/sitemap_index.xml", api.SitemapIndexHandler).Methods(http.MethodGet, http.MethodHead)
+	r.HandleFunc("/sitemap_{lang:en|cy}.xml", api.SitemapHandler).Methods(http.MethodGet, http.MethodHead)
+	r.HandleFunc("/robots.txt", api.RobotsHandler).Methods(http.MethodGet, http.MethodHead)
+	return api
+}
+
+// requestLanguage works out the language of a request, from the "lang" path variable or query parameter,
+// falling back to matching the Host header against the configured Welsh hostname
+func (api *API) requestLanguage(req *http.Request) config.Language {
+	lang := mux.Vars(req)["lang"]
+	if lang == "" {
+		lang = req.URL.Query().Get("lang")
+	}
+	switch config.Language(lang) {
+	case config.English, config.Welsh:
+		return config.Language(lang)
+	}
+
+	welshURL, err := url.Parse(api.hostNames[config.Welsh])
+	if err == nil && welshURL.Hostname() != "" && strings.EqualFold(hostName(req.Host), welshURL.Hostname()) {
+		return config.Welsh
+	}
+	return config.English
+}
+
+// hostName strips the port, if any, from a Host header value
+func hostName(host string) string {
+	u := url.URL{Host: host}
+	return u.Hostname()
+}
@@ -0,0 +1,322 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/api"
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	ctx     = context.Background()
+	modTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+func newTestAPI(store sitemap.FileStore) *api.API {
+	cfg := &config.Config{
+		DpOnsURLHostNameEn: "https://www.ons.gov.uk/",
+		DpOnsURLHostNameCy: "https://cy.ons.gov.uk/",
+	}
+	return api.Setup(
+		ctx,
+		mux.NewRouter(),
+		cfg,
+		store,
+		sitemap.Files{config.English: "sitemap-en", config.Welsh: "sitemap-cy"},
+		store,
+		sitemap.Files{config.English: "robots-en", config.Welsh: "robots-cy"},
+	)
+}
+
+func newStoreMock(files map[string]string) *mock.FileStoreMock {
+	return &mock.FileStoreMock{
+		GetFileInfoFunc: func(name string) (*sitemap.FileInfo, error) {
+			content, ok := files[name]
+			if !ok {
+				return nil, sitemap.ErrFileNotFound
+			}
+			return &sitemap.FileInfo{Size: int64(len(content)), ModTime: modTime, ETag: `"` + name + `"`}, nil
+		},
+		GetFileFunc: func(name string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(files[name])), nil
+		},
+	}
+}
+
+func doRequest(a *api.API, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, http.NoBody)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSitemapHandler(t *testing.T) {
+	Convey("Given an api with english and welsh sitemaps in the store", t, func() {
+		store := newStoreMock(map[string]string{"sitemap-en": "english sitemap", "sitemap-cy": "welsh sitemap"})
+		a := newTestAPI(store)
+
+		Convey("When the sitemap is requested on the english host", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", nil)
+
+			Convey("Then the english sitemap is returned with caching headers", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "english sitemap")
+				So(w.Header().Get("Content-Type"), ShouldEqual, "application/xml; charset=utf-8")
+				So(w.Header().Get("ETag"), ShouldEqual, `"sitemap-en"`)
+				So(w.Header().Get("Last-Modified"), ShouldEqual, "Tue, 02 Jan 2024 03:04:05 GMT")
+			})
+		})
+
+		Convey("When the sitemap is requested on the welsh host", func() {
+			w := doRequest(a, http.MethodGet, "https://cy.ons.gov.uk:443/sitemap.xml", nil)
+
+			Convey("Then the welsh sitemap is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "welsh sitemap")
+			})
+		})
+
+		Convey("When the sitemap is requested with a language parameter", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml?lang=cy", nil)
+
+			Convey("Then the sitemap for that language is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "welsh sitemap")
+			})
+		})
+
+		Convey("When a per-language sitemap is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap_cy.xml", nil)
+
+			Convey("Then the sitemap for that language is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "welsh sitemap")
+			})
+		})
+
+		Convey("When the sitemap is requested with a matching If-None-Match header", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", map[string]string{"If-None-Match": `"other", W/"sitemap-en"`})
+
+			Convey("Then not modified is returned without reading the file", func() {
+				So(w.Code, ShouldEqual, http.StatusNotModified)
+				So(w.Body.Len(), ShouldEqual, 0)
+				So(store.GetFileCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When the sitemap is requested with a non matching If-None-Match header", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT",
+			})
+
+			Convey("Then the sitemap is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "english sitemap")
+			})
+		})
+
+		Convey("When the sitemap is requested with an If-Modified-Since header", func() {
+			Convey("Then not modified is returned if the file has not changed since", func() {
+				w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"})
+				So(w.Code, ShouldEqual, http.StatusNotModified)
+			})
+			Convey("Then the sitemap is returned if the file has changed since", func() {
+				w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:04 GMT"})
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "english sitemap")
+			})
+		})
+
+		Convey("When a HEAD request is made", func() {
+			w := doRequest(a, http.MethodHead, "https://www.ons.gov.uk/sitemap.xml", nil)
+
+			Convey("Then the headers are returned without a body", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.Len(), ShouldEqual, 0)
+				So(w.Header().Get("ETag"), ShouldEqual, `"sitemap-en"`)
+			})
+		})
+	})
+
+	Convey("Given an api with no sitemaps in the store", t, func() {
+		a := newTestAPI(newStoreMock(map[string]string{}))
+
+		Convey("When the sitemap is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", nil)
+
+			Convey("Then not found is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+
+	Convey("Given an api with a failing store", t, func() {
+		store := &mock.FileStoreMock{
+			GetFileInfoFunc: func(name string) (*sitemap.FileInfo, error) {
+				return nil, errors.New("store error")
+			},
+		}
+		a := newTestAPI(store)
+
+		Convey("When the sitemap is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", nil)
+
+			Convey("Then an internal server error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+	})
+}
+
+func TestSitemapGenerations(t *testing.T) {
+	Convey("Given an api serving full sitemaps published as generations", t, func() {
+		store := newStoreMock(map[string]string{
+			"sitemap-en":                        "overwritten sitemap",
+			"sitemap-generations/g1/sitemap-en": "first generation",
+			"sitemap-generations/g2/sitemap-en": "second generation",
+		})
+		manifest := `{"current": "g1", "generations": [
+			{"id": "g2", "files": {"en": "sitemap-generations/g2/sitemap-en"}},
+			{"id": "g1", "files": {"en": "sitemap-generations/g1/sitemap-en"}}]}`
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader(manifest)), `"v1"`, nil
+		}
+		generations := sitemap.NewGenerations(store, "manifest.json", sitemap.Files{config.English: "sitemap-en"}, 2)
+		a := api.Setup(ctx, mux.NewRouter(), &config.Config{DpOnsURLHostNameEn: "https://www.ons.gov.uk/"}, store, generations, store, sitemap.Files{})
+
+		Convey("When the sitemap is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", nil)
+
+			Convey("Then the sitemap of the live generation is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "first generation")
+			})
+		})
+
+		Convey("When the manifest can not be read", func() {
+			store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+				return nil, "", errors.New("s3 error")
+			}
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap.xml", nil)
+
+			Convey("Then an internal server error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+	})
+}
+
+func TestSitemapIndexHandler(t *testing.T) {
+	Convey("Given an api with english and welsh sitemaps in the store", t, func() {
+		a := newTestAPI(newStoreMock(map[string]string{"sitemap-en": "english sitemap", "sitemap-cy": "welsh sitemap"}))
+
+		Convey("When the sitemap index is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap_index.xml", nil)
+
+			Convey("Then an index of both sitemaps is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "application/xml; charset=utf-8")
+				So(w.Header().Get("ETag"), ShouldStartWith, `W/"`)
+				So(w.Body.String(), ShouldContainSubstring, "<sitemapindex")
+				So(w.Body.String(), ShouldContainSubstring, "<loc>https://www.ons.gov.uk/sitemap_en.xml</loc>")
+				So(w.Body.String(), ShouldContainSubstring, "<loc>https://cy.ons.gov.uk/sitemap_cy.xml</loc>")
+				So(w.Body.String(), ShouldContainSubstring, "<lastmod>2024-01-02T03:04:05Z</lastmod>")
+			})
+
+			Convey("And requesting it again with its ETag returns not modified", func() {
+				w2 := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap_index.xml", map[string]string{"If-None-Match": w.Header().Get("ETag")})
+				So(w2.Code, ShouldEqual, http.StatusNotModified)
+			})
+		})
+	})
+
+	Convey("Given an api with only the english sitemap in the store", t, func() {
+		a := newTestAPI(newStoreMock(map[string]string{"sitemap-en": "english sitemap"}))
+
+		Convey("When the sitemap index is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap_index.xml", nil)
+
+			Convey("Then only the english sitemap is listed", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldContainSubstring, "sitemap_en.xml")
+				So(w.Body.String(), ShouldNotContainSubstring, "sitemap_cy.xml")
+			})
+		})
+	})
+
+	Convey("Given an api with no sitemaps in the store", t, func() {
+		a := newTestAPI(newStoreMock(map[string]string{}))
+
+		Convey("When the sitemap index is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/sitemap_index.xml", nil)
+
+			Convey("Then not found is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+}
+
+func TestRobotsHandler(t *testing.T) {
+	Convey("Given an api with english and welsh robots files in the store", t, func() {
+		a := newTestAPI(newStoreMock(map[string]string{"robots-en": "english robots", "robots-cy": "welsh robots"}))
+
+		Convey("When robots.txt is requested on the welsh host", func() {
+			w := doRequest(a, http.MethodGet, "https://cy.ons.gov.uk/robots.txt", nil)
+
+			Convey("Then the welsh robots file is returned as plain text", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "welsh robots")
+				So(w.Header().Get("Content-Type"), ShouldEqual, "text/plain; charset=utf-8")
+			})
+		})
+
+		Convey("When robots.txt is requested on the english host", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/robots.txt", nil)
+
+			Convey("Then the english robots file is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "english robots")
+			})
+		})
+	})
+}
+
+func TestRobotsStore(t *testing.T) {
+	Convey("Given an api serving the robots files from their own store", t, func() {
+		robotsStore := newStoreMock(map[string]string{"robots-en.txt": "english robots"})
+		a := api.Setup(
+			ctx,
+			mux.NewRouter(),
+			&config.Config{DpOnsURLHostNameEn: "https://www.ons.gov.uk/"},
+			newStoreMock(map[string]string{}),
+			sitemap.Files{config.English: "sitemap-en"},
+			robotsStore,
+			sitemap.Files{config.English: "robots-en.txt"},
+		)
+
+		Convey("When robots.txt is requested", func() {
+			w := doRequest(a, http.MethodGet, "https://www.ons.gov.uk/robots.txt", nil)
+
+			Convey("Then the robots file is read from the robots store", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldEqual, "english robots")
+			})
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// serveFile streams a file from store, setting the caching headers and answering conditional requests
+func (api *API) serveFile(w http.ResponseWriter, req *http.Request, store sitemap.FileStore, name, contentType string) {
+	ctx := req.Context()
+	logData := log.Data{"filename": name}
+
+	info, err := store.GetFileInfo(name)
+	if errors.Is(err, sitemap.ErrFileNotFound) {
+		log.Info(ctx, "requested file not found", logData)
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Error(ctx, "failed to get file info", err, logData)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if writeNotModified(w, req, info.ETag, info.ModTime) {
+		return
+	}
+
+	body, err := store.GetFile(name)
+	if err != nil {
+		log.Error(ctx, "failed to get file", err, logData)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		closeErr := body.Close()
+		if closeErr != nil {
+			log.Error(ctx, "failed to close file", closeErr, logData)
+		}
+	}()
+
+	w.Header().Set("Content-Type", contentType)
+	if req.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if _, err = io.Copy(w, body); err != nil {
+		log.Error(ctx, "failed to write file to response", err, logData)
+	}
+}
+
+// writeNotModified sets the ETag and Last-Modified headers and, if the request preconditions show
+// that the client copy is current, writes a 304 response and returns true
+func writeNotModified(w http.ResponseWriter, req *http.Request, etag string, modTime time.Time) bool {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+
+	if !isNotModified(req, etag, modTime) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// isNotModified evaluates If-None-Match, or If-Modified-Since when there is no If-None-Match, as per RFC 9110
+func isNotModified(req *http.Request, etag string, modTime time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+
+	ims := req.Header.Get("If-Modified-Since")
+	if ims == "" || modTime.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(t)
+}
+
+// etagMatches performs a weak comparison of etag against the list in an If-None-Match header
+func etagMatches(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
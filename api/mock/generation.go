@@ -0,0 +1,105 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"github.com/ONSdigital/dp-sitemap/api"
+	"sync"
+)
+
+// Ensure, that GenerationJobMock does implement api.GenerationJob.
+// If this is not the case, regenerate this file with moq.
+var _ api.GenerationJob = &GenerationJobMock{}
+
+// GenerationJobMock is a mock implementation of api.GenerationJob.
+//
+//	func TestSomethingThatUsesGenerationJob(t *testing.T) {
+//
+//		// make and configure a mocked api.GenerationJob
+//		mockedGenerationJob := &GenerationJobMock{
+//			StatusFunc: func() (api.GenerationStatus, error) {
+//				panic("mock out the Status method")
+//			},
+//			TriggerFunc: func() error {
+//				panic("mock out the Trigger method")
+//			},
+//		}
+//
+//		// use mockedGenerationJob in code that requires api.GenerationJob
+//		// and then make assertions.
+//
+//	}
+type GenerationJobMock struct {
+	// StatusFunc mocks the Status method.
+	StatusFunc func() (api.GenerationStatus, error)
+
+	// TriggerFunc mocks the Trigger method.
+	TriggerFunc func() error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Status holds details about calls to the Status method.
+		Status []struct {
+		}
+		// Trigger holds details about calls to the Trigger method.
+		Trigger []struct {
+		}
+	}
+	lockStatus  sync.RWMutex
+	lockTrigger sync.RWMutex
+}
+
+// Status calls StatusFunc.
+func (mock *GenerationJobMock) Status() (api.GenerationStatus, error) {
+	if mock.StatusFunc == nil {
+		panic("GenerationJobMock.StatusFunc: method is nil but GenerationJob.Status was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockStatus.Lock()
+	mock.calls.Status = append(mock.calls.Status, callInfo)
+	mock.lockStatus.Unlock()
+	return mock.StatusFunc()
+}
+
+// StatusCalls gets all the calls that were made to Status.
+// Check the length with:
+//
+//	len(mockedGenerationJob.StatusCalls())
+func (mock *GenerationJobMock) StatusCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockStatus.RLock()
+	calls = mock.calls.Status
+	mock.lockStatus.RUnlock()
+	return calls
+}
+
+// Trigger calls TriggerFunc.
+func (mock *GenerationJobMock) Trigger() error {
+	if mock.TriggerFunc == nil {
+		panic("GenerationJobMock.TriggerFunc: method is nil but GenerationJob.Trigger was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockTrigger.Lock()
+	mock.calls.Trigger = append(mock.calls.Trigger, callInfo)
+	mock.lockTrigger.Unlock()
+	return mock.TriggerFunc()
+}
+
+// TriggerCalls gets all the calls that were made to Trigger.
+// Check the length with:
+//
+//	len(mockedGenerationJob.TriggerCalls())
+func (mock *GenerationJobMock) TriggerCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockTrigger.RLock()
+	calls = mock.calls.Trigger
+	mock.lockTrigger.RUnlock()
+	return calls
+}
@@ -0,0 +1,68 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"github.com/ONSdigital/dp-sitemap/api"
+	"sync"
+)
+
+// Ensure, that RobotsReloaderMock does implement api.RobotsReloader.
+// If this is not the case, regenerate this file with moq.
+var _ api.RobotsReloader = &RobotsReloaderMock{}
+
+// RobotsReloaderMock is a mock implementation of api.RobotsReloader.
+//
+//	func TestSomethingThatUsesRobotsReloader(t *testing.T) {
+//
+//		// make and configure a mocked api.RobotsReloader
+//		mockedRobotsReloader := &RobotsReloaderMock{
+//			ReloadFunc: func() error {
+//				panic("mock out the Reload method")
+//			},
+//		}
+//
+//		// use mockedRobotsReloader in code that requires api.RobotsReloader
+//		// and then make assertions.
+//
+//	}
+type RobotsReloaderMock struct {
+	// ReloadFunc mocks the Reload method.
+	ReloadFunc func() error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Reload holds details about calls to the Reload method.
+		Reload []struct {
+		}
+	}
+	lockReload sync.RWMutex
+}
+
+// Reload calls ReloadFunc.
+func (mock *RobotsReloaderMock) Reload() error {
+	if mock.ReloadFunc == nil {
+		panic("RobotsReloaderMock.ReloadFunc: method is nil but RobotsReloader.Reload was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockReload.Lock()
+	mock.calls.Reload = append(mock.calls.Reload, callInfo)
+	mock.lockReload.Unlock()
+	return mock.ReloadFunc()
+}
+
+// ReloadCalls gets all the calls that were made to Reload.
+// Check the length with:
+//
+//	len(mockedRobotsReloader.ReloadCalls())
+func (mock *RobotsReloaderMock) ReloadCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockReload.RLock()
+	calls = mock.calls.Reload
+	mock.lockReload.RUnlock()
+	return calls
+}
@@ -0,0 +1,146 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/ONSdigital/dp-sitemap/api"
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/event"
+	"sync"
+)
+
+// Ensure, that URLUpdaterMock does implement api.URLUpdater.
+// If this is not the case, regenerate this file with moq.
+var _ api.URLUpdater = &URLUpdaterMock{}
+
+// URLUpdaterMock is a mock implementation of api.URLUpdater.
+//
+//	func TestSomethingThatUsesURLUpdater(t *testing.T) {
+//
+//		// make and configure a mocked api.URLUpdater
+//		mockedURLUpdater := &URLUpdaterMock{
+//			HandleFunc: func(ctx context.Context, cfg *config.Config, contentPublished *event.ContentPublished) error {
+//				panic("mock out the Handle method")
+//			},
+//			RemoveFunc: func(ctx context.Context, cfg *config.Config, path string) error {
+//				panic("mock out the Remove method")
+//			},
+//		}
+//
+//		// use mockedURLUpdater in code that requires api.URLUpdater
+//		// and then make assertions.
+//
+//	}
+type URLUpdaterMock struct {
+	// HandleFunc mocks the Handle method.
+	HandleFunc func(ctx context.Context, cfg *config.Config, contentPublished *event.ContentPublished) error
+
+	// RemoveFunc mocks the Remove method.
+	RemoveFunc func(ctx context.Context, cfg *config.Config, path string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Handle holds details about calls to the Handle method.
+		Handle []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Cfg is the cfg argument value.
+			Cfg *config.Config
+			// ContentPublished is the contentPublished argument value.
+			ContentPublished *event.ContentPublished
+		}
+		// Remove holds details about calls to the Remove method.
+		Remove []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Cfg is the cfg argument value.
+			Cfg *config.Config
+			// Path is the path argument value.
+			Path string
+		}
+	}
+	lockHandle sync.RWMutex
+	lockRemove sync.RWMutex
+}
+
+// Handle calls HandleFunc.
+func (mock *URLUpdaterMock) Handle(ctx context.Context, cfg *config.Config, contentPublished *event.ContentPublished) error {
+	if mock.HandleFunc == nil {
+		panic("URLUpdaterMock.HandleFunc: method is nil but URLUpdater.Handle was just called")
+	}
+	callInfo := struct {
+		Ctx              context.Context
+		Cfg              *config.Config
+		ContentPublished *event.ContentPublished
+	}{
+		Ctx:              ctx,
+		Cfg:              cfg,
+		ContentPublished: contentPublished,
+	}
+	mock.lockHandle.Lock()
+	mock.calls.Handle = append(mock.calls.Handle, callInfo)
+	mock.lockHandle.Unlock()
+	return mock.HandleFunc(ctx, cfg, contentPublished)
+}
+
+// HandleCalls gets all the calls that were made to Handle.
+// Check the length with:
+//
+//	len(mockedURLUpdater.HandleCalls())
+func (mock *URLUpdaterMock) HandleCalls() []struct {
+	Ctx              context.Context
+	Cfg              *config.Config
+	ContentPublished *event.ContentPublished
+} {
+	var calls []struct {
+		Ctx              context.Context
+		Cfg              *config.Config
+		ContentPublished *event.ContentPublished
+	}
+	mock.lockHandle.RLock()
+	calls = mock.calls.Handle
+	mock.lockHandle.RUnlock()
+	return calls
+}
+
+// Remove calls RemoveFunc.
+func (mock *URLUpdaterMock) Remove(ctx context.Context, cfg *config.Config, path string) error {
+	if mock.RemoveFunc == nil {
+		panic("URLUpdaterMock.RemoveFunc: method is nil but URLUpdater.Remove was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Cfg  *config.Config
+		Path string
+	}{
+		Ctx:  ctx,
+		Cfg:  cfg,
+		Path: path,
+	}
+	mock.lockRemove.Lock()
+	mock.calls.Remove = append(mock.calls.Remove, callInfo)
+	mock.lockRemove.Unlock()
+	return mock.RemoveFunc(ctx, cfg, path)
+}
+
+// RemoveCalls gets all the calls that were made to Remove.
+// Check the length with:
+//
+//	len(mockedURLUpdater.RemoveCalls())
+func (mock *URLUpdaterMock) RemoveCalls() []struct {
+	Ctx  context.Context
+	Cfg  *config.Config
+	Path string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Cfg  *config.Config
+		Path string
+	}
+	mock.lockRemove.RLock()
+	calls = mock.calls.Remove
+	mock.lockRemove.RUnlock()
+	return calls
+}
@@ -0,0 +1,14 @@
+package api
+
+import "net/http"
+
+// RobotsHandler serves the robots file for the language of the request
+func (api *API) RobotsHandler(w http.ResponseWriter, req *http.Request) {
+	lang := api.requestLanguage(req)
+	name, ok := api.robotsFiles[lang]
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	api.serveFile(w, req, api.robotsStore, name, contentTypeText)
+}
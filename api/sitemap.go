@@ -0,0 +1,107 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// SitemapHandler serves the full sitemap for the language of the request
+func (api *API) SitemapHandler(w http.ResponseWriter, req *http.Request) {
+	lang := api.requestLanguage(req)
+	files, err := api.sitemapFiles.LiveFiles()
+	if err != nil {
+		log.Error(req.Context(), "failed to get live sitemap files", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	name, ok := files[lang]
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	api.serveFile(w, req, api.store, name, contentTypeXML)
+}
+
+// SitemapIndexHandler serves a sitemap index listing the sitemaps of every language that has been generated
+func (api *API) SitemapIndexHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	index := sitemap.SitemapIndex{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+	}
+	etagHash := sha256.New()
+	var lastModified time.Time
+
+	files, err := api.sitemapFiles.LiveFiles()
+	if err != nil {
+		log.Error(ctx, "failed to get live sitemap files", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		name, ok := files[lang]
+		if !ok {
+			continue
+		}
+		info, err := api.store.GetFileInfo(name)
+		if errors.Is(err, sitemap.ErrFileNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Error(ctx, "failed to get sitemap file info", err, log.Data{"filename": name})
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		loc, err := url.JoinPath(api.hostNames[lang], "sitemap_"+lang.String()+".xml")
+		if err != nil {
+			log.Error(ctx, "failed to build sitemap url", err, log.Data{"host_name": api.hostNames[lang]})
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		entry := sitemap.IndexSitemap{Loc: loc}
+		if !info.ModTime.IsZero() {
+			entry.Lastmod = info.ModTime.UTC().Format(time.RFC3339)
+		}
+		index.Sitemap = append(index.Sitemap, entry)
+
+		fmt.Fprintf(etagHash, "%s:%s;", lang, info.ETag)
+		if info.ModTime.After(lastModified) {
+			lastModified = info.ModTime
+		}
+	}
+
+	if len(index.Sitemap) == 0 {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	etag := fmt.Sprintf(`W/"%x"`, etagHash.Sum(nil))
+	if writeNotModified(w, req, etag, lastModified) {
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypeXML)
+	if req.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		log.Error(ctx, "failed to write sitemap index header", err)
+		return
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(index); err != nil {
+		log.Error(ctx, "failed to encode sitemap index", err)
+	}
+}
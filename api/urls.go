@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/dp-sitemap/event"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+const (
+	maxURLRequestSize = 4096
+	requestIDSize     = 16
+)
+
+// URLRequest is the body of a request to add a page to the sitemaps
+type URLRequest struct {
+	URI string `json:"uri"`
+}
+
+// AddURLHandler adds a single page, given by its URI in the request body, to the sitemaps
+func (api *API) AddURLHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := withRequestID(req)
+
+	var body URLRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxURLRequestSize)).Decode(&body)
+	if err != nil {
+		log.Info(ctx, "invalid add url request body", log.Data{"error": err.Error()})
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err = validateURI(body.URI); err != nil {
+		log.Info(ctx, "invalid add url request", log.Data{"uri": body.URI, "error": err.Error()})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = api.urlUpdater.Handle(ctx, api.cfg, &event.ContentPublished{
+		URI:     body.URI,
+		TraceID: request.GetRequestId(ctx),
+	})
+	audit(ctx, req, "add", body.URI, err)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveURLHandler removes a single page, given by the "uri" query parameter, from the sitemaps
+func (api *API) RemoveURLHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := withRequestID(req)
+
+	uri := req.URL.Query().Get("uri")
+	if err := validateURI(uri); err != nil {
+		log.Info(ctx, "invalid remove url request", log.Data{"uri": uri, "error": err.Error()})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := api.urlUpdater.Remove(ctx, api.cfg, uri)
+	audit(ctx, req, "remove", uri, err)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateURI checks that uri is a clean, site relative path
+func validateURI(uri string) error {
+	if uri == "" {
+		return errors.New("uri is required")
+	}
+	if !strings.HasPrefix(uri, "/") {
+		return errors.New("uri must be a path starting with /")
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("uri is not valid: %w", err)
+	}
+	if u.Scheme != "" || u.Host != "" || u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("uri must be a path without host, query or fragment")
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == "." || segment == ".." {
+			return errors.New("uri must not contain relative path segments")
+		}
+	}
+	return nil
+}
+
+// withRequestID returns the request context with the request ID from its header, or a new one if there is none
+func withRequestID(req *http.Request) context.Context {
+	requestID := req.Header.Get(request.RequestHeaderKey)
+	if requestID == "" {
+		requestID = request.NewRequestID(requestIDSize)
+	}
+	return request.WithRequestId(req.Context(), requestID)
+}
+
+// audit logs the outcome of an admin change to the sitemaps
+func audit(ctx context.Context, req *http.Request, action, uri string, err error) {
+	logData := log.Data{
+		"audit":       true,
+		"action":      action,
+		"uri":         uri,
+		"remote_addr": req.RemoteAddr,
+		"user_agent":  req.UserAgent(),
+	}
+	if err != nil {
+		logData["outcome"] = "failure"
+		log.Error(ctx, "admin sitemap url update failed", err, logData)
+		return
+	}
+	logData["outcome"] = "success"
+	log.Info(ctx, "admin sitemap url update", logData)
+}
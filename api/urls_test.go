@@ -0,0 +1,163 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/dp-sitemap/api"
+	"github.com/ONSdigital/dp-sitemap/api/mock"
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/event"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	sitemapmock "github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func doBodyRequest(a *api.API, method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestURLAdmin(t *testing.T) {
+	Convey("Given an api with admin endpoints", t, func() {
+		updater := &mock.URLUpdaterMock{
+			HandleFunc: func(ctx context.Context, cfg *config.Config, contentPublished *event.ContentPublished) error {
+				return nil
+			},
+			RemoveFunc: func(ctx context.Context, cfg *config.Config, path string) error { return nil },
+		}
+		a := newTestAPI(newStoreMock(map[string]string{}))
+		a.SetupAdmin(ctx, testAuthToken, &mock.GenerationJobMock{}, updater, &mock.RobotsReloaderMock{})
+
+		Convey("When a url is added without a token", func() {
+			w := doBodyRequest(a, http.MethodPost, "/admin/urls", `{"uri": "/economy"}`, nil)
+
+			Convey("Then unauthorised is returned and the sitemaps are not updated", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(updater.HandleCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When a url is added", func() {
+			headers := map[string]string{"Authorization": "Bearer " + testAuthToken, request.RequestHeaderKey: "test-request-id"}
+			w := doBodyRequest(a, http.MethodPost, "/admin/urls", `{"uri": "/economy/inflation"}`, headers)
+
+			Convey("Then it goes through the content published path", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(updater.HandleCalls(), ShouldHaveLength, 1)
+				So(updater.HandleCalls()[0].ContentPublished.URI, ShouldEqual, "/economy/inflation")
+				So(updater.HandleCalls()[0].ContentPublished.TraceID, ShouldEqual, "test-request-id")
+				So(request.GetRequestId(updater.HandleCalls()[0].Ctx), ShouldEqual, "test-request-id")
+			})
+		})
+
+		Convey("When a url is added with an invalid body", func() {
+			w := doBodyRequest(a, http.MethodPost, "/admin/urls", `{"uri":`, authHeader)
+
+			Convey("Then bad request is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(updater.HandleCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When invalid uris are added", func() {
+			for _, uri := range []string{"", "economy", "https://www.ons.gov.uk/economy", "//www.ons.gov.uk/economy", "/economy?a=b", "/economy#a", "/economy/../admin"} {
+				w := doBodyRequest(a, http.MethodPost, "/admin/urls", `{"uri": "`+uri+`"}`, authHeader)
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+			}
+
+			Convey("Then the sitemaps are not updated", func() {
+				So(updater.HandleCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When adding a url fails", func() {
+			updater.HandleFunc = func(ctx context.Context, cfg *config.Config, contentPublished *event.ContentPublished) error {
+				return errors.New("zebedee error")
+			}
+			w := doBodyRequest(a, http.MethodPost, "/admin/urls", `{"uri": "/economy"}`, authHeader)
+
+			Convey("Then an internal server error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+
+		Convey("When a url is removed", func() {
+			w := doBodyRequest(a, http.MethodDelete, "/admin/urls?uri=/economy/inflation", "", authHeader)
+
+			Convey("Then it is removed from the sitemaps", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(updater.RemoveCalls(), ShouldHaveLength, 1)
+				So(updater.RemoveCalls()[0].Path, ShouldEqual, "/economy/inflation")
+			})
+		})
+
+		Convey("When a url is removed without a uri", func() {
+			w := doBodyRequest(a, http.MethodDelete, "/admin/urls", "", authHeader)
+
+			Convey("Then bad request is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(updater.RemoveCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("When removing a url fails", func() {
+			updater.RemoveFunc = func(ctx context.Context, cfg *config.Config, path string) error {
+				return errors.New("store error")
+			}
+			w := doBodyRequest(a, http.MethodDelete, "/admin/urls?uri=/economy", "", authHeader)
+
+			Convey("Then an internal server error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			})
+		})
+	})
+}
+
+func TestURLAdminAddTwice(t *testing.T) {
+	Convey("Given an api adding urls to local sitemaps through the content published handler", t, func() {
+		dir := t.TempDir()
+		cfg := &config.Config{}
+		files := sitemap.Files{config.English: filepath.Join(dir, "sitemap-en.xml"), config.Welsh: filepath.Join(dir, "sitemap-cy.xml")}
+		lastmod := "2024-01-01"
+		fetcher := &sitemapmock.FetcherMock{
+			GetPageInfoFunc: func(ctx context.Context, path string) (*sitemap.PageInfo, error) {
+				return &sitemap.PageInfo{
+					ReleaseDate: lastmod,
+					URLs:        map[config.Language]*sitemap.URL{config.English: {Loc: "https://www.ons.gov.uk" + path, Lastmod: lastmod}},
+				}, nil
+			},
+		}
+		handler := event.NewContentPublishedHandler(&sitemap.LocalStore{}, files, nil, cfg, fetcher)
+		a := newTestAPI(newStoreMock(map[string]string{}))
+		a.SetupAdmin(ctx, testAuthToken, &mock.GenerationJobMock{}, handler, &mock.RobotsReloaderMock{})
+
+		Convey("When the same url is added twice", func() {
+			w := doBodyRequest(a, http.MethodPost, "/admin/urls", `{"uri": "/economy/inflation"}`, authHeader)
+			So(w.Code, ShouldEqual, http.StatusNoContent)
+			lastmod = "2024-02-01"
+			w = doBodyRequest(a, http.MethodPost, "/admin/urls", `{"uri": "/economy/inflation"}`, authHeader)
+			So(w.Code, ShouldEqual, http.StatusNoContent)
+
+			Convey("Then the sitemap lists it once with the latest lastmod", func() {
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				So(strings.Count(string(content), "<loc>https://www.ons.gov.uk/economy/inflation</loc>"), ShouldEqual, 1)
+				So(string(content), ShouldContainSubstring, "<lastmod>2024-02-01</lastmod>")
+				So(string(content), ShouldNotContainSubstring, "<lastmod>2024-01-01</lastmod>")
+			})
+		})
+	})
+}
@@ -32,6 +32,10 @@ func GetRootCommand() *cobra.Command {
 	rootCmd.AddCommand(setupGenerateCmd())
 	rootCmd.AddCommand(setupUpdateCmd())
 	rootCmd.AddCommand(setupLoadStaticSitemapCmd())
+	rootCmd.AddCommand(setupRollbackCmd())
+	rootCmd.AddCommand(setupValidateCmd())
+	rootCmd.AddCommand(setupDiffCmd())
+	rootCmd.AddCommand(setupRobotsCmd())
 	return rootCmd
 }
 
@@ -67,6 +71,8 @@ func setupGenerateCmd() *cobra.Command {
 				SitemapPathReader:    viper.GetString(utilities.SitemapPathReaderFlag),
 				ZebedeeURL:           viper.GetString(utilities.ZebedeeURLFlag),
 				FakeScroll:           viper.GetBool(utilities.FakeScrollFlag),
+				Force:                viper.GetBool(utilities.ForceFlag),
+				RobotsCheck:          viper.GetString(utilities.RobotsCheckFlag),
 			}
 			utilities.CmdFlagFields = &flagList
 			utilities.GenerateSitemap(cfg, &flagList)
@@ -74,6 +80,8 @@ func setupGenerateCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().Bool(utilities.ForceFlag, false, "publish the sitemap even if it has lost too many urls")
+	cmd.Flags().String(utilities.RobotsCheckFlag, "", "warn or drop the sitemap urls disallowed by the robots rules (default SITEMAP_ROBOTS_CHECK)")
 
 	return cmd
 }
@@ -110,6 +118,15 @@ func setupUpdateCmd() *cobra.Command {
 				SitemapPathReader:    viper.GetString(utilities.SitemapPathReaderFlag),
 				ZebedeeURL:           viper.GetString(utilities.ZebedeeURLFlag),
 				FakeScroll:           viper.GetBool(utilities.FakeScrollFlag),
+				Store:                viper.GetString(utilities.StoreFlag),
+				EventFile:            viper.GetString(utilities.EventFileFlag),
+				URIFile:              viper.GetString(utilities.URIFileFlag),
+				URI:                  viper.GetString(utilities.URIFlag),
+				DataType:             viper.GetString(utilities.DataTypeFlag),
+				CollectionID:         viper.GetString(utilities.CollectionIDFlag),
+				JobID:                viper.GetString(utilities.JobIDFlag),
+				SearchIndex:          viper.GetString(utilities.SearchIndexFlag),
+				TraceID:              viper.GetString(utilities.TraceIDFlag),
 			}
 			utilities.CmdFlagFields = &flagList
 			err = utilities.UpdateSitemap(cfg, &flagList)
@@ -120,6 +137,15 @@ func setupUpdateCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().String(utilities.StoreFlag, "", "store of the sitemaps to update, local or s3 (default SITEMAP_SAVE_LOCATION)")
+	cmd.Flags().String(utilities.EventFileFlag, "", "JSON file holding a content published event or an array of them")
+	cmd.Flags().String(utilities.URIFileFlag, "", "file listing the URIs to update, one per line, - for stdin")
+	cmd.Flags().String(utilities.URIFlag, "", "URI of the published content")
+	cmd.Flags().String(utilities.DataTypeFlag, "", "data type of the published content")
+	cmd.Flags().String(utilities.CollectionIDFlag, "", "collection ID of the published content")
+	cmd.Flags().String(utilities.JobIDFlag, "", "job ID of the published content")
+	cmd.Flags().String(utilities.SearchIndexFlag, "", "search index of the published content")
+	cmd.Flags().String(utilities.TraceIDFlag, "", "trace ID of the published content")
 	return cmd
 }
 
@@ -138,12 +164,118 @@ func setupLoadStaticSitemapCmd() *cobra.Command {
 				RobotsFilePathReader: viper.GetString(utilities.RobotsFilePathReaderFlag),
 				SitemapPath:          viper.GetString(utilities.SitemapPathFlag),
 				SitemapPathReader:    viper.GetString(utilities.SitemapPathReaderFlag),
+				DryRun:               viper.GetBool(utilities.DryRunFlag),
+				RobotsCheck:          viper.GetString(utilities.RobotsCheckFlag),
 			}
 			utilities.CmdFlagFields = &flagList
-			utilities.LoadStaticSitemap(cfg, &flagList)
+			cmd.SilenceUsage = true
+			return utilities.LoadStaticSitemap(cfg, &flagList, os.Stdout)
+		},
+	}
+	cmd.Flags().Bool(utilities.DryRunFlag, false, "print the static sitemaps instead of writing them")
+	cmd.Flags().String(utilities.RobotsCheckFlag, "", "warn or drop the sitemap urls disallowed by the robots rules (default SITEMAP_ROBOTS_CHECK)")
+	return cmd
+}
+
+func setupRollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll the full sitemaps back to a previous generation",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Get()
+			if err != nil {
+				fmt.Println("Error retrieving config" + err.Error())
+				os.Exit(1)
+			}
+
+			if viper.GetBool(utilities.ListFlag) {
+				return utilities.ListSitemapGenerations(cfg)
+			}
+			return utilities.RollbackSitemap(cfg, viper.GetString(utilities.GenerationFlag))
+		},
+	}
+	cmd.Flags().String(utilities.GenerationFlag, "", "ID of the generation to roll back to (default the generation before the live one)")
+	cmd.Flags().Bool(utilities.ListFlag, false, "list the kept generations instead of rolling back")
+	return cmd
+}
+
+func setupValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [sitemap files...]",
+		Short: "Validate sitemap files, or the live full sitemaps if none are given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Get()
+			if err != nil {
+				fmt.Println("Error retrieving config" + err.Error())
+				os.Exit(1)
+			}
+
+			cmd.SilenceUsage = true
+			return utilities.ValidateSitemaps(cfg, args, os.Stdout)
+		},
+	}
+	return cmd
+}
+
+func setupDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff [old sitemap] [new sitemap]",
+		Short: "Compare two sitemaps, or two generations of the full sitemaps if none are given",
+		Args: cobra.MatchAll(cobra.MaximumNArgs(2), func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return fmt.Errorf("expected an old and a new sitemap")
+			}
 			return nil
+		}),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Get()
+			if err != nil {
+				fmt.Println("Error retrieving config" + err.Error())
+				os.Exit(1)
+			}
+
+			cmd.SilenceUsage = true
+			if len(args) == 2 {
+				return utilities.DiffSitemapFiles(cfg, args[0], args[1], viper.GetBool(utilities.StoredFlag), viper.GetBool(utilities.JSONFlag), os.Stdout)
+			}
+			return utilities.DiffSitemapGenerations(cfg, viper.GetString(utilities.FromFlag), viper.GetString(utilities.ToFlag), viper.GetBool(utilities.JSONFlag), os.Stdout)
+		},
+	}
+	cmd.Flags().Bool(utilities.StoredFlag, false, "read the sitemaps from the store of the service instead of local files")
+	cmd.Flags().Bool(utilities.JSONFlag, false, "write the differences as JSON")
+	cmd.Flags().String(utilities.FromFlag, "", "ID of the old generation (default the generation before the live one)")
+	cmd.Flags().String(utilities.ToFlag, "", "ID of the new generation (default the live generation)")
+	return cmd
+}
+
+func setupRobotsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "robots",
+		Short: "Check the robots rules",
+	}
+	testCmd := &cobra.Command{
+		Use:   "test [urls...]",
+		Short: "Report whether the robots rules allow the user agents to crawl the urls and the live full sitemaps",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Get()
+			if err != nil {
+				fmt.Println("Error retrieving config" + err.Error())
+				os.Exit(1)
+			}
+
+			flagList := utilities.FlagFields{
+				RobotsFilePathReader: viper.GetString(utilities.RobotsFilePathReaderFlag),
+				UserAgents:           viper.GetStringSlice(utilities.UserAgentFlag),
+				CheckSitemap:         viper.GetBool(utilities.CheckSitemapFlag),
+			}
+			utilities.CmdFlagFields = &flagList
+			cmd.SilenceUsage = true
+			return utilities.CheckRobots(cfg, &flagList, args, os.Stdout)
 		},
 	}
+	testCmd.Flags().StringSlice(utilities.UserAgentFlag, []string{"*", "Googlebot"}, "user agents to test the robots rules for")
+	testCmd.Flags().Bool(utilities.CheckSitemapFlag, true, "check that no url of the live full sitemaps is blocked by the robots rules")
+	cmd.AddCommand(testCmd)
 	return cmd
 }
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"os"
 
 	"github.com/ONSdigital/log.go/v2/log"
 )
@@ -12,6 +13,6 @@ func main() {
 	cmdErr := GetRootCommand().Execute()
 	if cmdErr != nil {
 		log.Error(ctx, "error initialising the CLI tool", cmdErr)
-		return
+		os.Exit(1)
 	}
 }
@@ -14,18 +14,52 @@ const (
 	SitemapPathReaderFlag    = "sitemap-file-path-reader"
 	ZebedeeURLFlag           = "zebedee-url"
 	FakeScrollFlag           = "fake-scroll"
+	GenerationFlag           = "generation"
+	ListFlag                 = "list"
+	ForceFlag                = "force"
+	StoredFlag               = "stored"
+	JSONFlag                 = "json"
+	FromFlag                 = "from"
+	ToFlag                   = "to"
+	StoreFlag                = "store"
+	EventFileFlag            = "event-file"
+	URIFileFlag              = "uri-file"
+	URIFlag                  = "uri"
+	DataTypeFlag             = "data-type"
+	CollectionIDFlag         = "collection-id"
+	JobIDFlag                = "job-id"
+	SearchIndexFlag          = "search-index"
+	TraceIDFlag              = "trace-id"
+	DryRunFlag               = "dry-run"
+	UserAgentFlag            = "user-agent"
+	CheckSitemapFlag         = "check-sitemap"
+	RobotsCheckFlag          = "robots-check"
 )
 
 // Config represents service configuration for dp-sitemap
 type FlagFields struct {
-	RobotsFilePath       string // path to the robots file that will be generated
-	RobotsFilePathReader string // path to the robots file that we are reading from
-	ElasticSearchURL     string // elastic search url
-	ElasticSearchIndex   string // elastic search index name
-	ScrollTimeout        string // elastic search scroll timeout
-	ScrollSize           int    // elastic search scroll size
-	SitemapPath          string // path to the sitemap file that will be generated
-	SitemapPathReader    string // path to the sitemap file that we are reading from
-	ZebedeeURL           string // zebedee url
-	FakeScroll           bool   // toggle to use or not the fake scroll implementation that replicates elastic search
+	RobotsFilePath       string   // path to the robots file that will be generated
+	RobotsFilePathReader string   // path to the robots file that we are reading from
+	ElasticSearchURL     string   // elastic search url
+	ElasticSearchIndex   string   // elastic search index name
+	ScrollTimeout        string   // elastic search scroll timeout
+	ScrollSize           int      // elastic search scroll size
+	SitemapPath          string   // path to the sitemap file that will be generated
+	SitemapPathReader    string   // path to the sitemap file that we are reading from
+	ZebedeeURL           string   // zebedee url
+	FakeScroll           bool     // toggle to use or not the fake scroll implementation that replicates elastic search
+	Force                bool     // publish the sitemap whatever its url count drop
+	Store                string   // store of the sitemaps to update, "local" or "s3", overriding SITEMAP_SAVE_LOCATION
+	EventFile            string   // path to a JSON file holding the content published events to apply
+	URIFile              string   // path to a newline delimited list of URIs to update, "-" for stdin
+	URI                  string   // URI of the published content to update
+	DataType             string   // data type of the published content
+	CollectionID         string   // collection ID of the published content
+	JobID                string   // job ID of the published content
+	SearchIndex          string   // search index of the published content
+	TraceID              string   // trace ID of the published content
+	DryRun               bool     // print the static sitemaps instead of writing them
+	UserAgents           []string // user agents the robots rules are tested for
+	CheckSitemap         bool     // check that no url of the live full sitemaps is blocked by the robots rules
+	RobotsCheck          string   // "warn" or "drop" the sitemap urls blocked by the robots rules, overriding SITEMAP_ROBOTS_CHECK
 }
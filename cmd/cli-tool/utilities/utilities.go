@@ -2,24 +2,36 @@ package utilities
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/zebedee"
 	"github.com/ONSdigital/dp-net/v2/awsauth"
 	dphttp "github.com/ONSdigital/dp-net/v2/http"
 	"github.com/ONSdigital/dp-sitemap/config"
 	"github.com/ONSdigital/dp-sitemap/event"
+	"github.com/ONSdigital/dp-sitemap/lock"
 	"github.com/ONSdigital/dp-sitemap/robotseo"
+	"github.com/ONSdigital/dp-sitemap/service"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
 	es710 "github.com/elastic/go-elasticsearch/v7"
 )
 
-func createCliSitemapGenerator(cfg *config.Config, commandline *FlagFields) (*sitemap.Generator, error) {
-	store := &sitemap.LocalStore{}
+// createScroll returns the fake scroll, or a scroll through the search index given on the command line
+func createScroll(cfg *config.Config, commandline *FlagFields) (sitemap.Scroll, error) {
+	if commandline.FakeScroll {
+		return NewFakeScroll(), nil
+	}
 
 	var transport http.RoundTripper = dphttp.DefaultTransport
 
@@ -39,21 +51,21 @@ func createCliSitemapGenerator(cfg *config.Config, commandline *FlagFields) (*si
 	if err != nil {
 		return nil, err
 	}
+	return sitemap.NewElasticScroll(rawClient, cfg), nil
+}
 
-	// Get zebedeeClient using arg -zebedee-url
-	zebedeeClient := zebedee.New(commandline.ZebedeeURL)
+func createCliSitemapGenerator(cfg *config.Config, commandline *FlagFields) (*sitemap.Generator, error) {
+	store := &sitemap.LocalStore{}
 
-	var scroll sitemap.Scroll
-	if commandline.FakeScroll {
-		scroll = NewFakeScroll()
-	} else {
-		scroll = sitemap.NewElasticScroll(
-			rawClient,
-			cfg,
-		)
+	scroll, err := createScroll(cfg, commandline)
+	if err != nil {
+		return nil, err
 	}
 
-	generator := sitemap.NewGenerator(
+	// Get zebedeeClient using arg -zebedee-url
+	zebedeeClient := zebedee.New(commandline.ZebedeeURL)
+
+	options := []sitemap.GeneratorOptions{
 		sitemap.WithFetcher(sitemap.NewElasticFetcher(
 			scroll,
 			cfg,
@@ -65,11 +77,39 @@ func createCliSitemapGenerator(cfg *config.Config, commandline *FlagFields) (*si
 			config.English: commandline.SitemapPath + "_en.xml",
 			config.Welsh:   commandline.SitemapPath + "_cy.xml",
 		}),
-	)
+		sitemap.WithMaxURLDropPercent(cfg.SitemapMaxPublishDropPercent),
+		sitemap.WithForce(commandline.Force || cfg.SitemapForcePublish),
+	}
+	if cfg.SitemapStaticMerge {
+		options = append(options, sitemap.WithStaticPages(sitemap.NewStaticPages(cfg, cfg.SitemapStaticDir)))
+	}
+	robotsCheck, err := createRobotsCheck(cfg, commandline)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, sitemap.WithRobotsCheck(robotsCheck))
+	generator := sitemap.NewGenerator(options...)
 
 	return generator, nil
 }
 
+// createRobotsCheck returns the check of the sitemap urls against the robots rules of RobotsFilePathReader, or the
+// embedded ones, in the mode given on the command line or by the config, or nil if there is none
+func createRobotsCheck(cfg *config.Config, commandLine *FlagFields) (*sitemap.RobotsCheck, error) {
+	mode := commandLine.RobotsCheck
+	if mode == "" {
+		mode = cfg.SitemapRobotsCheck
+	}
+	if mode == "" {
+		return nil, nil
+	}
+	robots, err := robotseo.NewRobotFileWriter(commandLine.RobotsFilePathReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the robots config: %w", err)
+	}
+	return sitemap.NewRobotsCheck(robots, mode)
+}
+
 func GenerateSitemap(cfg *config.Config, commandline *FlagFields) {
 	generator, err := createCliSitemapGenerator(cfg, commandline)
 	if err != nil {
@@ -78,17 +118,20 @@ func GenerateSitemap(cfg *config.Config, commandline *FlagFields) {
 	}
 
 	// Generating sitemap
-	genErr := generator.MakeFullSitemap(context.Background())
+	result, genErr := generator.MakeFullSitemap(context.Background())
 	if genErr != nil {
 		fmt.Println("Error writing sitemap file", genErr.Error())
 		os.Exit(1)
 	}
-	fmt.Println("sitemap generation job complete")
+	fmt.Println("sitemap generation job complete", result.URLCounts)
 }
 
 func GenerateRobotFile(cfg *config.Config, commandline *FlagFields) {
-	robotseo.Init(commandline.RobotsFilePathReader)
-	robotFileWriter := robotseo.RobotFileWriter{}
+	robotFileWriter, err := robotseo.NewRobotFileWriter(commandline.RobotsFilePathReader)
+	if err != nil {
+		fmt.Println("failed to read the robots config:", err)
+		return
+	}
 	cfg.RobotsFilePath = map[config.Language]string{
 		config.English: commandline.RobotsFilePath,
 	}
@@ -99,7 +142,12 @@ func GenerateRobotFile(cfg *config.Config, commandline *FlagFields) {
 	cfg.OpenSearchConfig.ScrollSize = commandline.ScrollSize
 	cfg.OpenSearchConfig.Signer = true
 
-	body := robotFileWriter.GetRobotsFileBody(config.English, cfg.SitemapLocalFile)
+	sitemapURL, err := robotseo.SitemapURL(cfg, config.English)
+	if err != nil {
+		fmt.Println("failed to get the sitemap url:", err)
+		return
+	}
+	body := robotFileWriter.GetRobotsFileBody(config.English, sitemapURL)
 
 	saveErr := store.SaveFile(commandline.RobotsFilePath, strings.NewReader(body))
 	if saveErr != nil {
@@ -109,52 +157,500 @@ func GenerateRobotFile(cfg *config.Config, commandline *FlagFields) {
 	fmt.Println("robot file creation successful")
 }
 
+// UpdateSitemap adds the pages of the content published events given on the command line to the
+// live sitemaps, prompting for a single event if none is given
 func UpdateSitemap(cfg *config.Config, commandLine *FlagFields) error {
-	var scroll sitemap.Scroll
-	if commandLine.FakeScroll {
-		scroll = &FakeScroll{}
-	} else {
-		scroll = &sitemap.ElasticScroll{}
+	switch commandLine.Store {
+	case "":
+	case "local", "s3":
+		cfg.SitemapSaveLocation = commandLine.Store
+	default:
+		return fmt.Errorf("invalid store %q, expected local or s3", commandLine.Store)
 	}
-	var store sitemap.FileStore
-	if cfg.SitemapSaveLocation == "local" {
-		store = &sitemap.LocalStore{}
-	} else {
-		store = &sitemap.S3Store{}
+
+	events, err := getEvents(commandLine)
+	if err != nil {
+		fmt.Println("Failed to get event content from user:", err)
+		return err
+	}
+
+	scroll, err := createScroll(cfg, commandLine)
+	if err != nil {
+		return err
+	}
+	store, files, manifest, err := createStore(cfg)
+	if err != nil {
+		return err
 	}
 	zebedeeClient := zebedee.New(commandLine.ZebedeeURL)
 	fetcher := sitemap.NewElasticFetcher(scroll, cfg, zebedeeClient)
-	handler := event.NewContentPublishedHandler(store, zebedeeClient, cfg, fetcher)
-	content, contentErr := getContent()
-	if contentErr != nil {
-		fmt.Println("Failed to get event content from user:", contentErr)
-		return contentErr
+	generations := sitemap.NewGenerations(store, manifest, files, cfg.SitemapGenerationsKept)
+	var opts []event.HandlerOptions
+	if cfg.SitemapSaveLocation == "s3" {
+		// wait for any full sitemap generation of the service, so that the update is made to the generation it publishes
+		opts = append(opts, event.WithGenerationLock(lock.NewStoreLocker(store, cfg.S3Config.LockFileKey, cfg.SitemapLockTTL), "dp-sitemap-cli", cfg.SitemapLockTTL/3))
 	}
+	handler := event.NewContentPublishedHandler(store, generations, zebedeeClient, cfg, fetcher, opts...)
 
-	err := handler.Handle(context.Background(), cfg, content)
+	failed := 0
+	for _, content := range events {
+		if err = handler.Handle(context.Background(), cfg, content); err != nil {
+			fmt.Println("Failed to handle event for", content.URI+":", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to update the sitemap for %d of %d uris", failed, len(events))
+	}
+	fmt.Println("sitemap update job complete for", len(events), "uris")
+	return nil
+}
+
+// getEvents returns the content published events given by the event file, the URI file or the
+// event flags, in that order of precedence, or prompts for a single event if none is given
+func getEvents(commandLine *FlagFields) ([]*event.ContentPublished, error) {
+	content := event.ContentPublished{
+		URI:          commandLine.URI,
+		DataType:     commandLine.DataType,
+		CollectionID: commandLine.CollectionID,
+		JobID:        commandLine.JobID,
+		SearchIndex:  commandLine.SearchIndex,
+		TraceID:      commandLine.TraceID,
+	}
+	switch {
+	case commandLine.EventFile != "":
+		return readEventFile(commandLine.EventFile)
+	case commandLine.URIFile != "":
+		return readURIFile(commandLine.URIFile, content)
+	case content.URI != "":
+		return []*event.ContentPublished{&content}, nil
+	}
+	prompted, err := getContent()
+	if err != nil {
+		return nil, err
+	}
+	return []*event.ContentPublished{prompted}, nil
+}
+
+// readEventFile reads a JSON content published event, or an array of them, from a file
+func readEventFile(name string) ([]*event.ContentPublished, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event file: %w", err)
+	}
+
+	var events []*event.ContentPublished
+	if b = bytes.TrimSpace(b); bytes.HasPrefix(b, []byte("[")) {
+		err = json.Unmarshal(b, &events)
+	} else {
+		content := &event.ContentPublished{}
+		err = json.Unmarshal(b, content)
+		events = append(events, content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode event file: %w", err)
+	}
+	for i, content := range events {
+		if content == nil || content.URI == "" {
+			return nil, fmt.Errorf("event %d of the event file has no uri", i+1)
+		}
+	}
+	return events, nil
+}
+
+// readURIFile reads a newline delimited list of URIs from a file, or from stdin if name is "-", and
+// returns an event for each of them with the other fields of template
+func readURIFile(name string, template event.ContentPublished) ([]*event.ContentPublished, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open uri file: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var events []*event.ContentPublished
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		uri := strings.TrimSpace(scanner.Text())
+		if uri == "" {
+			continue
+		}
+		content := template
+		content.URI = uri
+		events = append(events, &content)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read uri file: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, errors.New("no uri in the uri file")
+	}
+	return events, nil
+}
+
+// getS3Client returns the client of the bucket the service saves the sitemaps to
+var getS3Client = func(cfg *config.S3Config) (sitemap.S3Client, error) {
+	return (&service.Init{}).DoGetS3Client(cfg)
+}
+
+// createStore returns the store the service saves the sitemaps to, with the names of the full sitemaps and of their manifest in it
+func createStore(cfg *config.Config) (store sitemap.FileStore, files sitemap.Files, manifest string, err error) {
+	if cfg.SitemapSaveLocation != "s3" {
+		return &sitemap.LocalStore{}, cfg.SitemapLocalFile, cfg.SitemapLocalManifestFile, nil
+	}
+	s3Client, err := getS3Client(&cfg.S3Config)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create s3 client: %w", err)
+	}
+	return sitemap.NewS3Store(s3Client), cfg.S3Config.SitemapFileKey, cfg.S3Config.SitemapManifestKey, nil
+}
+
+// createGenerations returns the full sitemap generations of the service, in the store given by its configuration
+func createGenerations(cfg *config.Config) (*sitemap.Generations, error) {
+	store, files, manifest, err := createStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sitemap.NewGenerations(store, manifest, files, cfg.SitemapGenerationsKept), nil
+}
+
+// ListSitemapGenerations prints the kept full sitemap generations, newest first
+func ListSitemapGenerations(cfg *config.Config) error {
+	generations, err := createGenerations(cfg)
+	if err != nil {
+		return err
+	}
+	manifest, _, err := generations.Manifest()
 	if err != nil {
-		fmt.Println("Failed to handle event:", err)
 		return err
 	}
-	fmt.Println("sitemap update job complete")
+	if len(manifest.Generations) == 0 {
+		fmt.Println("no sitemap generation has been published")
+		return nil
+	}
+	for _, gen := range manifest.Generations {
+		marker := " "
+		if gen.ID == manifest.Current {
+			marker = "*"
+		}
+		fmt.Printf("%s %s created %s urls %v\n", marker, gen.ID, gen.Created.Format(time.RFC3339), gen.URLCounts)
+	}
 	return nil
 }
 
-func LoadStaticSitemap(cfg *config.Config, commandLine *FlagFields) {
-	if !strings.HasSuffix(commandLine.SitemapPathReader, "/") {
-		commandLine.SitemapPathReader += "/"
+// RollbackSitemap makes the full sitemap generation with the given ID live again,
+// or the one published before the live generation if id is empty
+func RollbackSitemap(cfg *config.Config, id string) error {
+	generations, err := createGenerations(cfg)
+	if err != nil {
+		return err
 	}
-	var err error
-	err = sitemap.LoadStaticSitemap(cfg, commandLine.SitemapPath+"_en", commandLine.SitemapPathReader+"sitemap_en.json", cfg.DpOnsURLHostNameEn, cfg.DpOnsURLHostNameCy, "cy", &sitemap.LocalStore{})
+	gen, err := generations.Rollback(context.Background(), id)
 	if err != nil {
-		fmt.Println("Failed to load english static sitemap:", err)
-		return
+		return err
 	}
-	err = sitemap.LoadStaticSitemap(cfg, commandLine.SitemapPath+"_cy", commandLine.SitemapPathReader+"sitemap_cy.json", cfg.DpOnsURLHostNameCy, cfg.DpOnsURLHostNameEn, "en", &sitemap.LocalStore{})
+	fmt.Println("rolled back to sitemap generation", gen.ID, gen.URLCounts)
+	return nil
+}
+
+// liveSitemaps returns the store of the service with the names of the live full sitemaps in it
+func liveSitemaps(cfg *config.Config) (sitemap.FileStore, sitemap.Files, error) {
+	store, files, manifest, err := createStore(cfg)
 	if err != nil {
-		fmt.Println("Failed to load welsh static sitemap:", err)
-		return
+		return nil, nil, err
+	}
+	live, err := sitemap.NewGenerations(store, manifest, files, cfg.SitemapGenerationsKept).LiveFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, live, nil
+}
+
+// ValidateSitemaps checks the given local sitemap files, or the live full sitemaps of the service if
+// there are none, writes the issues found to out and returns an error if the sitemaps are invalid
+func ValidateSitemaps(cfg *config.Config, files []string, out io.Writer) error {
+	validator, err := sitemap.NewSitemapValidator(cfg.DpOnsURLHostNameEn, cfg.DpOnsURLHostNameCy)
+	if err != nil {
+		return err
+	}
+
+	var store sitemap.FileStore = &sitemap.LocalStore{}
+	if len(files) == 0 {
+		var live sitemap.Files
+		if store, live, err = liveSitemaps(cfg); err != nil {
+			return err
+		}
+		for _, lang := range []config.Language{config.English, config.Welsh} {
+			if name, ok := live[lang]; ok {
+				files = append(files, name)
+			}
+		}
+	}
+
+	for _, name := range files {
+		var body io.ReadCloser
+		if body, err = store.GetFile(name); err != nil {
+			return fmt.Errorf("failed to get sitemap %s: %w", name, err)
+		}
+		validator.Validate(name, body)
+		body.Close()
+	}
+
+	report := validator.Report()
+	for _, issue := range report.Issues {
+		fmt.Fprintln(out, issue)
+	}
+	fmt.Fprintf(out, "%d files, %d urls, %d errors, %d warnings\n", report.Files, report.URLs, report.Errors(), len(report.Issues)-report.Errors())
+	if report.Errors() > 0 {
+		return fmt.Errorf("%d sitemap validation errors", report.Errors())
+	}
+	return nil
+}
+
+// languageDiff is the difference between two sitemaps of the same language
+type languageDiff struct {
+	Lang string `json:"lang,omitempty"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+	*sitemap.SitemapDiff
+}
+
+// DiffSitemapFiles writes to out the difference between two local sitemap files, or two sitemaps
+// in the store of the service if stored is true
+func DiffSitemapFiles(cfg *config.Config, oldName, newName string, stored, asJSON bool, out io.Writer) error {
+	var store sitemap.FileStore = &sitemap.LocalStore{}
+	if stored {
+		var err error
+		if store, _, _, err = createStore(cfg); err != nil {
+			return err
+		}
+	}
+	diff, err := diffSitemaps(store, "", oldName, newName)
+	if err != nil {
+		return err
+	}
+	return writeDiffs(out, []languageDiff{*diff}, asJSON)
+}
+
+// DiffSitemapGenerations writes to out the difference between the full sitemaps of each language of
+// two generations. The new generation defaults to the live one and the old one to the generation
+// published before the live one.
+func DiffSitemapGenerations(cfg *config.Config, from, to string, asJSON bool, out io.Writer) error {
+	store, _, manifestName, err := createStore(cfg)
+	if err != nil {
+		return err
+	}
+	manifest, _, err := sitemap.NewGenerations(store, manifestName, nil, 0).Manifest()
+	if err != nil {
+		return err
+	}
+	if from == "" {
+		from = manifest.Previous()
+	}
+	if to == "" {
+		to = manifest.Current
+	}
+	oldGen, newGen := manifest.Find(from), manifest.Find(to)
+	if oldGen == nil {
+		return fmt.Errorf("sitemap generation %q not found", from)
+	}
+	if newGen == nil {
+		return fmt.Errorf("sitemap generation %q not found", to)
+	}
+
+	diffs := []languageDiff{}
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		oldName, oldOK := oldGen.Files[lang]
+		newName, newOK := newGen.Files[lang]
+		if !oldOK || !newOK {
+			continue
+		}
+		var diff *languageDiff
+		if diff, err = diffSitemaps(store, lang.String(), oldName, newName); err != nil {
+			return err
+		}
+		diffs = append(diffs, *diff)
+	}
+	return writeDiffs(out, diffs, asJSON)
+}
+
+// diffSitemaps compares two sitemaps in store
+func diffSitemaps(store sitemap.FileStore, lang, oldName, newName string) (*languageDiff, error) {
+	oldBody, err := getSitemap(store, oldName)
+	if err != nil {
+		return nil, err
+	}
+	defer oldBody.Close()
+	newBody, err := getSitemap(store, newName)
+	if err != nil {
+		return nil, err
+	}
+	defer newBody.Close()
+
+	diff, err := sitemap.DiffSitemaps(oldBody, newBody)
+	if err != nil {
+		return nil, err
+	}
+	return &languageDiff{Lang: lang, Old: oldName, New: newName, SitemapDiff: diff}, nil
+}
+
+// getSitemap returns the content of a sitemap in store, failing with sitemap.ErrFileNotFound if it does not exist
+func getSitemap(store sitemap.FileStore, name string) (io.ReadCloser, error) {
+	if _, err := store.GetFileInfo(name); err != nil {
+		return nil, fmt.Errorf("failed to get sitemap %s: %w", name, err)
+	}
+	body, err := store.GetFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sitemap %s: %w", name, err)
+	}
+	return body, nil
+}
+
+// writeDiffs writes the differences between sitemaps as JSON, or one line per URL otherwise
+func writeDiffs(out io.Writer, diffs []languageDiff, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+	}
+	for _, diff := range diffs {
+		if diff.Lang != "" {
+			fmt.Fprintf(out, "%s: %s -> %s\n", diff.Lang, diff.Old, diff.New)
+		} else {
+			fmt.Fprintf(out, "%s -> %s\n", diff.Old, diff.New)
+		}
+		for _, loc := range diff.Added {
+			fmt.Fprintf(out, "+ %s\n", loc)
+		}
+		for _, loc := range diff.Removed {
+			fmt.Fprintf(out, "- %s\n", loc)
+		}
+		for _, change := range diff.Changed {
+			fmt.Fprintf(out, "~ %s", change.Loc)
+			if change.OldLastmod != change.NewLastmod {
+				fmt.Fprintf(out, " lastmod %q -> %q", change.OldLastmod, change.NewLastmod)
+			}
+			if change.OldAlternate != change.NewAlternate {
+				fmt.Fprintf(out, " alternate %q -> %q", change.OldAlternate, change.NewAlternate)
+			}
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%d added, %d removed, %d changed\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+	return nil
+}
+
+// LoadStaticSitemap writes the sitemaps of both languages of the static pages listed in the english list of
+// SitemapPathReader, in any supported format, or with dryRun writes them to out instead. Their urls are checked against
+// the robots rules if a robots check mode is given.
+func LoadStaticSitemap(cfg *config.Config, commandLine *FlagFields, out io.Writer) error {
+	staticSitemapName := sitemap.StaticSitemapFile(commandLine.SitemapPathReader, config.English)
+	robotsCheck, err := createRobotsCheck(cfg, commandLine)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if !commandLine.DryRun {
+		files := sitemap.Files{
+			config.English: commandLine.SitemapPath + "_" + config.English.String(),
+			config.Welsh:   commandLine.SitemapPath + "_" + config.Welsh.String(),
+		}
+		if err = sitemap.LoadStaticSitemap(ctx, cfg, staticSitemapName, files, &sitemap.LocalStore{}, robotsCheck); err != nil {
+			return fmt.Errorf("failed to load static sitemap: %w", err)
+		}
+		return nil
+	}
+
+	sitemaps, err := sitemap.StaticSitemaps(ctx, cfg, staticSitemapName, robotsCheck)
+	if err != nil {
+		return fmt.Errorf("failed to load static sitemap: %w", err)
+	}
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		fmt.Fprintf(out, "%s: %s\n%s\n", lang, staticSitemapName, sitemaps[lang])
+	}
+	return nil
+}
+
+// CheckRobots writes to out whether the robots rules of RobotsFilePathReader, or the embedded ones, allow each of the
+// user agents to crawl each of the urls, the rules of the language of the host name of a url applying. With
+// CheckSitemap, it also checks that none of the urls of the live full sitemaps of the service is blocked for any of
+// the user agents, returning an error otherwise.
+func CheckRobots(cfg *config.Config, commandLine *FlagFields, urls []string, out io.Writer) error {
+	robots, err := robotseo.NewRobotFileWriter(commandLine.RobotsFilePathReader)
+	if err != nil {
+		return fmt.Errorf("failed to read the robots config: %w", err)
+	}
+
+	for _, rawURL := range urls {
+		path, pathErr := robotseo.URLPath(rawURL)
+		if pathErr != nil {
+			return pathErr
+		}
+		lang := urlLanguage(cfg, rawURL)
+		for _, userAgent := range commandLine.UserAgents {
+			fmt.Fprintf(out, "%s: %s: %s\n", rawURL, userAgent, robots.Test(lang, userAgent, path))
+		}
+	}
+	if !commandLine.CheckSitemap {
+		return nil
+	}
+
+	store, live, err := liveSitemaps(cfg)
+	if err != nil {
+		return err
+	}
+	var checked, blocked int
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		name, ok := live[lang]
+		if !ok {
+			continue
+		}
+		var body io.ReadCloser
+		if body, err = getSitemap(store, name); err != nil {
+			return err
+		}
+		var urlset sitemap.UrlsetReader
+		err = xml.NewDecoder(body).Decode(&urlset)
+		body.Close()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to decode sitemap %s: %w", name, err)
+		}
+		for _, u := range urlset.URL {
+			checked++
+			path, pathErr := robotseo.URLPath(u.Loc)
+			if pathErr != nil {
+				return pathErr
+			}
+			for _, userAgent := range commandLine.UserAgents {
+				if match := robots.Test(lang, userAgent, path); !match.Allowed {
+					blocked++
+					fmt.Fprintf(out, "%s: %s: %s: %s\n", name, u.Loc, userAgent, match)
+					break
+				}
+			}
+		}
+	}
+	fmt.Fprintf(out, "%d sitemap urls, %d blocked\n", checked, blocked)
+	if blocked > 0 {
+		return fmt.Errorf("%d sitemap urls blocked by the robots rules", blocked)
+	}
+	return nil
+}
+
+// urlLanguage returns the language of the host name of a url, english unless it is on the welsh host name
+func urlLanguage(cfg *config.Config, rawURL string) config.Language {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return config.English
+	}
+	welsh, err := url.Parse(cfg.DpOnsURLHostNameCy)
+	if err == nil && strings.EqualFold(u.Hostname(), welsh.Hostname()) {
+		return config.Welsh
 	}
+	return config.English
 }
 
 var getContent = func() (*event.ContentPublished, error) {
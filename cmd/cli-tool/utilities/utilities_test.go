@@ -1,11 +1,29 @@
 package utilities
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-sitemap/config"
 	"github.com/ONSdigital/dp-sitemap/event"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -66,6 +84,8 @@ func TestUpdateSitemap(t *testing.T) {
 	Convey("Given a valid config and command line flags", t, func() {
 		cfg := &config.Config{}
 		commandLine := &FlagFields{}
+		originalGetContent := getContent
+		Reset(func() { getContent = originalGetContent })
 
 		Convey("When FakeScroll is true", func() {
 			commandLine.FakeScroll = true
@@ -132,3 +152,485 @@ func TestUpdateSitemap(t *testing.T) {
 		})
 	})
 }
+
+func TestRollbackSitemap(t *testing.T) {
+	Convey("Given two full sitemap generations in a local store", t, func() {
+		dir := t.TempDir()
+		cfg := &config.Config{
+			SitemapSaveLocation:      "local",
+			SitemapLocalFile:         map[config.Language]string{config.English: filepath.Join(dir, "sitemap-en.xml")},
+			SitemapLocalManifestFile: filepath.Join(dir, "manifest.json"),
+			SitemapGenerationsKept:   3,
+		}
+		generations, err := createGenerations(cfg)
+		So(err, ShouldBeNil)
+		first := generations.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		So(generations.Publish(context.Background(), first), ShouldBeNil)
+		second := generations.New(time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC))
+		So(generations.Publish(context.Background(), second), ShouldBeNil)
+
+		Convey("When they are listed", func() {
+			err := ListSitemapGenerations(cfg)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the sitemaps are rolled back", func() {
+			err := RollbackSitemap(cfg, "")
+
+			Convey("Then the previous generation is live", func() {
+				So(err, ShouldBeNil)
+				live, err := generations.LiveFiles()
+				So(err, ShouldBeNil)
+				So(live, ShouldResemble, first.Files)
+			})
+		})
+
+		Convey("When the sitemaps are rolled back to an unknown generation", func() {
+			err := RollbackSitemap(cfg, "unknown")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				live, err := generations.LiveFiles()
+				So(err, ShouldBeNil)
+				So(live, ShouldResemble, second.Files)
+			})
+		})
+	})
+}
+
+func TestValidateSitemaps(t *testing.T) {
+	Convey("Given local sitemap files", t, func() {
+		dir := t.TempDir()
+		cfg := &config.Config{
+			DpOnsURLHostNameEn: "https://www.ons.gov.uk/",
+			DpOnsURLHostNameCy: "https://cy.ons.gov.uk/",
+		}
+		valid := filepath.Join(dir, "valid.xml")
+		So(os.WriteFile(valid, []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://www.ons.gov.uk/a</loc><lastmod>2023-01-02</lastmod></url></urlset>`), 0o600), ShouldBeNil)
+		invalid := filepath.Join(dir, "invalid.xml")
+		So(os.WriteFile(invalid, []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/a</loc></url></urlset>`), 0o600), ShouldBeNil)
+
+		Convey("When a valid sitemap is validated", func() {
+			var out bytes.Buffer
+			err := ValidateSitemaps(cfg, []string{valid}, &out)
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldEqual, "1 files, 1 urls, 0 errors, 0 warnings\n")
+			})
+		})
+
+		Convey("When an invalid sitemap is validated", func() {
+			var out bytes.Buffer
+			err := ValidateSitemaps(cfg, []string{valid, invalid}, &out)
+
+			Convey("Then the issues are reported and an error is returned", func() {
+				So(err.Error(), ShouldEqual, "1 sitemap validation errors")
+				So(out.String(), ShouldContainSubstring, invalid+`: https://example.com/a: error: loc "https://example.com/a" is not on a configured host name`)
+				So(out.String(), ShouldContainSubstring, "2 files, 2 urls, 1 errors, 0 warnings")
+			})
+		})
+
+		Convey("When a missing sitemap is validated", func() {
+			var out bytes.Buffer
+			err := ValidateSitemaps(cfg, []string{filepath.Join(dir, "missing.xml")}, &out)
+
+			Convey("Then it is reported as empty and an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(out.String(), ShouldContainSubstring, "missing.xml: error: sitemap is empty")
+			})
+		})
+	})
+}
+
+func TestDiffSitemaps(t *testing.T) {
+	const (
+		oldSitemap = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>https://www.ons.gov.uk/removed</loc><lastmod>2023-01-01</lastmod></url>
+<url><loc>https://www.ons.gov.uk/updated</loc><lastmod>2023-01-01</lastmod></url>
+</urlset>`
+		newSitemap = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>https://www.ons.gov.uk/added</loc><lastmod>2023-02-01</lastmod></url>
+<url><loc>https://www.ons.gov.uk/updated</loc><lastmod>2023-02-01</lastmod></url>
+</urlset>`
+	)
+
+	Convey("Given two local sitemap files", t, func() {
+		dir := t.TempDir()
+		oldFile, newFile := filepath.Join(dir, "old.xml"), filepath.Join(dir, "new.xml")
+		So(os.WriteFile(oldFile, []byte(oldSitemap), 0o600), ShouldBeNil)
+		So(os.WriteFile(newFile, []byte(newSitemap), 0o600), ShouldBeNil)
+
+		Convey("When they are compared", func() {
+			var out bytes.Buffer
+			err := DiffSitemapFiles(&config.Config{}, oldFile, newFile, false, false, &out)
+
+			Convey("Then the differences are written", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldEqual, oldFile+" -> "+newFile+`
++ https://www.ons.gov.uk/added
+- https://www.ons.gov.uk/removed
+~ https://www.ons.gov.uk/updated lastmod "2023-01-01" -> "2023-02-01"
+1 added, 1 removed, 1 changed
+`)
+			})
+		})
+
+		Convey("When they are compared as JSON", func() {
+			var out bytes.Buffer
+			err := DiffSitemapFiles(&config.Config{}, oldFile, newFile, false, true, &out)
+
+			Convey("Then the differences are written as JSON", func() {
+				So(err, ShouldBeNil)
+				var diffs []map[string]interface{}
+				So(json.Unmarshal(out.Bytes(), &diffs), ShouldBeNil)
+				So(diffs, ShouldHaveLength, 1)
+				So(diffs[0]["added"], ShouldResemble, []interface{}{"https://www.ons.gov.uk/added"})
+				So(diffs[0]["removed"], ShouldResemble, []interface{}{"https://www.ons.gov.uk/removed"})
+				So(diffs[0]["changed"], ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When one of them is compared with a file that does not exist", func() {
+			var out bytes.Buffer
+			missing := filepath.Join(dir, "missing.xml")
+			err := DiffSitemapFiles(&config.Config{}, missing, newFile, false, false, &out)
+
+			Convey("Then the missing file is reported", func() {
+				So(errors.Is(err, sitemap.ErrFileNotFound), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, missing)
+				So(out.String(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given two full sitemap generations in a local store", t, func() {
+		dir := t.TempDir()
+		cfg := &config.Config{
+			SitemapSaveLocation:      "local",
+			SitemapLocalFile:         map[config.Language]string{config.English: filepath.Join(dir, "sitemap-en.xml")},
+			SitemapLocalManifestFile: filepath.Join(dir, "manifest.json"),
+			SitemapGenerationsKept:   3,
+		}
+		generations, err := createGenerations(cfg)
+		So(err, ShouldBeNil)
+		store := &sitemap.LocalStore{}
+		first := generations.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		So(store.SaveFile(first.Files[config.English], strings.NewReader(oldSitemap)), ShouldBeNil)
+		So(generations.Publish(context.Background(), first), ShouldBeNil)
+		second := generations.New(time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC))
+		So(store.SaveFile(second.Files[config.English], strings.NewReader(newSitemap)), ShouldBeNil)
+		So(generations.Publish(context.Background(), second), ShouldBeNil)
+
+		Convey("When the live generation is compared with the previous one", func() {
+			var out bytes.Buffer
+			err := DiffSitemapGenerations(cfg, "", "", false, &out)
+
+			Convey("Then the differences of each language are written", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldStartWith, "en: "+first.Files[config.English]+" -> "+second.Files[config.English]+"\n")
+				So(out.String(), ShouldEndWith, "1 added, 1 removed, 1 changed\n")
+			})
+		})
+
+		Convey("When an unknown generation is compared", func() {
+			err := DiffSitemapGenerations(cfg, "unknown", "", false, io.Discard)
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, `sitemap generation "unknown" not found`)
+			})
+		})
+	})
+}
+
+func TestGetEvents(t *testing.T) {
+	Convey("Given the fields of a content published event on the command line", t, func() {
+		dir := t.TempDir()
+		commandLine := &FlagFields{
+			URI:          "/economy",
+			DataType:     defaultTestDataType,
+			CollectionID: "collection",
+		}
+
+		Convey("When the events are read", func() {
+			events, err := getEvents(commandLine)
+
+			Convey("Then the event of the flags is returned", func() {
+				So(err, ShouldBeNil)
+				So(events, ShouldResemble, []*event.ContentPublished{{URI: "/economy", DataType: defaultTestDataType, CollectionID: "collection"}})
+			})
+		})
+
+		Convey("When a URI file is given", func() {
+			commandLine.URIFile = filepath.Join(dir, "uris.txt")
+			So(os.WriteFile(commandLine.URIFile, []byte("/a\n\n  /b  \n"), 0o600), ShouldBeNil)
+			events, err := getEvents(commandLine)
+
+			Convey("Then an event is returned for each URI with the fields of the flags", func() {
+				So(err, ShouldBeNil)
+				So(events, ShouldResemble, []*event.ContentPublished{
+					{URI: "/a", DataType: defaultTestDataType, CollectionID: "collection"},
+					{URI: "/b", DataType: defaultTestDataType, CollectionID: "collection"},
+				})
+			})
+		})
+
+		Convey("When an empty URI file is given", func() {
+			commandLine.URIFile = filepath.Join(dir, "uris.txt")
+			So(os.WriteFile(commandLine.URIFile, []byte("\n"), 0o600), ShouldBeNil)
+			_, err := getEvents(commandLine)
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldEqual, "no uri in the uri file")
+			})
+		})
+
+		Convey("When an event file holding an event is given", func() {
+			commandLine.EventFile = filepath.Join(dir, "event.json")
+			So(os.WriteFile(commandLine.EventFile, []byte(`{"uri": "/file", "data_type": "bulletin", "trace_id": "trace"}`), 0o600), ShouldBeNil)
+			events, err := getEvents(commandLine)
+
+			Convey("Then the event of the file is returned", func() {
+				So(err, ShouldBeNil)
+				So(events, ShouldResemble, []*event.ContentPublished{{URI: "/file", DataType: "bulletin", TraceID: "trace"}})
+			})
+		})
+
+		Convey("When an event file holding several events is given", func() {
+			commandLine.EventFile = filepath.Join(dir, "events.json")
+			So(os.WriteFile(commandLine.EventFile, []byte(`[{"uri": "/a"}, {"uri": "/b"}]`), 0o600), ShouldBeNil)
+			events, err := getEvents(commandLine)
+
+			Convey("Then the events of the file are returned", func() {
+				So(err, ShouldBeNil)
+				So(events, ShouldResemble, []*event.ContentPublished{{URI: "/a"}, {URI: "/b"}})
+			})
+		})
+
+		Convey("When an event file holding an event without uri is given", func() {
+			commandLine.EventFile = filepath.Join(dir, "events.json")
+			So(os.WriteFile(commandLine.EventFile, []byte(`[{"uri": "/a"}, {"data_type": "bulletin"}]`), 0o600), ShouldBeNil)
+			_, err := getEvents(commandLine)
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldEqual, "event 2 of the event file has no uri")
+			})
+		})
+	})
+
+	Convey("Given no event on the command line", t, func() {
+		originalGetContent := getContent
+		Reset(func() { getContent = originalGetContent })
+		getContent = func() (*event.ContentPublished, error) {
+			return &event.ContentPublished{URI: "/prompted"}, nil
+		}
+
+		Convey("When the events are read", func() {
+			events, err := getEvents(&FlagFields{})
+
+			Convey("Then the event is prompted for", func() {
+				So(err, ShouldBeNil)
+				So(events, ShouldResemble, []*event.ContentPublished{{URI: "/prompted"}})
+			})
+		})
+	})
+}
+
+func TestUpdateSitemapStore(t *testing.T) {
+	Convey("Given an unknown store on the command line", t, func() {
+		commandLine := &FlagFields{Store: "ftp", URI: "/economy"}
+
+		Convey("When the sitemap is updated", func() {
+			err := UpdateSitemap(&config.Config{}, commandLine)
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldEqual, `invalid store "ftp", expected local or s3`)
+			})
+		})
+	})
+}
+
+func TestUpdateSitemapS3(t *testing.T) {
+	Convey("Given a sitemap in s3 and a page published in zebedee", t, func() {
+		zebedeeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"uri": "/economy", "description": {"releaseDate": "2024-01-02T00:00:00Z"}}`))
+		}))
+		defer zebedeeServer.Close()
+
+		uploaded := map[string]string{}
+		s3Client := &mock.S3ClientMock{
+			BucketNameFunc: func() string { return "bucket" },
+			HeadFunc: func(key string) (*s3.HeadObjectOutput, error) {
+				if body, ok := uploaded[key]; ok {
+					return &s3.HeadObjectOutput{ETag: aws.String(fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(body))))}, nil
+				}
+				return nil, awserr.New("NotFound", "not found", nil)
+			},
+			GetFunc: func(key string) (io.ReadCloser, *int64, error) {
+				if body, ok := uploaded[key]; ok {
+					return io.NopCloser(strings.NewReader(body)), nil, nil
+				}
+				return nil, nil, awserr.New("NoSuchKey", "not found", nil)
+			},
+			UploadFunc: func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+				b, err := io.ReadAll(input.Body)
+				uploaded[*input.Key] = string(b)
+				return &s3manager.UploadOutput{}, err
+			},
+		}
+		originalGetS3Client := getS3Client
+		getS3Client = func(cfg *config.S3Config) (sitemap.S3Client, error) { return s3Client, nil }
+		Reset(func() { getS3Client = originalGetS3Client })
+
+		cfg := &config.Config{
+			DpOnsURLHostNameEn: "https://www.ons.gov.uk",
+			DpOnsURLHostNameCy: "https://cy.ons.gov.uk",
+			SitemapLockTTL:     time.Minute,
+			S3Config: config.S3Config{
+				SitemapFileKey: sitemap.Files{config.English: "sitemap-en.xml", config.Welsh: "sitemap-cy.xml"},
+				LockFileKey:    "full-sitemap.lock",
+			},
+		}
+		commandLine := &FlagFields{Store: "s3", FakeScroll: true, ZebedeeURL: zebedeeServer.URL, URI: "/economy"}
+
+		Convey("When the sitemap is updated", func() {
+			err := UpdateSitemap(cfg, commandLine)
+
+			Convey("Then the updated sitemap of each language is uploaded to s3", func() {
+				So(err, ShouldBeNil)
+				So(uploaded["sitemap-en.xml"], ShouldContainSubstring, "<loc>https://www.ons.gov.uk/economy</loc>")
+				So(uploaded["sitemap-cy.xml"], ShouldContainSubstring, "<loc>https://cy.ons.gov.uk/economy</loc>")
+			})
+			Convey("Then the full sitemap lock is taken and released", func() {
+				So(uploaded, ShouldHaveLength, 3)
+				So(uploaded["full-sitemap.lock"], ShouldContainSubstring, `"owner":""`)
+			})
+		})
+	})
+}
+
+func TestLoadStaticSitemap(t *testing.T) {
+	Convey("Given a yaml static page list", t, func() {
+		dir := t.TempDir()
+		cfg := &config.Config{
+			DpOnsURLHostNameEn: "https://www.ons.gov.uk/",
+			DpOnsURLHostNameCy: "https://cy.ons.gov.uk/",
+		}
+		So(os.WriteFile(filepath.Join(dir, "sitemap_en.yaml"), []byte("- url: a\n  releaseDate: 01-02-2023\n  languages:\n    cy:\n      path: b\n"), 0o600), ShouldBeNil)
+		commandLine := &FlagFields{SitemapPath: filepath.Join(dir, "static_sitemap"), SitemapPathReader: dir}
+
+		Convey("When it is loaded with a dry run", func() {
+			commandLine.DryRun = true
+			var out bytes.Buffer
+			err := LoadStaticSitemap(cfg, commandLine, &out)
+
+			Convey("Then the sitemaps of both languages are printed and not written", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldContainSubstring, "en: "+filepath.Join(dir, "sitemap_en.yaml"))
+				So(out.String(), ShouldContainSubstring, "<loc>https://www.ons.gov.uk/a</loc>")
+				So(out.String(), ShouldContainSubstring, "cy: "+filepath.Join(dir, "sitemap_en.yaml"))
+				So(out.String(), ShouldContainSubstring, "<loc>https://cy.ons.gov.uk/b</loc>")
+				So(out.String(), ShouldContainSubstring, "<lastmod>2023-02-01</lastmod>")
+				_, err = os.Stat(commandLine.SitemapPath + "_en")
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("When it is loaded", func() {
+			err := LoadStaticSitemap(cfg, commandLine, io.Discard)
+
+			Convey("Then the sitemaps of both languages are written", func() {
+				So(err, ShouldBeNil)
+				for _, lang := range []string{"en", "cy"} {
+					_, err = os.Stat(commandLine.SitemapPath + "_" + lang)
+					So(err, ShouldBeNil)
+				}
+			})
+		})
+
+		Convey("When it is loaded with an invalid entry", func() {
+			So(os.WriteFile(filepath.Join(dir, "sitemap_en.yaml"), []byte("- url: /a\n  releaseDate: 2023-02-01\n"), 0o600), ShouldBeNil)
+			err := LoadStaticSitemap(cfg, commandLine, io.Discard)
+
+			Convey("Then the invalid entry is reported and no sitemap is written", func() {
+				So(err.Error(), ShouldContainSubstring, "failed to load static sitemap")
+				So(err.Error(), ShouldContainSubstring, `entry 1: url "/a" is not relative to the host name`)
+				_, err = os.Stat(commandLine.SitemapPath + "_en")
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestCheckRobots(t *testing.T) {
+	Convey("Given robots rules and live full sitemaps", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "robot_en.json"), []byte(`{"*": {"DenyList": ["/search"]}, "Googlebot": {"AllowList": ["/"]}}`), 0o600), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "robot_cy.json"), []byte(`{"*": {"DenyList": ["/chwilio"]}}`), 0o600), ShouldBeNil)
+		cfg := &config.Config{
+			DpOnsURLHostNameEn:       "https://www.ons.gov.uk/",
+			DpOnsURLHostNameCy:       "https://cy.ons.gov.uk/",
+			SitemapSaveLocation:      "local",
+			SitemapLocalManifestFile: filepath.Join(dir, "manifest.json"),
+			SitemapLocalFile: map[config.Language]string{
+				config.English: filepath.Join(dir, "sitemap-en.xml"),
+				config.Welsh:   filepath.Join(dir, "sitemap-cy.xml"),
+			},
+		}
+		So(os.WriteFile(cfg.SitemapLocalFile[config.English], []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://www.ons.gov.uk/economy</loc></url></urlset>`), 0o600), ShouldBeNil)
+		So(os.WriteFile(cfg.SitemapLocalFile[config.Welsh], []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://cy.ons.gov.uk/economy</loc></url></urlset>`), 0o600), ShouldBeNil)
+		commandLine := &FlagFields{RobotsFilePathReader: dir, UserAgents: []string{"*", "Googlebot"}, CheckSitemap: true}
+
+		Convey("When urls are tested", func() {
+			var out bytes.Buffer
+			err := CheckRobots(cfg, commandLine, []string{"https://www.ons.gov.uk/search?q=cpi", "https://cy.ons.gov.uk/chwilio"}, &out)
+
+			Convey("Then the rules of the language of each url are reported for each user agent", func() {
+				So(err, ShouldBeNil)
+				So(out.String(), ShouldEqual, `https://www.ons.gov.uk/search?q=cpi: *: blocked by Disallow: /search
+https://www.ons.gov.uk/search?q=cpi: Googlebot: allowed by Allow: /
+https://cy.ons.gov.uk/chwilio: *: blocked by Disallow: /chwilio
+https://cy.ons.gov.uk/chwilio: Googlebot: blocked by Disallow: /chwilio
+2 sitemap urls, 0 blocked
+`)
+			})
+		})
+
+		Convey("When a url of a sitemap is blocked", func() {
+			So(os.WriteFile(cfg.SitemapLocalFile[config.Welsh], []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://cy.ons.gov.uk/chwilio/a</loc></url></urlset>`), 0o600), ShouldBeNil)
+			var out bytes.Buffer
+			err := CheckRobots(cfg, commandLine, nil, &out)
+
+			Convey("Then it is reported and an error is returned", func() {
+				So(err.Error(), ShouldEqual, "1 sitemap urls blocked by the robots rules")
+				So(out.String(), ShouldEqual, cfg.SitemapLocalFile[config.Welsh]+`: https://cy.ons.gov.uk/chwilio/a: *: blocked by Disallow: /chwilio
+2 sitemap urls, 1 blocked
+`)
+			})
+		})
+
+		Convey("When a live sitemap does not exist", func() {
+			So(os.Remove(cfg.SitemapLocalFile[config.Welsh]), ShouldBeNil)
+			var out bytes.Buffer
+			err := CheckRobots(cfg, commandLine, nil, &out)
+
+			Convey("Then the missing sitemap is reported", func() {
+				So(errors.Is(err, sitemap.ErrFileNotFound), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, cfg.SitemapLocalFile[config.Welsh])
+				So(out.String(), ShouldNotContainSubstring, "sitemap urls")
+			})
+		})
+
+		Convey("When the robots rules are invalid", func() {
+			So(os.WriteFile(filepath.Join(dir, "robot_en.json"), []byte(`{"*": {"DenyList": ["search"]}}`), 0o600), ShouldBeNil)
+			err := CheckRobots(cfg, commandLine, nil, io.Discard)
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "rule [search] must start with / or *")
+			})
+		})
+	})
+}
@@ -26,30 +26,54 @@ func (l Language) String() string {
 
 // Config represents service configuration for dp-sitemap
 type Config struct {
-	BindAddr                   string              `envconfig:"BIND_ADDR"`
-	GracefulShutdownTimeout    time.Duration       `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
-	HealthCheckInterval        time.Duration       `envconfig:"HEALTHCHECK_INTERVAL"`
-	HealthCheckCriticalTimeout time.Duration       `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
-	SitemapGenerationFrequency time.Duration       `envconfig:"SITEMAP_GENERATION_FREQUENCY"`
-	SitemapGenerationTimeout   time.Duration       `envconfig:"SITEMAP_GENERATION_TIMEOUT"`
-	RobotsFilePath             map[Language]string `envconfig:"ROBOTS_FILE_PATH"`
-	KafkaConfig                KafkaConfig
-	OpenSearchConfig           OpenSearchConfig
-	SitemapSaveLocation        string              `envconfig:"SITEMAP_SAVE_LOCATION"` // "local" or "s3", default: "local"
-	SitemapLocalFile           map[Language]string `envconfig:"SITEMAP_LOCAL_FILE"`
-	PublishingSitemapLocalFile string              `envconfig:"PUBLISHING_SITEMAP_LOCAL_FILE"`
-	PublishingSitemapMaxSize   int                 `envconfig:"PUBLISHING_SITEMAP_MAX_SIZE"`
-	S3Config                   S3Config
-	ZebedeeURL                 string `envconfig:"ZEBEDEE_URL"`
-	DpOnsURLHostNameEn         string `envconfig:"DP_ONS_URL_HOSTNAME_ENGLISH"`
-	DpOnsURLHostNameCy         string `envconfig:"DP_ONS_URL_HOSTNAME_WELSH"`
-	Debug                      bool   `envconfig:"SITEMAP_DEBUG_ENABLED"`
+	BindAddr                     string              `envconfig:"BIND_ADDR"`
+	GracefulShutdownTimeout      time.Duration       `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
+	HealthCheckInterval          time.Duration       `envconfig:"HEALTHCHECK_INTERVAL"`
+	HealthCheckCriticalTimeout   time.Duration       `envconfig:"HEALTHCHECK_CRITICAL_TIMEOUT"`
+	SitemapGenerationFrequency   time.Duration       `envconfig:"SITEMAP_GENERATION_FREQUENCY"`
+	SitemapGenerationTimeout     time.Duration       `envconfig:"SITEMAP_GENERATION_TIMEOUT"`
+	SitemapGenerationCron        string              `envconfig:"SITEMAP_GENERATION_CRON"`          // cron expression for the full sitemap generation, overrides the frequency
+	SitemapGenerationAt          string              `envconfig:"SITEMAP_GENERATION_AT"`            // daily times of day ("HH:MM[:SS]", separated by ";") for the full sitemap generation, overrides the frequency
+	SitemapGenerationTZ          string              `envconfig:"SITEMAP_GENERATION_TZ"`            // time zone of the daily times of day of the full sitemap generation, UTC if empty
+	SitemapGenerationOnStartup   bool                `envconfig:"SITEMAP_GENERATION_ON_STARTUP"`    // also run the full sitemap generation when the service starts
+	SitemapGenerationJitter      time.Duration       `envconfig:"SITEMAP_GENERATION_JITTER"`        // maximum random delay added to each scheduled full sitemap generation
+	SitemapMaxAgeMultiplier      int                 `envconfig:"SITEMAP_MAX_AGE_MULTIPLIER"`       // full sitemaps older than this many generation periods of the active schedule are reported in the healthcheck, 0 to disable
+	SitemapMaxURLDropPercent     float64             `envconfig:"SITEMAP_MAX_URL_DROP_PERCENT"`     // full sitemaps losing more than this percentage of their urls fail the healthcheck, 0 to disable
+	SitemapMaxFailures           int                 `envconfig:"SITEMAP_MAX_CONSECUTIVE_FAILURES"` // this many failed generations in a row fail the healthcheck, 0 to disable
+	SitemapLockTTL               time.Duration       `envconfig:"SITEMAP_LOCK_TTL"`                 // lock held by the instance generating the full sitemap expires after this long without being renewed
+	SitemapGenerationsKept       int                 `envconfig:"SITEMAP_GENERATIONS_KEPT"`         // number of full sitemap generations kept for rollback, 0 to overwrite the full sitemaps in place
+	SitemapMaxPublishDropPercent float64             `envconfig:"SITEMAP_MAX_PUBLISH_DROP_PERCENT"` // full sitemaps losing more than this percentage of the published urls are not published, 0 to disable
+	SitemapForcePublish          bool                `envconfig:"SITEMAP_FORCE_PUBLISH"`            // publish the full sitemaps whatever their url count drop
+	SitemapStaticMerge           bool                `envconfig:"SITEMAP_STATIC_MERGE"`             // merge the static pages into every full sitemap
+	SitemapStaticDir             string              `envconfig:"SITEMAP_STATIC_DIR"`               // directory of the sitemap_en static page list of both languages, empty for the embedded one
+	SitemapRobotsCheck           string              `envconfig:"SITEMAP_ROBOTS_CHECK"`             // "warn" or "drop" the sitemap urls disallowed by the robots rules for all user agents or Googlebot, empty to disable
+	RobotsSaveLocation           string              `envconfig:"ROBOTS_SAVE_LOCATION"`             // "local" or "s3", default the sitemap save location
+	RobotsFilePath               map[Language]string `envconfig:"ROBOTS_FILE_PATH"`                 // local file of the robots file of each language, when saved locally
+	RobotsConfigDir              string              `envconfig:"ROBOTS_CONFIG_DIR"`                // directory of the robot_en.json and robot_cy.json robots configs, watched for changes, empty for the embedded ones
+	RobotsSitemapURL             map[Language]string `envconfig:"ROBOTS_SITEMAP_URL"`               // public url of the sitemap of each language given in its robots file, default the sitemap.xml of the language host name
+	RobotsSitemapIndexURL        string              `envconfig:"ROBOTS_SITEMAP_INDEX_URL"`         // public url of the sitemap index given in the robots files instead of the sitemap of their language
+	KafkaConfig                  KafkaConfig
+	OpenSearchConfig             OpenSearchConfig
+	SitemapSaveLocation          string              `envconfig:"SITEMAP_SAVE_LOCATION"` // "local" or "s3", default: "local"
+	SitemapLocalFile             map[Language]string `envconfig:"SITEMAP_LOCAL_FILE"`
+	SitemapLocalManifestFile     string              `envconfig:"SITEMAP_LOCAL_MANIFEST_FILE"`
+	PublishingSitemapLocalFile   string              `envconfig:"PUBLISHING_SITEMAP_LOCAL_FILE"`
+	PublishingSitemapMaxSize     int                 `envconfig:"PUBLISHING_SITEMAP_MAX_SIZE"`
+	S3Config                     S3Config
+	ZebedeeURL                   string `envconfig:"ZEBEDEE_URL"`
+	DpOnsURLHostNameEn           string `envconfig:"DP_ONS_URL_HOSTNAME_ENGLISH"`
+	DpOnsURLHostNameCy           string `envconfig:"DP_ONS_URL_HOSTNAME_WELSH"`
+	Debug                        bool   `envconfig:"SITEMAP_DEBUG_ENABLED"`
+	AdminAuthToken               string `envconfig:"ADMIN_AUTH_TOKEN"              json:"-"`
 }
 
 type S3Config struct {
 	UploadBucketName         string              `envconfig:"S3_UPLOAD_BUCKET_NAME"`
 	SitemapFileKey           map[Language]string `envconfig:"S3_SITEMAP_FILE_KEY"`
 	PublishingSitemapFileKey string              `envconfig:"S3_PUBLISHING_SITEMAP_FILE_KEY"`
+	LockFileKey              string              `envconfig:"S3_LOCK_FILE_KEY"`
+	SitemapManifestKey       string              `envconfig:"S3_SITEMAP_MANIFEST_KEY"`
+	RobotsFileKey            map[Language]string `envconfig:"S3_ROBOTS_FILE_KEY"`
 	AwsRegion                string              `envconfig:"S3_AWS_REGION"`
 	LocalstackHost           string              `envconfig:"S3_LOCALSTACK_HOST"`
 }
@@ -94,12 +118,21 @@ func Get() (*Config, error) {
 	}
 
 	cfg = &Config{
-		BindAddr:                   "localhost:",
-		GracefulShutdownTimeout:    5 * time.Second,
-		HealthCheckInterval:        30 * time.Second,
-		HealthCheckCriticalTimeout: 90 * time.Second,
-		SitemapGenerationFrequency: time.Hour * 24,
-		SitemapGenerationTimeout:   10 * time.Minute,
+		BindAddr:                     "localhost:",
+		GracefulShutdownTimeout:      5 * time.Second,
+		HealthCheckInterval:          30 * time.Second,
+		HealthCheckCriticalTimeout:   90 * time.Second,
+		SitemapGenerationFrequency:   time.Hour * 24,
+		SitemapGenerationTimeout:     10 * time.Minute,
+		SitemapGenerationOnStartup:   true,
+		SitemapGenerationTZ:          "Europe/London",
+		SitemapMaxAgeMultiplier:      3,
+		SitemapMaxURLDropPercent:     20,
+		SitemapMaxFailures:           3,
+		SitemapLockTTL:               5 * time.Minute,
+		SitemapGenerationsKept:       3,
+		SitemapMaxPublishDropPercent: 50,
+		SitemapStaticMerge:           true,
 		RobotsFilePath: map[Language]string{
 			English: "/tmp/dp_robot_file_en.txt",
 			Welsh:   "/tmp/dp_robot_file_cy.txt",
@@ -115,6 +148,7 @@ func Get() (*Config, error) {
 		},
 		SitemapSaveLocation:        "local",
 		SitemapLocalFile:           map[Language]string{English: "/tmp/dp-sitemap-en.xml", Welsh: "/tmp/dp-sitemap-cy.xml"},
+		SitemapLocalManifestFile:   "/tmp/dp-sitemap-manifest.json",
 		PublishingSitemapLocalFile: "/tmp/dp-publishing-sitemap.xml",
 		PublishingSitemapMaxSize:   500,
 		ZebedeeURL:                 "http://localhost:8082",
@@ -140,6 +174,9 @@ func Get() (*Config, error) {
 		UploadBucketName:         "dp-sitemap-bucket",
 		SitemapFileKey:           map[Language]string{English: "sitemap-en", Welsh: "sitemap-cy"},
 		PublishingSitemapFileKey: "publishing-sitemap",
+		LockFileKey:              "full-sitemap.lock",
+		SitemapManifestKey:       "sitemap-manifest.json",
+		RobotsFileKey:            map[Language]string{English: "robots-en.txt", Welsh: "robots-cy.txt"},
 		AwsRegion:                "eu-west-1",
 	}
 
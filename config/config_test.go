@@ -51,8 +51,30 @@ func TestConfig(t *testing.T) {
 				So(cfg.S3Config.SitemapFileKey[English], ShouldEqual, "sitemap-en")
 				So(cfg.S3Config.SitemapFileKey[Welsh], ShouldEqual, "sitemap-cy")
 				So(cfg.S3Config.PublishingSitemapFileKey, ShouldEqual, "publishing-sitemap")
+				So(cfg.S3Config.LockFileKey, ShouldEqual, "full-sitemap.lock")
+				So(cfg.S3Config.SitemapManifestKey, ShouldEqual, "sitemap-manifest.json")
+				So(cfg.SitemapLocalManifestFile, ShouldEqual, "/tmp/dp-sitemap-manifest.json")
+				So(cfg.SitemapGenerationsKept, ShouldEqual, 3)
+				So(cfg.SitemapMaxPublishDropPercent, ShouldEqual, 50)
+				So(cfg.SitemapForcePublish, ShouldBeFalse)
+				So(cfg.SitemapStaticMerge, ShouldBeTrue)
+				So(cfg.SitemapStaticDir, ShouldEqual, "")
 				So(cfg.RobotsFilePath, ShouldNotBeEmpty)
+				So(cfg.SitemapRobotsCheck, ShouldEqual, "")
+				So(cfg.RobotsSaveLocation, ShouldEqual, "")
+				So(cfg.S3Config.RobotsFileKey[English], ShouldEqual, "robots-en.txt")
+				So(cfg.S3Config.RobotsFileKey[Welsh], ShouldEqual, "robots-cy.txt")
+				So(cfg.SitemapGenerationCron, ShouldEqual, "")
+				So(cfg.SitemapGenerationAt, ShouldEqual, "")
+				So(cfg.SitemapGenerationTZ, ShouldEqual, "Europe/London")
+				So(cfg.SitemapGenerationOnStartup, ShouldBeTrue)
+				So(cfg.SitemapGenerationJitter, ShouldEqual, 0)
+				So(cfg.SitemapMaxAgeMultiplier, ShouldEqual, 3)
+				So(cfg.SitemapMaxURLDropPercent, ShouldEqual, 20)
+				So(cfg.SitemapMaxFailures, ShouldEqual, 3)
+				So(cfg.SitemapLockTTL, ShouldEqual, 5*time.Minute)
 				So(cfg.Debug, ShouldBeTrue)
+				So(cfg.AdminAuthToken, ShouldEqual, "")
 			})
 
 			Convey("Then a second call to config should return the same config", func() {
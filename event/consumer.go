@@ -2,9 +2,12 @@ package event
 
 import (
 	"context"
+	"time"
 
 	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"github.com/ONSdigital/dp-net/v2/request"
 	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/metrics"
 	"github.com/ONSdigital/dp-sitemap/schema"
 	"github.com/ONSdigital/log.go/v2/log"
 )
@@ -27,8 +30,7 @@ func Consume(ctx context.Context, messageConsumer kafka.IConsumerGroup, handler
 					log.Info(ctx, "closing event consumer loop because upstream channel is closed", log.Data{"worker_id": workerID})
 					return
 				}
-				messageCtx := context.Background()
-				processMessage(messageCtx, message, handler, cfg)
+				processMessage(message.Context(), message, handler, cfg)
 				message.Release()
 			case <-messageConsumer.Channels().Closer:
 				log.Info(ctx, "closing event consumer loop because closer channel is closed", log.Data{"worker_id": workerID})
@@ -54,10 +56,13 @@ func processMessage(ctx context.Context, message kafka.Message, handler Handler,
 		return
 	}
 
+	ctx = contextWithTraceID(ctx, event)
 	log.Info(ctx, "event received", log.Data{"event": event})
 
 	// handle - commit on failure (implement error handling to not commit if message needs to be consumed again)
+	start := time.Now()
 	err = handler.Handle(ctx, cfg, event)
+	metrics.EventDuration.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(start).Seconds())
 	if err != nil {
 		log.Error(ctx, "failed to handle event", err)
 		message.Commit()
@@ -69,6 +74,20 @@ func processMessage(ctx context.Context, message kafka.Message, handler Handler,
 	log.Info(ctx, "message committed", log.Data{"event": event})
 }
 
+// contextWithTraceID returns a copy of ctx carrying the event's trace ID as the request ID,
+// so that it is included in every log entry and forwarded on outbound requests.
+// If the event has no trace ID, the one from the kafka message headers is used, if any.
+func contextWithTraceID(ctx context.Context, event *ContentPublished) context.Context {
+	traceID := event.TraceID
+	if traceID == "" {
+		traceID, _ = ctx.Value(kafka.TraceIDHeaderKey).(string)
+	}
+	if traceID == "" {
+		return ctx
+	}
+	return request.WithRequestId(ctx, traceID)
+}
+
 // unmarshal converts a event instance to []byte.
 func unmarshal(message kafka.Message) (*ContentPublished, error) {
 	var event ContentPublished
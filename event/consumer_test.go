@@ -10,6 +10,7 @@ import (
 
 	kafka "github.com/ONSdigital/dp-kafka/v3"
 	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
+	"github.com/ONSdigital/dp-net/v2/request"
 	"github.com/ONSdigital/dp-sitemap/event"
 	"github.com/ONSdigital/dp-sitemap/event/mock"
 	"github.com/ONSdigital/dp-sitemap/schema"
@@ -95,6 +96,23 @@ func TestConsume(t *testing.T) {
 			})
 		})
 
+		Convey("And a kafka message for an event with a trace ID being sent to the Upstream channel", func() {
+			tracedEvent := event.ContentPublished{URI: "/economy", TraceID: "test-trace-id"}
+			message, _ := kafkatest.NewMessage(marshal(tracedEvent), 0)
+			mockConsumer.Channels().Upstream <- message
+
+			Convey("When consume message is called", func() {
+				handlerWg.Add(1)
+				event.Consume(testCtx, mockConsumer, mockEventHandler, &config.Config{KafkaConfig: config.KafkaConfig{NumWorkers: 1}})
+				handlerWg.Wait()
+
+				Convey("The event trace ID is set as the request ID on the handler context", func() {
+					So(len(mockEventHandler.HandleCalls()), ShouldEqual, 1)
+					So(request.GetRequestId(mockEventHandler.HandleCalls()[0].Ctx), ShouldEqual, "test-trace-id")
+				})
+			})
+		})
+
 		Convey("With a failing handler and a kafka message with the valid schema being sent to the Upstream channel", func() {
 			mockEventHandler.HandleFunc = func(ctx context.Context, config *config.Config, event *event.ContentPublished) error {
 				defer handlerWg.Done()
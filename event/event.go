@@ -2,10 +2,10 @@ package event
 
 // ContentPublished provides an avro structure for a Content Published event
 type ContentPublished struct {
-	URI          string `avro:"uri"`
-	DataType     string `avro:"data_type"`
-	CollectionID string `avro:"collection_id"`
-	JobID        string `avro:"job_id"`
-	SearchIndex  string `avro:"search_index"`
-	TraceID      string `avro:"trace_id"`
+	URI          string `avro:"uri" json:"uri"`
+	DataType     string `avro:"data_type" json:"data_type"`
+	CollectionID string `avro:"collection_id" json:"collection_id"`
+	JobID        string `avro:"job_id" json:"job_id"`
+	SearchIndex  string `avro:"search_index" json:"search_index"`
+	TraceID      string `avro:"trace_id" json:"trace_id"`
 }
@@ -2,26 +2,69 @@ package event
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
 
 	"github.com/ONSdigital/dp-sitemap/clients"
 	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/lock"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
 	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/google/uuid"
 )
 
+// defaultLockRetry is the time between attempts to take the generation lock while a full sitemap is generated
+const defaultLockRetry = time.Second
+
 type ContentPublishedHandler struct {
 	fileStore     sitemap.FileStore
+	sitemapFiles  sitemap.FileResolver
 	zebedeeClient clients.ZebedeeClient
 	config        *config.Config
 	fetcher       sitemap.Fetcher
+	lock          lock.Locker
+	lockOwner     string
+	lockRenewal   time.Duration
+	lockRetry     time.Duration
+	robots        *sitemap.RobotsCheck
 }
+type HandlerOptions func(*ContentPublishedHandler) *ContentPublishedHandler
 
-func NewContentPublishedHandler(store sitemap.FileStore, client clients.ZebedeeClient, cfg *config.Config, fetcher sitemap.Fetcher) *ContentPublishedHandler {
-	return &ContentPublishedHandler{
+// NewContentPublishedHandler returns a handler updating the live full sitemaps given by sitemapFiles
+func NewContentPublishedHandler(store sitemap.FileStore, sitemapFiles sitemap.FileResolver, client clients.ZebedeeClient, cfg *config.Config, fetcher sitemap.Fetcher, opts ...HandlerOptions) *ContentPublishedHandler {
+	h := &ContentPublishedHandler{
 		fileStore:     store,
+		sitemapFiles:  sitemapFiles,
 		zebedeeClient: client,
 		config:        cfg,
 		fetcher:       fetcher,
+		lockRetry:     defaultLockRetry,
+	}
+	for _, opt := range opts {
+		h = opt(h)
+	}
+	return h
+}
+
+// WithGenerationLock updates the sitemaps holding the lock of the full sitemap generation, renewed every renewal,
+// so that an update waits for a running generation and is made to the generation it publishes rather than being lost
+func WithGenerationLock(l lock.Locker, owner string, renewal time.Duration) HandlerOptions {
+	return func(h *ContentPublishedHandler) *ContentPublishedHandler {
+		h.lock = l
+		h.lockOwner = owner
+		h.lockRenewal = renewal
+		return h
+	}
+}
+
+// WithRobotsCheck checks the urls of the published pages against the robots rules before adding them to the
+// sitemaps, dropping or warning about the disallowed ones
+func WithRobotsCheck(c *sitemap.RobotsCheck) HandlerOptions {
+	return func(h *ContentPublishedHandler) *ContentPublishedHandler {
+		h.robots = c
+		return h
 	}
 }
 
@@ -37,74 +80,100 @@ func (h *ContentPublishedHandler) Handle(ctx context.Context, cfg *config.Config
 		return err
 	}
 
-	if pageInfo.URLs[config.English] != nil {
-		err = h.createSiteMap(ctx, config.English, cfg.SitemapLocalFile[config.English], pageInfo)
+	return h.withGenerationLock(ctx, func(ctx context.Context) error {
+		files, err := h.sitemapFiles.LiveFiles()
 		if err != nil {
+			log.Error(ctx, "error getting live sitemap files", err)
 			return err
 		}
-	}
 
-	if pageInfo.URLs[config.Welsh] != nil {
-		err = h.createSiteMap(ctx, config.Welsh, cfg.SitemapLocalFile[config.Welsh], pageInfo)
+		urls := h.robots.FilterPage(ctx, "publishing", pageInfo.URLs)
+		for _, lang := range []config.Language{config.English, config.Welsh} {
+			if urls[lang] == nil {
+				continue
+			}
+			if err = h.createSiteMap(ctx, files[lang], urls[lang]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Remove takes the page at the given path out of the sitemap of each language
+func (h *ContentPublishedHandler) Remove(ctx context.Context, cfg *config.Config, path string) error {
+	log.Info(ctx, "removing page from sitemaps", log.Data{"uri": path})
+	hostNames := map[config.Language]string{
+		config.English: cfg.DpOnsURLHostNameEn,
+		config.Welsh:   cfg.DpOnsURLHostNameCy,
+	}
+	return h.withGenerationLock(ctx, func(ctx context.Context) error {
+		files, err := h.sitemapFiles.LiveFiles()
 		if err != nil {
+			log.Error(ctx, "error getting live sitemap files", err)
 			return err
 		}
-	}
-
-	return nil
+		for _, lang := range []config.Language{config.English, config.Welsh} {
+			loc, err := url.JoinPath(hostNames[lang], path)
+			if err != nil {
+				log.Error(ctx, "error building page url", err, log.Data{"uri": path, "lang": lang})
+				return err
+			}
+			err = h.updateSiteMap(ctx, files[lang], func(currentSitemap io.Reader) (string, int, error) {
+				var remover sitemap.DefaultRemover
+				return remover.Remove(ctx, currentSitemap, loc)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (h *ContentPublishedHandler) createSiteMap(ctx context.Context, lang config.Language, sitemapName string, pageInfo *sitemap.PageInfo) error {
-	currentSitemapName := sitemapName
-	var tmpSitemapName string
-
-	tmpSitemapName, err := h.generateTempSitemap(ctx, currentSitemapName, lang, pageInfo)
-	if err != nil {
-		return err
+// withGenerationLock runs update holding the generation lock, if any, waiting for it while a full sitemap is generated
+func (h *ContentPublishedHandler) withGenerationLock(ctx context.Context, update func(ctx context.Context) error) error {
+	if h.lock == nil {
+		return update(ctx)
 	}
-
-	currentSitemap, err := h.fileStore.CreateFile(currentSitemapName)
-	if err != nil {
-		log.Error(ctx, "error creating current sitemap", err)
-		return err
+	owner := h.lockOwner + "-update-" + uuid.NewString()
+	for {
+		var err error
+		ran, lockErr := lock.Do(ctx, h.lock, owner, h.lockRenewal, func(ctx context.Context) {
+			err = update(ctx)
+		})
+		if lockErr != nil {
+			log.Error(ctx, "error acquiring full sitemap lock", lockErr, log.Data{"owner": owner})
+			return lockErr
+		}
+		if ran {
+			return err
+		}
+		log.Info(ctx, "full sitemap being generated, waiting to update the sitemaps", log.Data{"owner": owner})
+		retry := time.NewTimer(h.lockRetry)
+		select {
+		case <-ctx.Done():
+			retry.Stop()
+			return fmt.Errorf("sitemap update cancelled: %w", ctx.Err())
+		case <-retry.C:
+		}
 	}
-	defer currentSitemap.Close()
+}
 
-	tmpSitemap, err := h.fileStore.GetFile(tmpSitemapName)
-	if err != nil {
-		log.Error(ctx, "error opening temp sitemap", err)
-		return err
-	}
-	defer tmpSitemap.Close()
+func (h *ContentPublishedHandler) createSiteMap(ctx context.Context, sitemapName string, pageURL *sitemap.URL) error {
+	return h.updateSiteMap(ctx, sitemapName, func(currentSitemap io.Reader) (string, int, error) {
+		var adder sitemap.DefaultAdder
+		return adder.Add(ctx, currentSitemap, pageURL)
+	})
+}
 
-	err = h.fileStore.CopyFile(tmpSitemap, currentSitemap)
+// updateSiteMap changes the current sitemap with update. Events may be handled by several workers or instances at
+// once, so the sitemap is only saved if it has not changed since it was read, the update being retried otherwise.
+func (h *ContentPublishedHandler) updateSiteMap(ctx context.Context, sitemapName string, update sitemap.Update) error {
+	_, err := sitemap.UpdateFile(ctx, h.fileStore, sitemapName, sitemap.DefaultUpdateAttempts, update)
 	if err != nil {
-		log.Error(ctx, "error copying file", err)
+		log.Error(ctx, "error updating sitemap", err, log.Data{"filename": sitemapName})
 		return err
 	}
-
-	err = h.fileStore.DeleteFile(tmpSitemapName)
-	if err != nil {
-		log.Error(ctx, "error deleting temp sitemap", err)
-	}
-
 	return nil
 }
-
-func (h *ContentPublishedHandler) generateTempSitemap(ctx context.Context, currentSitemapName string, lang config.Language, pageInfo *sitemap.PageInfo) (string, error) {
-	currentSitemap, err := h.fileStore.GetFile(currentSitemapName)
-	if err != nil {
-		log.Error(ctx, "error opening current sitemap", err)
-		return "", err
-	}
-	defer currentSitemap.Close()
-
-	var adder sitemap.DefaultAdder
-	tmpSitemapName, _, err := adder.Add(currentSitemap, pageInfo.URLs[lang])
-	if err != nil {
-		log.Error(ctx, "error creating temp sitemap file", err)
-		return "", err
-	}
-
-	return tmpSitemapName, nil
-}
@@ -2,16 +2,28 @@ package event
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/zebedee"
 	mock2 "github.com/ONSdigital/dp-sitemap/clients/mock"
 	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/lock"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
 	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -22,7 +34,7 @@ func TestHandle(t *testing.T) {
 		fetcher := &mock.FetcherMock{}
 		zebedeeClient := &mock2.ZebedeeClientMock{}
 		cfg, _ := config.Get()
-		handler := NewContentPublishedHandler(store, zebedeeClient, cfg, fetcher)
+		handler := NewContentPublishedHandler(store, sitemap.Files(cfg.SitemapLocalFile), zebedeeClient, cfg, fetcher)
 		content := &ContentPublished{
 			URI:          "economy/environmentalaccounts/articles/testarticle3",
 			DataType:     "theDateType",
@@ -46,20 +58,11 @@ func TestHandle(t *testing.T) {
 			}}, nil
 		}
 
-		store.GetFileFunc = func(name string) (io.ReadCloser, error) {
-			return io.NopCloser(strings.NewReader("")), nil
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
 		}
 
-		store.CreateFileFunc = func(name string) (io.ReadWriteCloser, error) {
-			file, _ := os.CreateTemp("", name)
-			return file, nil
-		}
-
-		store.CopyFileFunc = func(src io.Reader, dest io.Writer) error {
-			return nil
-		}
-
-		store.DeleteFileFunc = func(name string) error {
+		store.SaveFileIfVersionFunc = func(name string, body io.Reader, version string) error {
 			return nil
 		}
 
@@ -78,3 +81,333 @@ func TestHandle(t *testing.T) {
 		})
 	})
 }
+
+func TestRemove(t *testing.T) {
+	Convey("When removing a page from the sitemaps", t, func() {
+		store := &mock.FileStoreMock{}
+		cfg, _ := config.Get()
+		handler := NewContentPublishedHandler(store, sitemap.Files(cfg.SitemapLocalFile), &mock2.ZebedeeClientMock{}, cfg, &mock.FetcherMock{})
+
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
+		}
+
+		store.SaveFileIfVersionFunc = func(name string, body io.Reader, version string) error {
+			return nil
+		}
+
+		err := handler.Remove(context.Background(), cfg, "/economy/environmentalaccounts/articles/testarticle3")
+		Convey("There should be no error", func() {
+			So(err, ShouldBeNil)
+		})
+		Convey("The sitemap of each language should be updated", func() {
+			So(store.GetFileWithVersionCalls(), ShouldHaveLength, 2)
+			So(store.GetFileWithVersionCalls()[0].Name, ShouldEqual, cfg.SitemapLocalFile[config.English])
+			So(store.GetFileWithVersionCalls()[1].Name, ShouldEqual, cfg.SitemapLocalFile[config.Welsh])
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 2)
+			So(store.SaveFileIfVersionCalls()[0].Name, ShouldEqual, cfg.SitemapLocalFile[config.English])
+			So(store.SaveFileIfVersionCalls()[1].Name, ShouldEqual, cfg.SitemapLocalFile[config.Welsh])
+			So(store.SaveFileIfVersionCalls()[0].Version, ShouldEqual, `"v1"`)
+		})
+	})
+}
+
+func TestUpdateS3(t *testing.T) {
+	Convey("Given a handler updating sitemaps in s3", t, func() {
+		uploaded := map[string]string{}
+		s3Client := &mock.S3ClientMock{
+			BucketNameFunc: func() string { return "bucket" },
+			HeadFunc: func(key string) (*s3.HeadObjectOutput, error) {
+				if body, ok := uploaded[key]; ok {
+					return &s3.HeadObjectOutput{ETag: aws.String(fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(body))))}, nil
+				}
+				return nil, awserr.New("NotFound", "not found", nil)
+			},
+			GetFunc: func(key string) (io.ReadCloser, *int64, error) {
+				if body, ok := uploaded[key]; ok {
+					return io.NopCloser(strings.NewReader(body)), nil, nil
+				}
+				return nil, nil, awserr.New("NoSuchKey", "not found", nil)
+			},
+			UploadFunc: func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+				b, err := io.ReadAll(input.Body)
+				uploaded[*input.Key] = string(b)
+				return &s3manager.UploadOutput{}, err
+			},
+		}
+		fetcher := &mock.FetcherMock{}
+		cfg, _ := config.Get()
+		files := sitemap.Files{config.English: "sitemap-en.xml", config.Welsh: "sitemap-cy.xml"}
+		handler := NewContentPublishedHandler(sitemap.NewS3Store(s3Client), files, &mock2.ZebedeeClientMock{}, cfg, fetcher)
+
+		locEn, _ := url.JoinPath(cfg.DpOnsURLHostNameEn, "/economy")
+		locCy, _ := url.JoinPath(cfg.DpOnsURLHostNameCy, "/economy")
+		fetcher.GetPageInfoFunc = func(ctx context.Context, path string) (*sitemap.PageInfo, error) {
+			return &sitemap.PageInfo{
+				ReleaseDate: "2006-01-02",
+				URLs: map[config.Language]*sitemap.URL{
+					config.English: {Loc: locEn, Lastmod: "2006-01-02"},
+					config.Welsh:   {Loc: locCy, Lastmod: "2006-01-02"},
+				},
+			}, nil
+		}
+
+		Convey("When a content published event is handled", func() {
+			err := handler.Handle(context.Background(), cfg, &ContentPublished{URI: "/economy"})
+
+			Convey("Then the sitemap of each language is uploaded with the page", func() {
+				So(err, ShouldBeNil)
+				So(uploaded, ShouldHaveLength, 2)
+				So(uploaded["sitemap-en.xml"], ShouldContainSubstring, "<loc>"+locEn+"</loc>")
+				So(uploaded["sitemap-cy.xml"], ShouldContainSubstring, "<loc>"+locCy+"</loc>")
+			})
+
+			Convey("And when the page is removed", func() {
+				err = handler.Remove(context.Background(), cfg, "/economy")
+
+				Convey("Then the sitemap of each language is uploaded without the page", func() {
+					So(err, ShouldBeNil)
+					So(uploaded["sitemap-en.xml"], ShouldNotContainSubstring, "economy")
+					So(uploaded["sitemap-cy.xml"], ShouldNotContainSubstring, "economy")
+				})
+			})
+		})
+	})
+}
+
+func TestUpdateReadFailure(t *testing.T) {
+	Convey("Given a handler updating sitemaps in s3 that can not be read", t, func() {
+		s3Client := &mock.S3ClientMock{
+			BucketNameFunc: func() string { return "bucket" },
+			HeadFunc: func(key string) (*s3.HeadObjectOutput, error) {
+				return nil, awserr.New("SlowDown", "please reduce your request rate", nil)
+			},
+			GetFunc: func(key string) (io.ReadCloser, *int64, error) {
+				return nil, nil, awserr.New("SlowDown", "please reduce your request rate", nil)
+			},
+			UploadFunc: func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+				return &s3manager.UploadOutput{}, nil
+			},
+		}
+		fetcher := &mock.FetcherMock{
+			GetPageInfoFunc: func(ctx context.Context, path string) (*sitemap.PageInfo, error) {
+				return &sitemap.PageInfo{
+					ReleaseDate: "2006-01-02",
+					URLs:        map[config.Language]*sitemap.URL{config.English: {Loc: "https://www.ons.gov.uk/economy", Lastmod: "2006-01-02"}},
+				}, nil
+			},
+		}
+		cfg, _ := config.Get()
+		files := sitemap.Files{config.English: "sitemap-en.xml", config.Welsh: "sitemap-cy.xml"}
+		handler := NewContentPublishedHandler(sitemap.NewS3Store(s3Client), files, &mock2.ZebedeeClientMock{}, cfg, fetcher)
+
+		Convey("When a content published event is handled", func() {
+			err := handler.Handle(context.Background(), cfg, &ContentPublished{URI: "/economy"})
+
+			Convey("Then the event fails and the live sitemap is not overwritten", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "SlowDown")
+				So(s3Client.UploadCalls(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a page is removed", func() {
+			err := handler.Remove(context.Background(), cfg, "/economy")
+
+			Convey("Then the removal fails and the live sitemap is not overwritten", func() {
+				So(err, ShouldNotBeNil)
+				So(s3Client.UploadCalls(), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	Convey("Given a handler updating local sitemaps", t, func() {
+		dir := t.TempDir()
+		cfg, _ := config.Get()
+		files := sitemap.Files{config.English: filepath.Join(dir, "sitemap_en.xml"), config.Welsh: filepath.Join(dir, "sitemap_cy.xml")}
+		fetcher := &mock.FetcherMock{
+			GetPageInfoFunc: func(ctx context.Context, path string) (*sitemap.PageInfo, error) {
+				locEn, _ := url.JoinPath(cfg.DpOnsURLHostNameEn, path)
+				locCy, _ := url.JoinPath(cfg.DpOnsURLHostNameCy, path)
+				return &sitemap.PageInfo{
+					ReleaseDate: "2006-01-02",
+					URLs: map[config.Language]*sitemap.URL{
+						config.English: {Loc: locEn, Lastmod: "2006-01-02"},
+						config.Welsh:   {Loc: locCy, Lastmod: "2006-01-02"},
+					},
+				}, nil
+			},
+		}
+		handler := NewContentPublishedHandler(&sitemap.LocalStore{}, files, &mock2.ZebedeeClientMock{}, cfg, fetcher)
+
+		const pages = 4
+		for i := 0; i < pages; i++ {
+			So(handler.Handle(context.Background(), cfg, &ContentPublished{URI: fmt.Sprintf("/removed/%d", i)}), ShouldBeNil)
+		}
+
+		Convey("When pages are added and removed by several workers at once", func() {
+			var wg sync.WaitGroup
+			errs := make(chan error, 2*pages)
+			for i := 0; i < pages; i++ {
+				wg.Add(2)
+				go func(i int) {
+					defer wg.Done()
+					errs <- handler.Handle(context.Background(), cfg, &ContentPublished{URI: fmt.Sprintf("/added/%d", i)})
+				}(i)
+				go func(i int) {
+					defer wg.Done()
+					errs <- handler.Remove(context.Background(), cfg, fmt.Sprintf("/removed/%d", i))
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+
+			Convey("Then no update is lost", func() {
+				for err := range errs {
+					So(err, ShouldBeNil)
+				}
+				for _, name := range files {
+					content, err := os.ReadFile(name)
+					So(err, ShouldBeNil)
+					for i := 0; i < pages; i++ {
+						So(string(content), ShouldContainSubstring, fmt.Sprintf("/added/%d</loc>", i))
+						So(string(content), ShouldNotContainSubstring, fmt.Sprintf("/removed/%d</loc>", i))
+					}
+					count, err := sitemap.CountURLs(strings.NewReader(string(content)))
+					So(err, ShouldBeNil)
+					So(count, ShouldEqual, pages)
+				}
+			})
+		})
+	})
+}
+
+func TestGenerationLock(t *testing.T) {
+	Convey("Given a handler updating live sitemap generations while a full sitemap is generated", t, func() {
+		dir := t.TempDir()
+		cfg, _ := config.Get()
+		store := &sitemap.LocalStore{}
+		generations := sitemap.NewGenerations(store, filepath.Join(dir, "manifest.json"), sitemap.Files{config.English: filepath.Join(dir, "sitemap_en.xml")}, 2)
+		previous := generations.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		So(store.SaveFile(previous.Files[config.English], strings.NewReader("")), ShouldBeNil)
+		So(generations.Publish(context.Background(), previous), ShouldBeNil)
+
+		fetcher := &mock.FetcherMock{
+			GetPageInfoFunc: func(ctx context.Context, path string) (*sitemap.PageInfo, error) {
+				return &sitemap.PageInfo{
+					ReleaseDate: "2006-01-02",
+					URLs:        map[config.Language]*sitemap.URL{config.English: {Loc: "https://www.ons.gov.uk" + path, Lastmod: "2006-01-02"}},
+				}, nil
+			},
+		}
+		generationLock := lock.NewMemoryLocker(time.Minute)
+		handler := NewContentPublishedHandler(store, generations, &mock2.ZebedeeClientMock{}, cfg, fetcher,
+			WithGenerationLock(generationLock, "instance", time.Second))
+		handler.lockRetry = 10 * time.Millisecond
+
+		acquired, err := generationLock.Acquire(context.Background(), "generator")
+		So(err, ShouldBeNil)
+		So(acquired, ShouldBeTrue)
+
+		Convey("When a page is published before the new generation is", func() {
+			done := make(chan error)
+			go func() {
+				done <- handler.Handle(context.Background(), cfg, &ContentPublished{URI: "/economy"})
+			}()
+			time.Sleep(50 * time.Millisecond)
+			select {
+			case err = <-done:
+				t.Fatalf("sitemap updated while the full sitemap is generated: %v", err)
+			default:
+			}
+
+			next := generations.New(time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC))
+			So(store.SaveFile(next.Files[config.English], strings.NewReader("")), ShouldBeNil)
+			So(generations.Publish(context.Background(), next), ShouldBeNil)
+			So(generationLock.Release(context.Background(), "generator"), ShouldBeNil)
+			err = <-done
+
+			Convey("Then the page is added to the new generation once it is published", func() {
+				So(err, ShouldBeNil)
+				content, err := os.ReadFile(next.Files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "<loc>https://www.ons.gov.uk/economy</loc>")
+				content, err = os.ReadFile(previous.Files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a page is removed and the generation does not end before the removal is cancelled", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err = handler.Remove(ctx, cfg, "/economy")
+
+			Convey("Then the removal fails without changing the live sitemap", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+				content, err := os.ReadFile(previous.Files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestHandleRobotsCheck(t *testing.T) {
+	rules := &mock.RobotsRulesMock{
+		AllowedFunc: func(lang config.Language, userAgent, path string) bool {
+			return lang != config.Welsh || !strings.HasPrefix(path, "/private")
+		},
+	}
+	fetcher := &mock.FetcherMock{
+		GetPageInfoFunc: func(ctx context.Context, path string) (*sitemap.PageInfo, error) {
+			locEn := "https://www.ons.gov.uk" + path
+			locCy := "https://cy.ons.gov.uk" + path
+			return &sitemap.PageInfo{
+				ReleaseDate: "2006-01-02",
+				URLs: map[config.Language]*sitemap.URL{
+					config.English: {Loc: locEn, Lastmod: "2006-01-02", Alternate: &sitemap.AlternateURL{Rel: "alternate", Lang: "cy", Link: locCy}},
+					config.Welsh:   {Loc: locCy, Lastmod: "2006-01-02", Alternate: &sitemap.AlternateURL{Rel: "alternate", Lang: "en", Link: locEn}},
+				},
+			}, nil
+		},
+	}
+
+	Convey("Given a handler checking the published pages against robots rules disallowing some welsh pages", t, func() {
+		dir := t.TempDir()
+		cfg, _ := config.Get()
+		files := sitemap.Files{config.English: filepath.Join(dir, "sitemap_en.xml"), config.Welsh: filepath.Join(dir, "sitemap_cy.xml")}
+		newHandler := func(mode string) *ContentPublishedHandler {
+			check, err := sitemap.NewRobotsCheck(rules, mode)
+			So(err, ShouldBeNil)
+			return NewContentPublishedHandler(&sitemap.LocalStore{}, files, &mock2.ZebedeeClientMock{}, cfg, fetcher, WithRobotsCheck(check))
+		}
+
+		Convey("When a disallowed page is published dropping the disallowed urls", func() {
+			err := newHandler(sitemap.RobotsCheckDrop).Handle(context.Background(), cfg, &ContentPublished{URI: "/private"})
+
+			Convey("Then only the english page is added, without its alternate link to the welsh page", func() {
+				So(err, ShouldBeNil)
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "<loc>https://www.ons.gov.uk/private</loc>")
+				So(string(content), ShouldNotContainSubstring, "https://cy.ons.gov.uk/private")
+				_, err = os.Stat(files[config.Welsh])
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("When a disallowed page is published warning about the disallowed urls", func() {
+			err := newHandler(sitemap.RobotsCheckWarn).Handle(context.Background(), cfg, &ContentPublished{URI: "/private"})
+
+			Convey("Then it is added to the sitemap of each language", func() {
+				So(err, ShouldBeNil)
+				content, err := os.ReadFile(files[config.Welsh])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "<loc>https://cy.ons.gov.uk/private</loc>")
+			})
+		})
+	})
+}
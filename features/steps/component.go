@@ -12,6 +12,7 @@ import (
 	"github.com/ONSdigital/dp-kafka/v3/kafkatest"
 	dphttp "github.com/ONSdigital/dp-net/v2/http"
 	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/robotseo"
 	"github.com/ONSdigital/dp-sitemap/service"
 	"github.com/ONSdigital/dp-sitemap/service/mock"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
@@ -36,6 +37,7 @@ type Component struct {
 	cfg               *config.Config
 	files             map[string]string
 	welshVersion      map[string]bool
+	robotFileWriter   *robotseo.RobotFileWriter
 }
 
 func NewComponent() *Component {
@@ -18,6 +18,8 @@ import (
 	"github.com/ONSdigital/dp-sitemap/robotseo"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
 	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/cucumber/godog"
 	es710 "github.com/elastic/go-elasticsearch/v7"
@@ -26,7 +28,7 @@ import (
 )
 
 func (c *Component) RegisterSteps(ctx *godog.ScenarioContext) {
-	ctx.Step(`^i have my robots config files in the folder "([^"]*)"$`, iHaveMyRobotsConfigFilesInTheFolder)
+	ctx.Step(`^i have my robots config files in the folder "([^"]*)"$`, c.iHaveMyRobotsConfigFilesInTheFolder)
 	ctx.Step(`^i invoke writejson with the sitemap "([^"]*)"$`, c.iInvokeWritejsonWithTheSitemap)
 	ctx.Step(`^the content of the resulting robots file must be$`, c.theContentOfTheResultingRobotsFileMustBe)
 	ctx.Step(`^I generate a local sitemap$`, c.iGenerateLocalSitemap)
@@ -141,7 +143,7 @@ func (c *Component) iGenerateLocalSitemap() error {
 		sitemap.WithFullSitemapFiles(c.cfg.SitemapLocalFile),
 		sitemap.WithAdder(&sitemap.DefaultAdder{}),
 	)
-	err = generator.MakeFullSitemap(context.Background())
+	_, err = generator.MakeFullSitemap(context.Background())
 	if err != nil {
 		return err
 	}
@@ -222,6 +224,15 @@ func (c *Component) iGenerateS3Sitemap() error {
 		return nil, nil
 	}
 	s3uploader.BucketNameFunc = func() string { return c.cfg.S3Config.UploadBucketName }
+	s3uploader.HeadFunc = func(key string) (*s3.HeadObjectOutput, error) {
+		if _, ok := c.S3UploadedSitemap[key]; !ok {
+			return nil, awserr.New("NotFound", "not found", nil)
+		}
+		return &s3.HeadObjectOutput{}, nil
+	}
+	s3uploader.GetFunc = func(key string) (io.ReadCloser, *int64, error) {
+		return io.NopCloser(strings.NewReader(c.S3UploadedSitemap[key])), nil, nil
+	}
 
 	scroller := sitemap.NewElasticScroll(c.EsClient, c.cfg)
 
@@ -235,7 +246,7 @@ func (c *Component) iGenerateS3Sitemap() error {
 		sitemap.WithFullSitemapFiles(c.cfg.S3Config.SitemapFileKey),
 		sitemap.WithAdder(&sitemap.DefaultAdder{}),
 	)
-	err = generator.MakeFullSitemap(context.Background())
+	_, err = generator.MakeFullSitemap(context.Background())
 	if err != nil {
 		return err
 	}
@@ -250,14 +261,14 @@ func (c *Component) theContentOfTheS3SitemapShouldBe(arg1 *godog.DocString) erro
 	return nil
 }
 
-func iHaveMyRobotsConfigFilesInTheFolder(arg1 string) error {
-	robotseo.Init(arg1)
-	return nil
+func (c *Component) iHaveMyRobotsConfigFilesInTheFolder(arg1 string) error {
+	var err error
+	c.robotFileWriter, err = robotseo.NewRobotFileWriter(arg1)
+	return err
 }
 
 func (c *Component) iInvokeWritejsonWithTheSitemap(arg1 string) error {
-	fw := robotseo.RobotFileWriter{}
-	body := fw.GetRobotsFileBody(config.English, map[config.Language]string{config.English: arg1})
+	body := c.robotFileWriter.GetRobotsFileBody(config.English, arg1)
 	err := os.WriteFile(c.cfg.RobotsFilePath[config.English], []byte(body), 0o600)
 	if err != nil {
 		return fmt.Errorf("failed to write to robots file: %w", err)
@@ -0,0 +1,112 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+//go:generate moq -out mock/locker.go -pkg mock . Locker
+
+// Locker is a lock held by at most one owner at a time. The lock expires once
+// its time to live has passed without being acquired again by its owner.
+type Locker interface {
+	// Acquire takes the lock for owner if it is free, has expired, or is already held
+	// by owner, in which case its expiry is extended. It reports whether owner holds the lock.
+	Acquire(ctx context.Context, owner string) (bool, error)
+	// Release frees the lock if it is held by owner
+	Release(ctx context.Context, owner string) error
+}
+
+// ErrInvalidRenewal is returned when the lock renewal period is not positive
+var ErrInvalidRenewal = errors.New("lock renewal period must be positive")
+
+// Do runs fn if the lock can be acquired for owner, renewing it every renewal
+// while fn runs and releasing it afterwards. It reports whether fn was run.
+// The context given to fn is cancelled if the lock can not be renewed, as
+// another owner may then acquire it.
+func Do(ctx context.Context, l Locker, owner string, renewal time.Duration, fn func(ctx context.Context)) (bool, error) {
+	if renewal <= 0 {
+		return false, fmt.Errorf("%w, got %s", ErrInvalidRenewal, renewal)
+	}
+	acquired, err := l.Acquire(ctx, owner)
+	if err != nil || !acquired {
+		return false, err
+	}
+	defer func() {
+		if releaseErr := l.Release(ctx, owner); releaseErr != nil {
+			log.Error(ctx, "failed to release lock", releaseErr, log.Data{"owner": owner})
+		}
+	}()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(renewal)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				renewed, renewErr := l.Acquire(ctx, owner)
+				if renewErr != nil || !renewed {
+					log.Error(ctx, "failed to renew lock, cancelling its run", renewErr, log.Data{"owner": owner, "renewed": renewed})
+					cancel()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	fn(runCtx)
+	close(done)
+	wg.Wait()
+	return true, nil
+}
+
+// MemoryLocker is a Locker for owners within a single process
+type MemoryLocker struct {
+	mx      sync.Mutex
+	ttl     time.Duration
+	owner   string
+	expires time.Time
+}
+
+// NewMemoryLocker returns a lock expiring after ttl
+func NewMemoryLocker(ttl time.Duration) *MemoryLocker {
+	return &MemoryLocker{
+		ttl: ttl,
+	}
+}
+
+func (l *MemoryLocker) Acquire(_ context.Context, owner string) (bool, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := time.Now()
+	if l.owner != "" && l.owner != owner && now.Before(l.expires) {
+		return false, nil
+	}
+	l.owner = owner
+	l.expires = now.Add(l.ttl)
+	return true, nil
+}
+
+func (l *MemoryLocker) Release(_ context.Context, owner string) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.owner == owner {
+		l.owner = ""
+	}
+	return nil
+}
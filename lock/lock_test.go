@@ -0,0 +1,194 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/lock"
+	"github.com/ONSdigital/dp-sitemap/lock/mock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMemoryLocker(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a lock held by an owner", t, func() {
+		l := lock.NewMemoryLocker(50 * time.Millisecond)
+		acquired, err := l.Acquire(ctx, "a")
+		So(err, ShouldBeNil)
+		So(acquired, ShouldBeTrue)
+
+		Convey("Then another owner can not acquire it", func() {
+			acquired, err = l.Acquire(ctx, "b")
+			So(err, ShouldBeNil)
+			So(acquired, ShouldBeFalse)
+		})
+
+		Convey("Then the same owner can acquire it again", func() {
+			acquired, err = l.Acquire(ctx, "a")
+			So(err, ShouldBeNil)
+			So(acquired, ShouldBeTrue)
+		})
+
+		Convey("When it is released by another owner", func() {
+			So(l.Release(ctx, "b"), ShouldBeNil)
+
+			Convey("Then it is still held", func() {
+				acquired, err = l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeFalse)
+			})
+		})
+
+		Convey("When it is released by its owner", func() {
+			So(l.Release(ctx, "a"), ShouldBeNil)
+
+			Convey("Then another owner can acquire it", func() {
+				acquired, err = l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeTrue)
+			})
+		})
+
+		Convey("When it expires", func() {
+			time.Sleep(60 * time.Millisecond)
+
+			Convey("Then another owner can acquire it", func() {
+				acquired, err = l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a free lock", t, func() {
+		l := lock.NewMemoryLocker(time.Minute)
+
+		Convey("When a function is run with the lock", func() {
+			var heldDuringRun bool
+			ran, err := lock.Do(ctx, l, "a", time.Minute, func(ctx context.Context) {
+				acquired, _ := l.Acquire(ctx, "b")
+				heldDuringRun = !acquired
+			})
+
+			Convey("Then the function is run while the lock is held", func() {
+				So(err, ShouldBeNil)
+				So(ran, ShouldBeTrue)
+				So(heldDuringRun, ShouldBeTrue)
+			})
+			Convey("Then the lock is released afterwards", func() {
+				acquired, err := l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a lock held by another owner", t, func() {
+		l := lock.NewMemoryLocker(time.Minute)
+		_, err := l.Acquire(ctx, "b")
+		So(err, ShouldBeNil)
+
+		Convey("When a function is run with the lock", func() {
+			called := false
+			ran, err := lock.Do(ctx, l, "a", time.Minute, func(context.Context) { called = true })
+
+			Convey("Then the function is not run", func() {
+				So(err, ShouldBeNil)
+				So(ran, ShouldBeFalse)
+				So(called, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a lock that can not be acquired", t, func() {
+		l := &mock.LockerMock{
+			AcquireFunc: func(ctx context.Context, owner string) (bool, error) { return false, errors.New("store error") },
+		}
+
+		Convey("When a function is run with the lock", func() {
+			called := false
+			ran, err := lock.Do(ctx, l, "a", time.Minute, func(context.Context) { called = true })
+
+			Convey("Then the error is returned without running the function", func() {
+				So(err, ShouldNotBeNil)
+				So(ran, ShouldBeFalse)
+				So(called, ShouldBeFalse)
+				So(l.ReleaseCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+
+	Convey("Given a lock renewed while a long function runs", t, func() {
+		var acquisitions atomic.Int32
+		l := &mock.LockerMock{
+			AcquireFunc: func(ctx context.Context, owner string) (bool, error) {
+				acquisitions.Add(1)
+				return true, nil
+			},
+			ReleaseFunc: func(ctx context.Context, owner string) error { return nil },
+		}
+
+		Convey("When the function runs for several renewal periods", func() {
+			ran, err := lock.Do(ctx, l, "a", 10*time.Millisecond, func(context.Context) { time.Sleep(55 * time.Millisecond) })
+
+			Convey("Then the lock is renewed and released", func() {
+				So(err, ShouldBeNil)
+				So(ran, ShouldBeTrue)
+				So(acquisitions.Load(), ShouldBeGreaterThanOrEqualTo, 3)
+				So(l.ReleaseCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a lock that is lost while a function runs", t, func() {
+		var acquisitions atomic.Int32
+		l := &mock.LockerMock{
+			AcquireFunc: func(ctx context.Context, owner string) (bool, error) {
+				return acquisitions.Add(1) == 1, nil
+			},
+			ReleaseFunc: func(ctx context.Context, owner string) error { return nil },
+		}
+
+		Convey("When the function runs until its context is cancelled", func() {
+			var runErr error
+			ran, err := lock.Do(ctx, l, "a", 10*time.Millisecond, func(ctx context.Context) {
+				select {
+				case <-ctx.Done():
+					runErr = ctx.Err()
+				case <-time.After(time.Second):
+				}
+			})
+
+			Convey("Then its context is cancelled at the failed renewal", func() {
+				So(err, ShouldBeNil)
+				So(ran, ShouldBeTrue)
+				So(runErr, ShouldEqual, context.Canceled)
+				So(acquisitions.Load(), ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("Given a renewal period that is not positive", t, func() {
+		l := &mock.LockerMock{}
+
+		Convey("When a function is run with the lock", func() {
+			called := false
+			ran, err := lock.Do(ctx, l, "a", 0, func(context.Context) { called = true })
+
+			Convey("Then an error is returned without acquiring the lock", func() {
+				So(err, ShouldWrap, lock.ErrInvalidRenewal)
+				So(ran, ShouldBeFalse)
+				So(called, ShouldBeFalse)
+				So(l.AcquireCalls(), ShouldHaveLength, 0)
+			})
+		})
+	})
+}
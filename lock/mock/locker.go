@@ -0,0 +1,132 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/ONSdigital/dp-sitemap/lock"
+	"sync"
+)
+
+// Ensure, that LockerMock does implement lock.Locker.
+// If this is not the case, regenerate this file with moq.
+var _ lock.Locker = &LockerMock{}
+
+// LockerMock is a mock implementation of lock.Locker.
+//
+//	func TestSomethingThatUsesLocker(t *testing.T) {
+//
+//		// make and configure a mocked lock.Locker
+//		mockedLocker := &LockerMock{
+//			AcquireFunc: func(ctx context.Context, owner string) (bool, error) {
+//				panic("mock out the Acquire method")
+//			},
+//			ReleaseFunc: func(ctx context.Context, owner string) error {
+//				panic("mock out the Release method")
+//			},
+//		}
+//
+//		// use mockedLocker in code that requires lock.Locker
+//		// and then make assertions.
+//
+//	}
+type LockerMock struct {
+	// AcquireFunc mocks the Acquire method.
+	AcquireFunc func(ctx context.Context, owner string) (bool, error)
+
+	// ReleaseFunc mocks the Release method.
+	ReleaseFunc func(ctx context.Context, owner string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Acquire holds details about calls to the Acquire method.
+		Acquire []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Owner is the owner argument value.
+			Owner string
+		}
+		// Release holds details about calls to the Release method.
+		Release []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Owner is the owner argument value.
+			Owner string
+		}
+	}
+	lockAcquire sync.RWMutex
+	lockRelease sync.RWMutex
+}
+
+// Acquire calls AcquireFunc.
+func (mock *LockerMock) Acquire(ctx context.Context, owner string) (bool, error) {
+	if mock.AcquireFunc == nil {
+		panic("LockerMock.AcquireFunc: method is nil but Locker.Acquire was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Owner string
+	}{
+		Ctx:   ctx,
+		Owner: owner,
+	}
+	mock.lockAcquire.Lock()
+	mock.calls.Acquire = append(mock.calls.Acquire, callInfo)
+	mock.lockAcquire.Unlock()
+	return mock.AcquireFunc(ctx, owner)
+}
+
+// AcquireCalls gets all the calls that were made to Acquire.
+// Check the length with:
+//
+//	len(mockedLocker.AcquireCalls())
+func (mock *LockerMock) AcquireCalls() []struct {
+	Ctx   context.Context
+	Owner string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Owner string
+	}
+	mock.lockAcquire.RLock()
+	calls = mock.calls.Acquire
+	mock.lockAcquire.RUnlock()
+	return calls
+}
+
+// Release calls ReleaseFunc.
+func (mock *LockerMock) Release(ctx context.Context, owner string) error {
+	if mock.ReleaseFunc == nil {
+		panic("LockerMock.ReleaseFunc: method is nil but Locker.Release was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Owner string
+	}{
+		Ctx:   ctx,
+		Owner: owner,
+	}
+	mock.lockRelease.Lock()
+	mock.calls.Release = append(mock.calls.Release, callInfo)
+	mock.lockRelease.Unlock()
+	return mock.ReleaseFunc(ctx, owner)
+}
+
+// ReleaseCalls gets all the calls that were made to Release.
+// Check the length with:
+//
+//	len(mockedLocker.ReleaseCalls())
+func (mock *LockerMock) ReleaseCalls() []struct {
+	Ctx   context.Context
+	Owner string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Owner string
+	}
+	mock.lockRelease.RLock()
+	calls = mock.calls.Release
+	mock.lockRelease.RUnlock()
+	return calls
+}
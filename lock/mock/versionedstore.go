@@ -0,0 +1,132 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"github.com/ONSdigital/dp-sitemap/lock"
+	"io"
+	"sync"
+)
+
+// Ensure, that VersionedStoreMock does implement lock.VersionedStore.
+// If this is not the case, regenerate this file with moq.
+var _ lock.VersionedStore = &VersionedStoreMock{}
+
+// VersionedStoreMock is a mock implementation of lock.VersionedStore.
+//
+//	func TestSomethingThatUsesVersionedStore(t *testing.T) {
+//
+//		// make and configure a mocked lock.VersionedStore
+//		mockedVersionedStore := &VersionedStoreMock{
+//			GetFileWithVersionFunc: func(name string) (io.ReadCloser, string, error) {
+//				panic("mock out the GetFileWithVersion method")
+//			},
+//			SaveFileIfVersionFunc: func(name string, body io.Reader, version string) error {
+//				panic("mock out the SaveFileIfVersion method")
+//			},
+//		}
+//
+//		// use mockedVersionedStore in code that requires lock.VersionedStore
+//		// and then make assertions.
+//
+//	}
+type VersionedStoreMock struct {
+	// GetFileWithVersionFunc mocks the GetFileWithVersion method.
+	GetFileWithVersionFunc func(name string) (io.ReadCloser, string, error)
+
+	// SaveFileIfVersionFunc mocks the SaveFileIfVersion method.
+	SaveFileIfVersionFunc func(name string, body io.Reader, version string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// GetFileWithVersion holds details about calls to the GetFileWithVersion method.
+		GetFileWithVersion []struct {
+			// Name is the name argument value.
+			Name string
+		}
+		// SaveFileIfVersion holds details about calls to the SaveFileIfVersion method.
+		SaveFileIfVersion []struct {
+			// Name is the name argument value.
+			Name string
+			// Body is the body argument value.
+			Body io.Reader
+			// Version is the version argument value.
+			Version string
+		}
+	}
+	lockGetFileWithVersion sync.RWMutex
+	lockSaveFileIfVersion  sync.RWMutex
+}
+
+// GetFileWithVersion calls GetFileWithVersionFunc.
+func (mock *VersionedStoreMock) GetFileWithVersion(name string) (io.ReadCloser, string, error) {
+	if mock.GetFileWithVersionFunc == nil {
+		panic("VersionedStoreMock.GetFileWithVersionFunc: method is nil but VersionedStore.GetFileWithVersion was just called")
+	}
+	callInfo := struct {
+		Name string
+	}{
+		Name: name,
+	}
+	mock.lockGetFileWithVersion.Lock()
+	mock.calls.GetFileWithVersion = append(mock.calls.GetFileWithVersion, callInfo)
+	mock.lockGetFileWithVersion.Unlock()
+	return mock.GetFileWithVersionFunc(name)
+}
+
+// GetFileWithVersionCalls gets all the calls that were made to GetFileWithVersion.
+// Check the length with:
+//
+//	len(mockedVersionedStore.GetFileWithVersionCalls())
+func (mock *VersionedStoreMock) GetFileWithVersionCalls() []struct {
+	Name string
+} {
+	var calls []struct {
+		Name string
+	}
+	mock.lockGetFileWithVersion.RLock()
+	calls = mock.calls.GetFileWithVersion
+	mock.lockGetFileWithVersion.RUnlock()
+	return calls
+}
+
+// SaveFileIfVersion calls SaveFileIfVersionFunc.
+func (mock *VersionedStoreMock) SaveFileIfVersion(name string, body io.Reader, version string) error {
+	if mock.SaveFileIfVersionFunc == nil {
+		panic("VersionedStoreMock.SaveFileIfVersionFunc: method is nil but VersionedStore.SaveFileIfVersion was just called")
+	}
+	callInfo := struct {
+		Name    string
+		Body    io.Reader
+		Version string
+	}{
+		Name:    name,
+		Body:    body,
+		Version: version,
+	}
+	mock.lockSaveFileIfVersion.Lock()
+	mock.calls.SaveFileIfVersion = append(mock.calls.SaveFileIfVersion, callInfo)
+	mock.lockSaveFileIfVersion.Unlock()
+	return mock.SaveFileIfVersionFunc(name, body, version)
+}
+
+// SaveFileIfVersionCalls gets all the calls that were made to SaveFileIfVersion.
+// Check the length with:
+//
+//	len(mockedVersionedStore.SaveFileIfVersionCalls())
+func (mock *VersionedStoreMock) SaveFileIfVersionCalls() []struct {
+	Name    string
+	Body    io.Reader
+	Version string
+} {
+	var calls []struct {
+		Name    string
+		Body    io.Reader
+		Version string
+	}
+	mock.lockSaveFileIfVersion.RLock()
+	calls = mock.calls.SaveFileIfVersion
+	mock.lockSaveFileIfVersion.RUnlock()
+	return calls
+}
@@ -0,0 +1,115 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+//go:generate moq -out mock/versionedstore.go -pkg mock . VersionedStore
+
+// VersionedStore is a store supporting conditional writes of its files
+type VersionedStore interface {
+	GetFileWithVersion(name string) (body io.ReadCloser, version string, err error)
+	SaveFileIfVersion(name string, body io.Reader, version string) error
+}
+
+// lockFile is the content of the file backing a StoreLocker
+type lockFile struct {
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+// StoreLocker is a Locker backed by a file in a store shared between processes,
+// such as an s3 bucket. Conditional writes of the file make sure only one owner
+// can take the lock at a time.
+type StoreLocker struct {
+	store VersionedStore
+	name  string
+	ttl   time.Duration
+}
+
+// NewStoreLocker returns a lock expiring after ttl, kept in the file name of store
+func NewStoreLocker(store VersionedStore, name string, ttl time.Duration) *StoreLocker {
+	return &StoreLocker{
+		store: store,
+		name:  name,
+		ttl:   ttl,
+	}
+}
+
+func (l *StoreLocker) Acquire(ctx context.Context, owner string) (bool, error) {
+	current, version, err := l.read()
+	if err != nil {
+		return false, err
+	}
+	if current.Owner != "" && current.Owner != owner && time.Now().Before(current.Expires) {
+		return false, nil
+	}
+	if current.Owner != "" && current.Owner != owner {
+		log.Info(ctx, "taking over expired lock", log.Data{"lock": l.name, "previous_owner": current.Owner, "expired": current.Expires})
+	}
+
+	err = l.write(lockFile{Owner: owner, Expires: time.Now().Add(l.ttl)}, version)
+	if errors.Is(err, sitemap.ErrVersionConflict) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (l *StoreLocker) Release(_ context.Context, owner string) error {
+	current, version, err := l.read()
+	if err != nil {
+		return err
+	}
+	if current.Owner != owner {
+		return nil
+	}
+	err = l.write(lockFile{}, version)
+	if errors.Is(err, sitemap.ErrVersionConflict) {
+		// the lock has been taken by another owner since
+		return nil
+	}
+	return err
+}
+
+// read returns the current content of the lock file and its version, which is empty if the file does not exist
+func (l *StoreLocker) read() (lockFile, string, error) {
+	var current lockFile
+	body, version, err := l.store.GetFileWithVersion(l.name)
+	if errors.Is(err, sitemap.ErrFileNotFound) {
+		return current, "", nil
+	}
+	if err != nil {
+		return current, "", fmt.Errorf("failed to read lock file: %w", err)
+	}
+	defer body.Close()
+
+	if err = json.NewDecoder(body).Decode(&current); err != nil && !errors.Is(err, io.EOF) {
+		return current, "", fmt.Errorf("failed to decode lock file: %w", err)
+	}
+	return current, version, nil
+}
+
+// write saves the lock file if it is still at the given version
+func (l *StoreLocker) write(content lockFile, version string) error {
+	b, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Errorf("failed to encode lock file: %w", err)
+	}
+	err = l.store.SaveFileIfVersion(l.name, bytes.NewReader(b), version)
+	if err != nil && !errors.Is(err, sitemap.ErrVersionConflict) {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package lock_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/lock"
+	"github.com/ONSdigital/dp-sitemap/lock/mock"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// versionedFile is an in-memory file supporting conditional writes, like an s3 object
+type versionedFile struct {
+	mx      sync.Mutex
+	content []byte
+	version int
+}
+
+func (f *versionedFile) store() *mock.VersionedStoreMock {
+	return &mock.VersionedStoreMock{
+		GetFileWithVersionFunc: func(name string) (io.ReadCloser, string, error) {
+			f.mx.Lock()
+			defer f.mx.Unlock()
+			if f.version == 0 {
+				return nil, "", sitemap.ErrFileNotFound
+			}
+			return io.NopCloser(bytes.NewReader(f.content)), strconv.Itoa(f.version), nil
+		},
+		SaveFileIfVersionFunc: func(name string, body io.Reader, version string) error {
+			f.mx.Lock()
+			defer f.mx.Unlock()
+			current := ""
+			if f.version != 0 {
+				current = strconv.Itoa(f.version)
+			}
+			if version != current {
+				return sitemap.ErrVersionConflict
+			}
+			content, err := io.ReadAll(body)
+			if err != nil {
+				return err
+			}
+			f.content = content
+			f.version++
+			return nil
+		},
+	}
+}
+
+func TestStoreLocker(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a lock with no lock file", t, func() {
+		file := &versionedFile{}
+		store := file.store()
+		l := lock.NewStoreLocker(store, "full-sitemap.lock", 50*time.Millisecond)
+
+		Convey("When an owner acquires it", func() {
+			acquired, err := l.Acquire(ctx, "a")
+
+			Convey("Then the lock file is created", func() {
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeTrue)
+				So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 1)
+				So(store.SaveFileIfVersionCalls()[0].Name, ShouldEqual, "full-sitemap.lock")
+				So(store.SaveFileIfVersionCalls()[0].Version, ShouldEqual, "")
+				So(string(file.content), ShouldContainSubstring, `"owner":"a"`)
+			})
+
+			Convey("Then another owner can not acquire it", func() {
+				acquired, err = l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeFalse)
+			})
+
+			Convey("Then the same owner can renew it", func() {
+				acquired, err = l.Acquire(ctx, "a")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeTrue)
+			})
+
+			Convey("Then another owner can acquire it once it is released", func() {
+				So(l.Release(ctx, "a"), ShouldBeNil)
+				acquired, err = l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeTrue)
+			})
+
+			Convey("Then another owner can acquire it once it has expired", func() {
+				time.Sleep(60 * time.Millisecond)
+				acquired, err = l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeTrue)
+			})
+
+			Convey("Then it is not released by another owner", func() {
+				So(l.Release(ctx, "b"), ShouldBeNil)
+				acquired, err = l.Acquire(ctx, "b")
+				So(err, ShouldBeNil)
+				So(acquired, ShouldBeFalse)
+			})
+		})
+
+		Convey("When many owners acquire it at the same time", func() {
+			var (
+				wg      sync.WaitGroup
+				mx      sync.Mutex
+				holders []string
+			)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(owner string) {
+					defer wg.Done()
+					acquired, err := l.Acquire(ctx, owner)
+					if err == nil && acquired {
+						mx.Lock()
+						holders = append(holders, owner)
+						mx.Unlock()
+					}
+				}(strconv.Itoa(i))
+			}
+			wg.Wait()
+
+			Convey("Then exactly one of them holds it", func() {
+				So(holders, ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a lock file that can not be read", t, func() {
+		store := &mock.VersionedStoreMock{
+			GetFileWithVersionFunc: func(name string) (io.ReadCloser, string, error) {
+				return nil, "", errors.New("s3 error")
+			},
+		}
+		l := lock.NewStoreLocker(store, "full-sitemap.lock", time.Minute)
+
+		Convey("When an owner acquires it", func() {
+			acquired, err := l.Acquire(ctx, "a")
+
+			Convey("Then an error is returned", func() {
+				So(acquired, ShouldBeFalse)
+				So(err.Error(), ShouldContainSubstring, "failed to read lock file")
+				So(err.Error(), ShouldContainSubstring, "s3 error")
+			})
+		})
+	})
+}
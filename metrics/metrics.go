@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "dp_sitemap"
+
+// Outcome label values
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+var (
+	// FullSitemapDuration is the time taken to generate the full sitemaps, by outcome
+	FullSitemapDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "full_sitemap_generation_duration_seconds",
+		Help:      "Time taken to generate the full sitemaps.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14),
+	}, []string{"outcome"})
+
+	// FullSitemapURLs is the number of URLs in the last generated full sitemap of each language
+	FullSitemapURLs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "full_sitemap_urls",
+		Help:      "Number of URLs in the last generated full sitemap.",
+	}, []string{"lang"})
+
+	// FullSitemapURLsEmitted is the total number of URLs written to full sitemaps of each language
+	FullSitemapURLsEmitted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "full_sitemap_urls_emitted_total",
+		Help:      "Total number of URLs written to generated full sitemaps.",
+	}, []string{"lang"})
+
+	// RobotsBlockedURLs counts the sitemap urls disallowed by the robots rules, by language and sitemap
+	RobotsBlockedURLs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "robots_blocked_urls_total",
+		Help:      "Number of sitemap URLs disallowed by the robots rules.",
+	}, []string{"lang", "sitemap"})
+
+	// FullSitemapPublicationsRefused counts the full sitemaps not published because they lost too many URLs
+	FullSitemapPublicationsRefused = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "full_sitemap_publications_refused_total",
+		Help:      "Number of generated full sitemaps not published because their URL count dropped too much.",
+	})
+
+	// WelshContentChecks counts the lookups of welsh content, by outcome.
+	// A failure means no welsh content could be found for the page.
+	WelshContentChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "welsh_content_checks_total",
+		Help:      "Number of welsh content lookups.",
+	}, []string{"outcome"})
+
+	// ScrollPages counts the pages of search results fetched while generating the full sitemaps
+	ScrollPages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scroll_pages_total",
+		Help:      "Number of search result pages fetched.",
+	})
+
+	// EventDuration is the time taken to process a content published event, by outcome
+	EventDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_processing_duration_seconds",
+		Help:      "Time taken to process a content published event.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	// FileStoreDuration is the time taken by file store operations
+	FileStoreDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "file_store_operation_duration_seconds",
+		Help:      "Time taken by file store operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"store", "operation"})
+
+	// FileStoreErrors counts the failed file store operations
+	FileStoreErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "file_store_errors_total",
+		Help:      "Number of failed file store operations.",
+	}, []string{"store", "operation"})
+
+	// FileStoreConflicts counts the conditional saves rejected because the file was changed concurrently
+	FileStoreConflicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "file_store_version_conflicts_total",
+		Help:      "Number of conditional file saves rejected because the file was changed concurrently.",
+	}, []string{"store"})
+
+	// PublishingSitemapSize is the number of URLs currently in the publishing sitemap
+	PublishingSitemapSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "publishing_sitemap_urls",
+		Help:      "Number of URLs in the publishing sitemap.",
+	})
+
+	// PublishingSitemapMaxSize is the number of URLs in the publishing sitemap that triggers a full sitemap generation
+	PublishingSitemapMaxSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "publishing_sitemap_max_urls",
+		Help:      "Number of URLs in the publishing sitemap that triggers a full sitemap generation.",
+	})
+)
+
+// Outcome returns the outcome label value for err
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
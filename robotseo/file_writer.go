@@ -1,7 +1,11 @@
 package robotseo
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ONSdigital/dp-sitemap/config"
 	"github.com/pkg/errors"
@@ -9,30 +13,95 @@ import (
 
 //go:generate moq -out mock/robotFileWriter.go -pkg mock . RobotFileWriterInterface
 type RobotFileWriterInterface interface {
-	GetRobotsFileBody(lang string, sitemap map[config.Language]string) string
+	GetRobotsFileBody(lang config.Language, sitemapURL string) string
 }
+
+// RobotFileWriter writes robots files. When Version is set, they start with a comment giving the version of the
+// service, so that a robots file and its ETag only change along with its rules or the service.
 type RobotFileWriter struct {
+	Version string
+	dir     string
+	mu      sync.RWMutex
+	robots  map[config.Language]RobotsModel
 }
 
 var (
-	ErrNoRobotsBody     = errors.New("no robots body")
-	ErrNoRobotsFilePath = errors.New("no robots file path given")
+	ErrNoRobotsBody      = errors.New("no robots body")
+	ErrNoRobotsFilePath  = errors.New("no robots file path given")
+	ErrInvalidSitemapURL = errors.New("invalid robots sitemap url")
+	ErrEmptyRobots       = errors.New("robots config has no user agent")
+	ErrNoRobotsDir       = errors.New("no robots config directory to watch")
 )
 
-func (r *RobotFileWriter) GetRobotsFileBody(lang config.Language, sitemap map[config.Language]string) string {
+// GetRobotsFileBody returns the robots file of a language, ending with its Host directive if it has one and a Sitemap
+// directive for sitemapURL unless it is empty, outside of the user agent groups
+func (r *RobotFileWriter) GetRobotsFileBody(lang config.Language, sitemapURL string) string {
+	r.mu.RLock()
+	robots := r.robots[lang]
+	r.mu.RUnlock()
 	robot := strings.Builder{}
-	for k, v := range robotList[lang] {
-		robot.WriteString("\nUser-agent: " + k)
-		for _, allow := range v.AllowList {
-			robot.WriteString("\nAllow: " + allow)
+	comments := robots.Comments
+	if r.Version != "" {
+		comments = append([]string{"Generated by dp-sitemap " + r.Version}, comments...)
+	}
+	writeComments(&robot, comments)
+	for _, group := range robots.Groups {
+		robot.WriteString("\n")
+		writeComments(&robot, group.Comments)
+		for _, userAgent := range group.UserAgents {
+			robot.WriteString("User-agent: " + userAgent + "\n")
 		}
-		for _, deny := range v.DenyList {
-			robot.WriteString("\nDisallow: " + deny)
+		if group.CrawlDelay > 0 {
+			robot.WriteString("Crawl-delay: " + strconv.FormatFloat(group.CrawlDelay, 'f', -1, 64) + "\n")
 		}
-		if sm, ok := sitemap[lang]; ok {
-			robot.WriteString("\n\nsitemap: " + sm)
+		for _, rule := range group.Rules {
+			if rule.Allow {
+				robot.WriteString("Allow: " + rule.Path + "\n")
+			} else {
+				robot.WriteString("Disallow: " + rule.Path + "\n")
+			}
 		}
+	}
+	if robots.Host != "" || sitemapURL != "" {
 		robot.WriteString("\n")
 	}
+	if robots.Host != "" {
+		robot.WriteString("Host: " + robots.Host + "\n")
+	}
+	if sitemapURL != "" {
+		robot.WriteString("Sitemap: " + sitemapURL + "\n")
+	}
 	return robot.String()
 }
+
+// writeComments writes comment lines
+func writeComments(robot *strings.Builder, comments []string) {
+	for _, comment := range comments {
+		robot.WriteString("# " + comment + "\n")
+	}
+}
+
+// SitemapURL returns the public url of the sitemap given in the robots file of a language: the sitemap index url
+// if there is one, otherwise the sitemap url of the language, which defaults to the sitemap.xml of its host name.
+// Robots files require an absolute url.
+func SitemapURL(cfg *config.Config, lang config.Language) (string, error) {
+	sitemapURL := cfg.RobotsSitemapIndexURL
+	if sitemapURL == "" {
+		sitemapURL = cfg.RobotsSitemapURL[lang]
+	}
+	if sitemapURL == "" {
+		hostName := cfg.DpOnsURLHostNameEn
+		if lang == config.Welsh {
+			hostName = cfg.DpOnsURLHostNameCy
+		}
+		var err error
+		if sitemapURL, err = url.JoinPath(hostName, "sitemap.xml"); err != nil {
+			return "", fmt.Errorf("%w %q of %s: %w", ErrInvalidSitemapURL, hostName, lang, err)
+		}
+	}
+	parsed, err := url.Parse(sitemapURL)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" {
+		return "", fmt.Errorf("%w %q of %s: the url must be absolute", ErrInvalidSitemapURL, sitemapURL, lang)
+	}
+	return sitemapURL, nil
+}
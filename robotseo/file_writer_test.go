@@ -0,0 +1,134 @@
+package robotseo
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetRobotsFileBody(t *testing.T) {
+	Convey("Given robots rules for several user agents", t, func() {
+		fw := &RobotFileWriter{robots: map[config.Language]RobotsModel{
+			config.English: {Groups: []SeoRobotModel{
+				{UserAgents: []string{"*"}, Rules: []RobotRule{{Allow: true, Path: "/"}}},
+				{UserAgents: []string{"Googlebot"}, Rules: []RobotRule{{Path: "/private"}, {Allow: true, Path: "/private/public"}}},
+			}},
+		}}
+
+		Convey("When the robots file is written with a sitemap url", func() {
+			body := fw.GetRobotsFileBody(config.English, "https://www.ons.gov.uk/sitemap.xml")
+
+			Convey("Then the groups and rules are in order with a single sitemap directive after them", func() {
+				So(body, ShouldEqual, `
+User-agent: *
+Allow: /
+
+User-agent: Googlebot
+Disallow: /private
+Allow: /private/public
+
+Sitemap: https://www.ons.gov.uk/sitemap.xml
+`)
+			})
+			Convey("Then the same robots file is written again", func() {
+				So(fw.GetRobotsFileBody(config.English, "https://www.ons.gov.uk/sitemap.xml"), ShouldEqual, body)
+			})
+		})
+
+		Convey("When the robots file has comments, a host and groups of several user agents with a crawl delay", func() {
+			fw.robots[config.English] = RobotsModel{
+				Comments: []string{"Robots file of www.ons.gov.uk"},
+				Host:     "www.ons.gov.uk",
+				Groups: []SeoRobotModel{
+					{
+						UserAgents: []string{"Googlebot", "Bingbot"},
+						Comments:   []string{"Search engines"},
+						CrawlDelay: 0.5,
+						Rules:      []RobotRule{{Allow: true, Path: "/*.json$"}, {Path: "/search"}},
+					},
+				},
+			}
+			fw.Version = "1.2.3"
+			body := fw.GetRobotsFileBody(config.English, "https://www.ons.gov.uk/sitemap.xml")
+
+			Convey("Then they are all written", func() {
+				So(body, ShouldEqual, `# Generated by dp-sitemap 1.2.3
+# Robots file of www.ons.gov.uk
+
+# Search engines
+User-agent: Googlebot
+User-agent: Bingbot
+Crawl-delay: 0.5
+Allow: /*.json$
+Disallow: /search
+
+Host: www.ons.gov.uk
+Sitemap: https://www.ons.gov.uk/sitemap.xml
+`)
+			})
+		})
+
+		Convey("When the robots file is written without a sitemap url", func() {
+			body := fw.GetRobotsFileBody(config.English, "")
+
+			Convey("Then it has no sitemap directive", func() {
+				So(body, ShouldNotContainSubstring, "Sitemap:")
+			})
+		})
+	})
+}
+
+func TestSitemapURL(t *testing.T) {
+	Convey("Given the host names of each language", t, func() {
+		cfg := &config.Config{
+			DpOnsURLHostNameEn: "https://www.ons.gov.uk/",
+			DpOnsURLHostNameCy: "https://cy.ons.gov.uk",
+		}
+
+		Convey("When no sitemap url is configured", func() {
+			Convey("Then the sitemap of each language host name is used", func() {
+				enURL, err := SitemapURL(cfg, config.English)
+				So(err, ShouldBeNil)
+				So(enURL, ShouldEqual, "https://www.ons.gov.uk/sitemap.xml")
+				cyURL, err := SitemapURL(cfg, config.Welsh)
+				So(err, ShouldBeNil)
+				So(cyURL, ShouldEqual, "https://cy.ons.gov.uk/sitemap.xml")
+			})
+		})
+
+		Convey("When a sitemap url is configured for a language", func() {
+			cfg.RobotsSitemapURL = map[config.Language]string{config.Welsh: "https://cy.ons.gov.uk/sitemap_cy.xml"}
+
+			Convey("Then it is used for that language only", func() {
+				cyURL, err := SitemapURL(cfg, config.Welsh)
+				So(err, ShouldBeNil)
+				So(cyURL, ShouldEqual, "https://cy.ons.gov.uk/sitemap_cy.xml")
+				enURL, err := SitemapURL(cfg, config.English)
+				So(err, ShouldBeNil)
+				So(enURL, ShouldEqual, "https://www.ons.gov.uk/sitemap.xml")
+			})
+		})
+
+		Convey("When a sitemap index url is configured", func() {
+			cfg.RobotsSitemapURL = map[config.Language]string{config.Welsh: "https://cy.ons.gov.uk/sitemap_cy.xml"}
+			cfg.RobotsSitemapIndexURL = "https://www.ons.gov.uk/sitemap_index.xml"
+
+			Convey("Then it is used for every language", func() {
+				cyURL, err := SitemapURL(cfg, config.Welsh)
+				So(err, ShouldBeNil)
+				So(cyURL, ShouldEqual, "https://www.ons.gov.uk/sitemap_index.xml")
+			})
+		})
+
+		Convey("When a relative sitemap url is configured", func() {
+			cfg.RobotsSitemapURL = map[config.Language]string{config.English: "/tmp/dp-sitemap-en.xml"}
+
+			Convey("Then an error is returned", func() {
+				_, err := SitemapURL(cfg, config.English)
+				So(err, ShouldWrap, ErrInvalidSitemapURL)
+				So(err.Error(), ShouldContainSubstring, `"/tmp/dp-sitemap-en.xml" of en: the url must be absolute`)
+			})
+		})
+	})
+}
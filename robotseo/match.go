@@ -0,0 +1,136 @@
+package robotseo
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+)
+
+// Match is the result of testing a path against the robots rules of a user agent
+type Match struct {
+	Allowed   bool
+	UserAgent string     // user agent of the group applied, empty if no group applies
+	Rule      *RobotRule // rule applied, nil if no rule matches the path
+}
+
+// String describes whether the path is allowed and by which rule
+func (m Match) String() string {
+	result := "blocked"
+	if m.Allowed {
+		result = "allowed"
+	}
+	if m.Rule == nil {
+		return result
+	}
+	directive := "Disallow"
+	if m.Rule.Allow {
+		directive = "Allow"
+	}
+	return result + " by " + directive + ": " + m.Rule.Path
+}
+
+// Test returns whether the robots rules allow userAgent to crawl path, the way Google does: the group of the user agent,
+// or of all user agents if it has none, applies and the most specific rule matching the path, the one with the longest
+// pattern, wins, an allow rule winning a tie. A path matching no rule is allowed.
+func (m *RobotsModel) Test(userAgent, path string) Match {
+	group := m.group(userAgent)
+	if group == nil {
+		return Match{Allowed: true}
+	}
+	match := Match{Allowed: true, UserAgent: userAgent}
+	if !containsFold(group.UserAgents, userAgent) {
+		match.UserAgent = "*"
+	}
+	for i := range group.Rules {
+		rule := &group.Rules[i]
+		if rule.Path == "" || !matchPattern(rule.Path, path) {
+			continue
+		}
+		if match.Rule == nil || len(rule.Path) > len(match.Rule.Path) || (len(rule.Path) == len(match.Rule.Path) && rule.Allow) {
+			match.Rule = rule
+			match.Allowed = rule.Allow
+		}
+	}
+	return match
+}
+
+// group returns the group of a user agent, or of all user agents if it has none
+func (m *RobotsModel) group(userAgent string) *SeoRobotModel {
+	var all *SeoRobotModel
+	for i := range m.Groups {
+		if containsFold(m.Groups[i].UserAgents, userAgent) {
+			return &m.Groups[i]
+		}
+		if all == nil && containsFold(m.Groups[i].UserAgents, "*") {
+			all = &m.Groups[i]
+		}
+	}
+	return all
+}
+
+// containsFold returns whether userAgents contains userAgent, ignoring case
+func containsFold(userAgents []string, userAgent string) bool {
+	for _, ua := range userAgents {
+		if strings.EqualFold(ua, userAgent) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern returns whether a path starts with a rule pattern, where * matches any sequence of characters and a
+// trailing $ matches the end of the path
+func matchPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
+
+// Test returns whether the robots rules of a language allow userAgent to crawl path
+func (r *RobotFileWriter) Test(lang config.Language, userAgent, path string) Match {
+	r.mu.RLock()
+	robots := r.robots[lang]
+	r.mu.RUnlock()
+	return robots.Test(userAgent, path)
+}
+
+// Allowed returns whether the robots rules of a language allow userAgent to crawl path
+func (r *RobotFileWriter) Allowed(lang config.Language, userAgent, path string) bool {
+	return r.Test(lang, userAgent, path).Allowed
+}
+
+// URLPath returns the path and query of a url, the part of it that robots rules are matched against
+func URLPath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path, nil
+}
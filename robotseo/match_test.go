@@ -0,0 +1,92 @@
+package robotseo
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRobotsTest(t *testing.T) {
+	Convey("Given robots rules for all user agents and for Googlebot", t, func() {
+		robots := RobotsModel{Groups: []SeoRobotModel{
+			{UserAgents: []string{"*"}, Rules: []RobotRule{
+				{Allow: true, Path: "/p"},
+				{Path: "/"},
+				{Allow: true, Path: "/$"},
+				{Allow: true, Path: "/page"},
+				{Path: "/*.ph"},
+				{Path: "/*.pdf$"},
+			}},
+			{UserAgents: []string{"Googlebot"}, Rules: []RobotRule{{Path: "/search"}, {Allow: true, Path: "/search/about"}, {Path: ""}}},
+		}}
+
+		Convey("When paths are tested, the most specific rule wins", func() {
+			cases := []struct {
+				userAgent string
+				path      string
+				allowed   bool
+				rule      string
+			}{
+				{"*", "/page", true, "/page"},
+				{"*", "/", true, "/$"},
+				{"*", "/page.htm", true, "/page"},
+				{"*", "/other", false, "/"},
+				{"*", "/page.php", true, "/page"},
+				{"*", "/files/report.pdf", false, "/*.pdf$"},
+				{"*", "/files/report.pdf?download=1", false, "/"},
+				{"Bingbot", "/other", false, "/"},
+				{"googlebot", "/search?q=cpi", false, "/search"},
+				{"Googlebot", "/search/about", true, "/search/about"},
+				{"Googlebot", "/other", true, ""},
+			}
+			for _, c := range cases {
+				match := robots.Test(c.userAgent, c.path)
+
+				So(match.Allowed, ShouldEqual, c.allowed)
+				if c.rule == "" {
+					So(match.Rule, ShouldBeNil)
+				} else {
+					So(match.Rule.Path, ShouldEqual, c.rule)
+				}
+			}
+		})
+
+		Convey("When a user agent without a group is tested", func() {
+			match := robots.Test("Bingbot", "/other")
+
+			Convey("Then the group of all user agents applies", func() {
+				So(match.UserAgent, ShouldEqual, "*")
+				So(match.String(), ShouldEqual, "blocked by Disallow: /")
+			})
+		})
+	})
+
+	Convey("Given robots rules without a group of all user agents", t, func() {
+		robots := RobotsModel{Groups: []SeoRobotModel{{UserAgents: []string{"Googlebot"}, Rules: []RobotRule{{Path: "/"}}}}}
+
+		Convey("When another user agent is tested", func() {
+			match := robots.Test("Bingbot", "/page")
+
+			Convey("Then it is allowed", func() {
+				So(match, ShouldResemble, Match{Allowed: true})
+				So(match.String(), ShouldEqual, "allowed")
+			})
+		})
+	})
+}
+
+func TestURLPath(t *testing.T) {
+	Convey("Given urls", t, func() {
+		Convey("Then their path and query are matched against the robots rules", func() {
+			path, err := URLPath("https://www.ons.gov.uk/economy/inflation?page=2")
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, "/economy/inflation?page=2")
+			path, err = URLPath("https://www.ons.gov.uk")
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, "/")
+			path, err = URLPath("/search")
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, "/search")
+		})
+	})
+}
@@ -15,64 +15,65 @@ var _ robotseo.RobotFileWriterInterface = &RobotFileWriterInterfaceMock{}
 
 // RobotFileWriterInterfaceMock is a mock implementation of robotseo.RobotFileWriterInterface.
 //
-// 	func TestSomethingThatUsesRobotFileWriterInterface(t *testing.T) {
+//	func TestSomethingThatUsesRobotFileWriterInterface(t *testing.T) {
 //
-// 		// make and configure a mocked robotseo.RobotFileWriterInterface
-// 		mockedRobotFileWriterInterface := &RobotFileWriterInterfaceMock{
-// 			GetRobotsFileBodyFunc: func(lang string, sitemap map[config.Language]string) string {
-// 				panic("mock out the GetRobotsFileBody method")
-// 			},
-// 		}
+//		// make and configure a mocked robotseo.RobotFileWriterInterface
+//		mockedRobotFileWriterInterface := &RobotFileWriterInterfaceMock{
+//			GetRobotsFileBodyFunc: func(lang config.Language, sitemapURL string) string {
+//				panic("mock out the GetRobotsFileBody method")
+//			},
+//		}
 //
-// 		// use mockedRobotFileWriterInterface in code that requires robotseo.RobotFileWriterInterface
-// 		// and then make assertions.
+//		// use mockedRobotFileWriterInterface in code that requires robotseo.RobotFileWriterInterface
+//		// and then make assertions.
 //
-// 	}
+//	}
 type RobotFileWriterInterfaceMock struct {
 	// GetRobotsFileBodyFunc mocks the GetRobotsFileBody method.
-	GetRobotsFileBodyFunc func(lang string, sitemap map[config.Language]string) string
+	GetRobotsFileBodyFunc func(lang config.Language, sitemapURL string) string
 
 	// calls tracks calls to the methods.
 	calls struct {
 		// GetRobotsFileBody holds details about calls to the GetRobotsFileBody method.
 		GetRobotsFileBody []struct {
 			// Lang is the lang argument value.
-			Lang string
-			// Sitemap is the sitemap argument value.
-			Sitemap map[config.Language]string
+			Lang config.Language
+			// SitemapURL is the sitemapURL argument value.
+			SitemapURL string
 		}
 	}
 	lockGetRobotsFileBody sync.RWMutex
 }
 
 // GetRobotsFileBody calls GetRobotsFileBodyFunc.
-func (mock *RobotFileWriterInterfaceMock) GetRobotsFileBody(lang string, sitemap map[config.Language]string) string {
+func (mock *RobotFileWriterInterfaceMock) GetRobotsFileBody(lang config.Language, sitemapURL string) string {
 	if mock.GetRobotsFileBodyFunc == nil {
 		panic("RobotFileWriterInterfaceMock.GetRobotsFileBodyFunc: method is nil but RobotFileWriterInterface.GetRobotsFileBody was just called")
 	}
 	callInfo := struct {
-		Lang    string
-		Sitemap map[config.Language]string
+		Lang       config.Language
+		SitemapURL string
 	}{
-		Lang:    lang,
-		Sitemap: sitemap,
+		Lang:       lang,
+		SitemapURL: sitemapURL,
 	}
 	mock.lockGetRobotsFileBody.Lock()
 	mock.calls.GetRobotsFileBody = append(mock.calls.GetRobotsFileBody, callInfo)
 	mock.lockGetRobotsFileBody.Unlock()
-	return mock.GetRobotsFileBodyFunc(lang, sitemap)
+	return mock.GetRobotsFileBodyFunc(lang, sitemapURL)
 }
 
 // GetRobotsFileBodyCalls gets all the calls that were made to GetRobotsFileBody.
 // Check the length with:
-//     len(mockedRobotFileWriterInterface.GetRobotsFileBodyCalls())
+//
+//	len(mockedRobotFileWriterInterface.GetRobotsFileBodyCalls())
 func (mock *RobotFileWriterInterfaceMock) GetRobotsFileBodyCalls() []struct {
-	Lang    string
-	Sitemap map[config.Language]string
+	Lang       config.Language
+	SitemapURL string
 } {
 	var calls []struct {
-		Lang    string
-		Sitemap map[config.Language]string
+		Lang       config.Language
+		SitemapURL string
 	}
 	mock.lockGetRobotsFileBody.RLock()
 	calls = mock.calls.GetRobotsFileBody
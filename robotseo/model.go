@@ -1,6 +1,23 @@
 package robotseo
 
+// RobotsModel is the robots config of a language: comments written at the top of its robots file, an optional
+// preferred host and the user agent groups, in the order of the robots config file
+type RobotsModel struct {
+	Comments []string
+	Host     string
+	Groups   []SeoRobotModel
+}
+
+// SeoRobotModel is a group of robots rules applying to one or more user agents
 type SeoRobotModel struct {
-	AllowList []string `json:"AllowList"`
-	DenyList  []string `json:"DenyList"`
+	UserAgents []string
+	Comments   []string
+	CrawlDelay float64
+	Rules      []RobotRule
+}
+
+// RobotRule allows or disallows crawling the paths matching a pattern, which may hold * wildcards and end with $
+type RobotRule struct {
+	Allow bool
+	Path  string
 }
@@ -0,0 +1,82 @@
+package robotseo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// FileSaver saves the robots files
+type FileSaver interface {
+	SaveFile(name string, body io.Reader) error
+}
+
+// Publisher writes the robots file of each language to its own file of the robots store, independently of the
+// sitemap generations, whenever it is asked to or the robots rules change
+type Publisher struct {
+	writer      *RobotFileWriter
+	store       FileSaver
+	files       map[config.Language]string
+	sitemapURLs map[config.Language]string
+	mu          sync.Mutex
+}
+
+// NewPublisher returns a publisher of the robots files written by writer to the files of each language in store, with
+// the sitemap urls of the config
+func NewPublisher(cfg *config.Config, writer *RobotFileWriter, store FileSaver, files map[config.Language]string) (*Publisher, error) {
+	p := &Publisher{
+		writer:      writer,
+		store:       store,
+		files:       files,
+		sitemapURLs: map[config.Language]string{},
+	}
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		if files[lang] == "" {
+			return nil, fmt.Errorf("%w for %s", ErrNoRobotsFilePath, lang)
+		}
+		sitemapURL, err := SitemapURL(cfg, lang)
+		if err != nil {
+			return nil, err
+		}
+		p.sitemapURLs[lang] = sitemapURL
+	}
+	return p, nil
+}
+
+// Publish writes the robots file of each language with the current rules
+func (p *Publisher) Publish() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		body := p.writer.GetRobotsFileBody(lang, p.sitemapURLs[lang])
+		if err := p.store.SaveFile(p.files[lang], strings.NewReader(body)); err != nil {
+			return fmt.Errorf("failed to save robots file %s: %w", p.files[lang], err)
+		}
+	}
+	return nil
+}
+
+// Reload reloads the robots rules and writes the robots files with them. Invalid rules are rejected, the previous
+// rules and robots files being kept.
+func (p *Publisher) Reload() error {
+	if err := p.writer.Reload(); err != nil {
+		return err
+	}
+	return p.Publish()
+}
+
+// Watch writes the robots files again whenever the robots config files change, until ctx is done
+func (p *Publisher) Watch(ctx context.Context) error {
+	return p.writer.Watch(ctx, func() {
+		if err := p.Publish(); err != nil {
+			log.Error(ctx, "failed to write robots files after a robots config change", err)
+			return
+		}
+		log.Info(ctx, "wrote robots files after a robots config change")
+	})
+}
@@ -0,0 +1,93 @@
+package robotseo
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryStore saves files in memory, failing with err if it is set
+type memoryStore struct {
+	files map[string]string
+	err   error
+}
+
+func (s *memoryStore) SaveFile(name string, body io.Reader) error {
+	if s.err != nil {
+		return s.err
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	s.files[name] = string(b)
+	return nil
+}
+
+func TestPublisher(t *testing.T) {
+	cfg := &config.Config{
+		DpOnsURLHostNameEn: "https://www.ons.gov.uk",
+		DpOnsURLHostNameCy: "https://cy.ons.gov.uk",
+	}
+	files := map[config.Language]string{config.English: "robots-en.txt", config.Welsh: "robots-cy.txt"}
+
+	Convey("Given a robots publisher to its own store", t, func() {
+		fw := &RobotFileWriter{robots: map[config.Language]RobotsModel{
+			config.English: {Groups: []SeoRobotModel{{UserAgents: []string{"*"}, Rules: []RobotRule{{Allow: true, Path: "/"}}}}},
+			config.Welsh:   {Groups: []SeoRobotModel{{UserAgents: []string{"*"}, Rules: []RobotRule{{Path: "/"}}}}},
+		}}
+		store := &memoryStore{files: map[string]string{}}
+		p, err := NewPublisher(cfg, fw, store, files)
+		So(err, ShouldBeNil)
+
+		Convey("When the robots files are published", func() {
+			err = p.Publish()
+
+			Convey("Then the robots file of each language is written to its own file with its sitemap", func() {
+				So(err, ShouldBeNil)
+				So(store.files, ShouldResemble, map[string]string{
+					"robots-en.txt": "\nUser-agent: *\nAllow: /\n\nSitemap: https://www.ons.gov.uk/sitemap.xml\n",
+					"robots-cy.txt": "\nUser-agent: *\nDisallow: /\n\nSitemap: https://cy.ons.gov.uk/sitemap.xml\n",
+				})
+			})
+		})
+
+		Convey("When the store fails", func() {
+			store.err = errors.New("store error")
+			err = p.Publish()
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldEqual, "failed to save robots file robots-en.txt: store error")
+			})
+		})
+	})
+
+	Convey("Given a robots file missing for a language", t, func() {
+		Convey("When a robots publisher is created", func() {
+			_, err := NewPublisher(cfg, &RobotFileWriter{}, &memoryStore{}, map[config.Language]string{config.English: "robots-en.txt"})
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldWrap, ErrNoRobotsFilePath)
+			})
+		})
+	})
+
+	Convey("Given a robots publisher of a directory of invalid robots config files", t, func() {
+		fw := &RobotFileWriter{dir: t.TempDir(), robots: map[config.Language]RobotsModel{}}
+		store := &memoryStore{files: map[string]string{}}
+		p, err := NewPublisher(cfg, fw, store, files)
+		So(err, ShouldBeNil)
+
+		Convey("When the robots config is reloaded", func() {
+			err = p.Reload()
+
+			Convey("Then it is rejected and no robots file is written", func() {
+				So(err.Error(), ShouldContainSubstring, "failed to read robot_en.json")
+				So(store.files, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -1,75 +1,370 @@
 package robotseo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/ONSdigital/dp-sitemap/config"
 	"github.com/ONSdigital/dp-sitemap/features"
 	"github.com/ONSdigital/log.go/v2/log"
-	"golang.org/x/exp/slices"
+	"github.com/fsnotify/fsnotify"
 )
 
-var robotList map[config.Language]map[string]SeoRobotModel
+// NewRobotFileWriter returns a robots file writer with the rules of the robot_<lang>.json config files in dir, or of
+// the ones embedded in the service if dir is empty
+func NewRobotFileWriter(dir string) (*RobotFileWriter, error) {
+	robots, err := loadRobots(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &RobotFileWriter{
+		dir:    dir,
+		robots: robots,
+	}, nil
+}
 
-func Init(pathToRobotFile string) {
-	robotList = map[config.Language]map[string]SeoRobotModel{}
-	ctx := context.Background()
-	var b []byte
-	var err error
-	var fileName string
+// Reload reads the robots config files again. Invalid configs are rejected and the previous rules kept.
+func (r *RobotFileWriter) Reload() error {
+	robots, err := loadRobots(r.dir)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.robots = robots
+	return nil
+}
 
-	if !strings.HasSuffix(pathToRobotFile, "/") && pathToRobotFile != "" {
-		pathToRobotFile += "/"
+// Watch reloads the robots config files of the directory of the writer whenever they change, until ctx is done,
+// calling onReload after each successful reload. Invalid changes are logged and the previous rules kept.
+func (r *RobotFileWriter) Watch(ctx context.Context, onReload func()) error {
+	if r.dir == "" {
+		return ErrNoRobotsDir
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create robots config watcher: %w", err)
+	}
+	if err = watcher.Add(r.dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch robots config directory: %w", err)
 	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod || !isRobotsFile(event.Name) {
+					continue
+				}
+				if reloadErr := r.Reload(); reloadErr != nil {
+					log.Error(ctx, "rejected robots config change, keeping the previous rules", reloadErr, log.Data{"filename": event.Name})
+					continue
+				}
+				log.Info(ctx, "reloaded robots config", log.Data{"filename": event.Name})
+				if onReload != nil {
+					onReload()
+				}
+			case watchErr, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error(ctx, "robots config watcher error", watchErr)
+			}
+		}
+	}()
+	return nil
+}
+
+// robotsFileName returns the name of the robots config file of a language
+func robotsFileName(lang config.Language) string {
+	return "robot_" + lang.String() + ".json"
+}
+
+// isRobotsFile returns whether a file is the robots config file of a language
+func isRobotsFile(name string) bool {
 	for _, lang := range []config.Language{config.English, config.Welsh} {
-		fileName = "robot_" + lang.String() + ".json"
+		if filepath.Base(name) == robotsFileName(lang) {
+			return true
+		}
+	}
+	return false
+}
 
-		// if pathToRobotFile is empty (the default) we get the robot file from the embedded filesystem
-		// otherwise we get the robot file from the local file store and the path specified by pathToRobotFile
-		if pathToRobotFile == "" {
+// loadRobots reads the robots config file of each language in dir, or the embedded ones if dir is empty, reporting
+// the problems of every file
+func loadRobots(dir string) (map[config.Language]RobotsModel, error) {
+	robotList := map[config.Language]RobotsModel{}
+	var errs []error
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		fileName := robotsFileName(lang)
+
+		var (
+			b   []byte
+			err error
+		)
+		if dir == "" {
 			b, err = features.GetRobotFile(fileName)
 		} else {
-			b, err = os.ReadFile(pathToRobotFile + fileName)
+			b, err = os.ReadFile(filepath.Join(dir, fileName))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", fileName, err))
+			continue
 		}
 
+		robots, err := parseRobots(b)
 		if err != nil {
-			log.Error(ctx, "file not found", err, log.Data{"filename": fileName})
-			panic("Can't find " + fileName)
+			errs = append(errs, fmt.Errorf("invalid %s: %w", fileName, err))
+			continue
 		}
+		if len(robots.Groups) == 0 {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrEmptyRobots, fileName))
+			continue
+		}
+		robotList[lang] = robots
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return robotList, nil
+}
+
+// parseRobots decodes a robots config file and validates it. The file is either a JSON object of the Comments, Host
+// and Groups of the robots file, or the legacy JSON object of the group of each user agent. Groups keep the order of the
+// file, the group of all user agents coming first.
+func parseRobots(b []byte) (RobotsModel, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return RobotsModel{}, err
+	}
 
-		rContent := map[string]SeoRobotModel{}
-		err = json.Unmarshal(b, &rContent)
+	var robots RobotsModel
+	if _, ok := fields["Groups"]; ok {
+		var doc struct {
+			Comments []string
+			Host     string
+			Groups   []json.RawMessage
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&doc); err != nil {
+			return RobotsModel{}, err
+		}
+		robots.Comments, robots.Host = doc.Comments, doc.Host
+		for i, raw := range doc.Groups {
+			group, err := parseRobotGroup(json.NewDecoder(bytes.NewReader(raw)))
+			if err != nil {
+				return RobotsModel{}, fmt.Errorf("invalid group %d: %w", i+1, err)
+			}
+			robots.Groups = append(robots.Groups, group)
+		}
+	} else {
+		var err error
+		if robots.Groups, err = parseLegacyRobotGroups(b); err != nil {
+			return RobotsModel{}, err
+		}
+	}
+
+	if err := validateRobots(&robots); err != nil {
+		return RobotsModel{}, err
+	}
+	slices.SortStableFunc(robots.Groups, func(a, b SeoRobotModel) int {
+		aAll, bAll := slices.Contains(a.UserAgents, "*"), slices.Contains(b.UserAgents, "*")
+		switch {
+		case aAll && !bAll:
+			return -1
+		case !aAll && bAll:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return robots, nil
+}
+
+// parseLegacyRobotGroups decodes the groups of a JSON object of the group of each user agent
+func parseLegacyRobotGroups(b []byte) ([]SeoRobotModel, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	var groups []SeoRobotModel
+	for dec.More() {
+		token, err := dec.Token()
 		if err != nil {
-			log.Error(ctx, "error reading file", err, log.Data{"filename": fileName})
-			panic("Unable to read JSON")
+			return nil, err
 		}
-		robotList[lang] = rContent
+		userAgent, _ := token.(string)
+		group, err := parseRobotGroup(dec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rules of user agent [%s]: %w", userAgent, err)
+		}
+		group.UserAgents = append([]string{userAgent}, group.UserAgents...)
+		groups = append(groups, group)
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
 	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected content after the user agents")
+	}
+	return groups, nil
+}
 
-	// Validation
-	// 1. Check there is at least 1 entry
-	// 2. Check that same allow/deny dont exist for a user-agent
-	for _, lang := range []config.Language{config.English, config.Welsh} {
-		fileName := "robot_" + lang.String() + ".json"
-		rList := robotList[lang]
-		if len(rList) == 0 {
-			log.Error(ctx, "no entry in file", errors.New(fileName+" cant be empty"), log.Data{"filename": fileName})
-			panic(fileName + " cant be empty")
-		}
-		for ua, list := range rList {
-			if len(list.AllowList) == 0 || len(list.DenyList) == 0 {
-				continue
+// parseRobotGroup decodes the user agents, comments, crawl delay and rules of a group, its allow and disallow rules
+// being in the order of its AllowList and DenyList
+func parseRobotGroup(dec *json.Decoder) (SeoRobotModel, error) {
+	var group SeoRobotModel
+	if err := expectDelim(dec, '{'); err != nil {
+		return group, err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return group, err
+		}
+		var value any
+		switch token {
+		case "UserAgents":
+			value = &group.UserAgents
+		case "Comments":
+			value = &group.Comments
+		case "CrawlDelay":
+			value = &group.CrawlDelay
+		case "AllowList", "DenyList":
+			var paths []string
+			if err = dec.Decode(&paths); err != nil {
+				return group, fmt.Errorf("invalid %v: %w", token, err)
 			}
-			for _, allow := range list.AllowList {
-				if slices.Contains(list.DenyList, allow) {
-					panic(fmt.Sprintf("user agent [%s], contains [%s] in both allow and deny", ua, allow))
-				}
+			for _, path := range paths {
+				group.Rules = append(group.Rules, RobotRule{Allow: token == "AllowList", Path: path})
+			}
+			continue
+		default:
+			return group, fmt.Errorf("unknown field %v", token)
+		}
+		if err = dec.Decode(value); err != nil {
+			return group, fmt.Errorf("invalid %v: %w", token, err)
+		}
+	}
+	return group, expectDelim(dec, '}')
+}
+
+// validateRobots checks the robots config, reporting every problem found: user agents must be listed once, ignoring
+// case as crawlers do, directive values must be on a single line, crawl delays must not be negative, the host must be a
+// host name and rule patterns must start with / or *, only end with $, and not be both allowed and disallowed in a group
+func validateRobots(robots *RobotsModel) error {
+	var errs []error
+	errs = append(errs, validateComments(robots.Comments)...)
+	if robots.Host != "" {
+		if err := validateHost(robots.Host); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	userAgents := map[string]bool{}
+	for _, group := range robots.Groups {
+		name := strings.Join(group.UserAgents, ", ")
+		if len(group.UserAgents) == 0 {
+			errs = append(errs, errors.New("group without user agent"))
+		}
+		for _, userAgent := range group.UserAgents {
+			switch {
+			case userAgent == "" || strings.ContainsAny(userAgent, "\r\n#"):
+				errs = append(errs, fmt.Errorf("invalid user agent [%s]", userAgent))
+			case userAgents[strings.ToLower(userAgent)]:
+				errs = append(errs, fmt.Errorf("user agent [%s] is listed twice", userAgent))
+			}
+			userAgents[strings.ToLower(userAgent)] = true
+		}
+		errs = append(errs, validateComments(group.Comments)...)
+		if group.CrawlDelay < 0 {
+			errs = append(errs, fmt.Errorf("user agent [%s], has a negative crawl delay", name))
+		}
+
+		allowed := map[string]bool{}
+		for _, rule := range group.Rules {
+			if err := validatePattern(rule); err != nil {
+				errs = append(errs, fmt.Errorf("user agent [%s], %w", name, err))
+			}
+			if rule.Allow {
+				allowed[rule.Path] = true
+			}
+		}
+		for _, rule := range group.Rules {
+			if !rule.Allow && allowed[rule.Path] {
+				errs = append(errs, fmt.Errorf("user agent [%s], contains [%s] in both allow and deny", name, rule.Path))
 			}
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// validatePattern checks the path pattern of a rule. An empty disallow rule allows everything.
+func validatePattern(rule RobotRule) error {
+	switch {
+	case rule.Path == "" && !rule.Allow:
+		return nil
+	case rule.Path == "":
+		return errors.New("has an empty allow rule")
+	case rule.Path[0] != '/' && rule.Path[0] != '*':
+		return fmt.Errorf("rule [%s] must start with / or *", rule.Path)
+	case strings.ContainsAny(rule.Path, " \t\r\n#"):
+		return fmt.Errorf("rule [%s] must not contain whitespace or #", rule.Path)
+	case strings.Contains(strings.TrimSuffix(rule.Path, "$"), "$"):
+		return fmt.Errorf("rule [%s] can only have $ at its end", rule.Path)
+	}
+	return nil
+}
+
+// validateHost checks that the preferred host is a host name, optionally with a scheme and port
+func validateHost(host string) error {
+	hostURL := host
+	if !strings.Contains(host, "://") {
+		hostURL = "//" + host
+	}
+	parsed, err := url.Parse(hostURL)
+	if err != nil || parsed.Host == "" || parsed.Path != "" || parsed.RawQuery != "" || parsed.User != nil {
+		return fmt.Errorf("invalid host [%s]", host)
+	}
+	return nil
+}
+
+// validateComments checks that comments are on a single line
+func validateComments(comments []string) []error {
+	var errs []error
+	for _, comment := range comments {
+		if strings.ContainsAny(comment, "\r\n") {
+			errs = append(errs, fmt.Errorf("comment [%s] must be on a single line", comment))
+		}
+	}
+	return errs
+}
+
+// expectDelim reads the next token of dec, which must be delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
 }
@@ -0,0 +1,227 @@
+package robotseo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseRobots(t *testing.T) {
+	Convey("Given a robots config with several user agents", t, func() {
+		content := `{
+  "Googlebot": {"DenyList": ["/private"], "AllowList": ["/private/public"]},
+  "*": {"AllowList": ["/"], "DenyList": []},
+  "Bingbot": {"AllowList": ["/"]}
+}`
+
+		Convey("When it is parsed repeatedly", func() {
+			for i := 0; i < 10; i++ {
+				robots, err := parseRobots([]byte(content))
+
+				So(err, ShouldBeNil)
+				So(robots.Groups, ShouldResemble, []SeoRobotModel{
+					{UserAgents: []string{"*"}, Rules: []RobotRule{{Allow: true, Path: "/"}}},
+					{UserAgents: []string{"Googlebot"}, Rules: []RobotRule{{Path: "/private"}, {Allow: true, Path: "/private/public"}}},
+					{UserAgents: []string{"Bingbot"}, Rules: []RobotRule{{Allow: true, Path: "/"}}},
+				})
+			}
+		})
+	})
+
+	Convey("Given a robots config listing a user agent twice", t, func() {
+		content := `{"*": {"AllowList": ["/"]}, "*": {"DenyList": ["/"]}}`
+
+		Convey("When it is parsed", func() {
+			_, err := parseRobots([]byte(content))
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldEqual, "user agent [*] is listed twice")
+			})
+		})
+	})
+
+	Convey("Given a robots config listing a user agent twice in different cases", t, func() {
+		content := `{"Googlebot": {"AllowList": ["/"]}, "googlebot": {"DenyList": ["/"]}}`
+
+		Convey("When it is parsed", func() {
+			_, err := parseRobots([]byte(content))
+
+			Convey("Then an error is returned, as user agents are matched ignoring case", func() {
+				So(err.Error(), ShouldEqual, "user agent [googlebot] is listed twice")
+			})
+		})
+	})
+
+	Convey("Given a robots config with an unknown field", t, func() {
+		content := `{"*": {"AllowList": ["/"], "Allow": ["/"]}}`
+
+		Convey("When it is parsed", func() {
+			_, err := parseRobots([]byte(content))
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldEqual, "invalid rules of user agent [*]: unknown field Allow")
+			})
+		})
+	})
+
+	Convey("Given a robots config with comments, a host and groups", t, func() {
+		content := `{
+  "Comments": ["Robots file of www.ons.gov.uk"],
+  "Host": "https://www.ons.gov.uk",
+  "Groups": [
+    {"UserAgents": ["Googlebot", "Bingbot"], "CrawlDelay": 2, "DenyList": ["/search$"], "AllowList": ["/*.json"]},
+    {"UserAgents": ["*"], "Comments": ["Everyone else"], "DenyList": [""]}
+  ]
+}`
+
+		Convey("When it is parsed", func() {
+			robots, err := parseRobots([]byte(content))
+
+			Convey("Then they are all read, in order with the group of all user agents first", func() {
+				So(err, ShouldBeNil)
+				So(robots, ShouldResemble, RobotsModel{
+					Comments: []string{"Robots file of www.ons.gov.uk"},
+					Host:     "https://www.ons.gov.uk",
+					Groups: []SeoRobotModel{
+						{UserAgents: []string{"*"}, Comments: []string{"Everyone else"}, Rules: []RobotRule{{Path: ""}}},
+						{UserAgents: []string{"Googlebot", "Bingbot"}, CrawlDelay: 2, Rules: []RobotRule{{Path: "/search$"}, {Allow: true, Path: "/*.json"}}},
+					},
+				})
+			})
+		})
+	})
+
+	Convey("Given a robots config with invalid directives", t, func() {
+		content := `{
+  "Host": "www.ons.gov.uk/path",
+  "Groups": [
+    {"UserAgents": ["Googlebot"], "CrawlDelay": -1, "AllowList": ["search", "/a$b", "/c d", "/e"], "DenyList": ["/e"]},
+    {"UserAgents": ["Googlebot"], "Comments": ["two\nlines"], "AllowList": [""]},
+    {"AllowList": ["/"]}
+  ]
+}`
+
+		Convey("When it is parsed", func() {
+			_, err := parseRobots([]byte(content))
+
+			Convey("Then every problem is reported", func() {
+				So(err.Error(), ShouldEqual, `invalid host [www.ons.gov.uk/path]
+user agent [Googlebot], has a negative crawl delay
+user agent [Googlebot], rule [search] must start with / or *
+user agent [Googlebot], rule [/a$b] can only have $ at its end
+user agent [Googlebot], rule [/c d] must not contain whitespace or #
+user agent [Googlebot], contains [/e] in both allow and deny
+user agent [Googlebot] is listed twice
+comment [two
+lines] must be on a single line
+user agent [Googlebot], has an empty allow rule
+group without user agent`)
+			})
+		})
+	})
+
+	Convey("Given a robots config that is not an object", t, func() {
+		Convey("When it is parsed", func() {
+			_, err := parseRobots([]byte(`["*"]`))
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "cannot unmarshal array")
+			})
+		})
+	})
+}
+
+func TestRobotFileWriterReload(t *testing.T) {
+	Convey("Given a directory of robots config files", t, func() {
+		dir := t.TempDir()
+		writeRobots := func(lang config.Language, content string) {
+			So(os.WriteFile(filepath.Join(dir, robotsFileName(lang)), []byte(content), 0o600), ShouldBeNil)
+		}
+		writeRobots(config.English, `{"*": {"AllowList": ["/"]}}`)
+		writeRobots(config.Welsh, `{"*": {"DenyList": ["/"]}}`)
+
+		Convey("When a robots file writer is created", func() {
+			fw, err := NewRobotFileWriter(dir)
+
+			Convey("Then it has the rules of each language", func() {
+				So(err, ShouldBeNil)
+				So(fw.GetRobotsFileBody(config.English, ""), ShouldContainSubstring, "Allow: /")
+				So(fw.GetRobotsFileBody(config.Welsh, ""), ShouldContainSubstring, "Disallow: /")
+			})
+
+			Convey("And the config files are changed and reloaded", func() {
+				writeRobots(config.English, `{"*": {"DenyList": ["/search"]}}`)
+				err = fw.Reload()
+
+				Convey("Then the new rules are used", func() {
+					So(err, ShouldBeNil)
+					So(fw.GetRobotsFileBody(config.English, ""), ShouldContainSubstring, "Disallow: /search")
+				})
+			})
+
+			Convey("And an invalid config file is reloaded", func() {
+				writeRobots(config.English, `{"*": {"AllowList": ["search"]}}`)
+				err = fw.Reload()
+
+				Convey("Then it is rejected and the previous rules kept", func() {
+					So(err.Error(), ShouldEqual, "invalid robot_en.json: user agent [*], rule [search] must start with / or *")
+					So(fw.GetRobotsFileBody(config.English, ""), ShouldContainSubstring, "Allow: /")
+				})
+			})
+
+			Convey("And the config files are watched and changed", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				reloaded := make(chan struct{}, 1)
+				So(fw.Watch(ctx, func() {
+					select {
+					case reloaded <- struct{}{}:
+					default:
+					}
+				}), ShouldBeNil)
+				writeRobots(config.Welsh, `{"*": {"DenyList": ["/cy"]}}`)
+
+				Convey("Then the new rules are reloaded", func() {
+					select {
+					case <-reloaded:
+					case <-time.After(5 * time.Second):
+						t.Fatal("robots config was not reloaded")
+					}
+					So(fw.GetRobotsFileBody(config.Welsh, ""), ShouldContainSubstring, "Disallow: /cy")
+				})
+			})
+		})
+	})
+
+	Convey("Given a directory with a missing and an empty robots config file", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, robotsFileName(config.English)), []byte(`{}`), 0o600), ShouldBeNil)
+
+		Convey("When a robots file writer is created", func() {
+			_, err := NewRobotFileWriter(dir)
+
+			Convey("Then both problems are reported", func() {
+				So(err, ShouldWrap, ErrEmptyRobots)
+				So(err.Error(), ShouldContainSubstring, "failed to read robot_cy.json")
+			})
+		})
+	})
+
+	Convey("Given a robots file writer of the embedded config files", t, func() {
+		fw, err := NewRobotFileWriter("")
+		So(err, ShouldBeNil)
+
+		Convey("When its config files are watched", func() {
+			err = fw.Watch(context.Background(), nil)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldEqual, ErrNoRobotsDir)
+			})
+		})
+	})
+}
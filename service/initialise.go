@@ -19,6 +19,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 
 	es710 "github.com/elastic/go-elasticsearch/v7"
 )
@@ -157,14 +158,34 @@ func (e *Init) DoGetS3Client(cfg *config.S3Config) (sitemap.S3Client, error) {
 			return nil, err
 		}
 
-		return dps3.NewClientWithSession(cfg.UploadBucketName, s), nil
+		return newS3Client(dps3.NewClientWithSession(cfg.UploadBucketName, s)), nil
 	}
 
 	s3Client, err := dps3.NewClient(cfg.AwsRegion, cfg.UploadBucketName)
 	if err != nil {
 		return nil, err
 	}
-	return s3Client, nil
+	return newS3Client(s3Client), nil
+}
+
+// s3Client is a dp-s3 client that can also delete objects, which dp-s3 does not support
+type s3Client struct {
+	*dps3.Client
+	api *s3.S3
+}
+
+func newS3Client(client *dps3.Client) *s3Client {
+	return &s3Client{Client: client, api: s3.New(client.Session())}
+}
+
+// Delete deletes the object with the given key from the bucket
+func (c *s3Client) Delete(key string) error {
+	bucket := c.BucketName()
+	_, err := c.api.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	return err
 }
 
 // DoGetS3Clients returns a DP and raw Elastic clients
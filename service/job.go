@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/api"
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/go-co-op/gocron"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+// fullSitemapJob gives access to the scheduled full sitemap generation job and keeps the outcome of its last run
+type fullSitemapJob struct {
+	scheduler   *gocron.Scheduler
+	description string
+	jitter      time.Duration
+	triggered   atomic.Bool
+	stopped     chan struct{}
+	stopOnce    sync.Once
+	mx          sync.RWMutex
+	duration    time.Duration
+	urlCounts   sitemap.URLCounts
+	err         error
+}
+
+func newFullSitemapJob(scheduler *gocron.Scheduler) *fullSitemapJob {
+	return &fullSitemapJob{
+		scheduler: scheduler,
+		stopped:   make(chan struct{}),
+	}
+}
+
+// schedule adds fn to the scheduler as the full sitemap generation job. The job runs on the cron expression
+// or at the daily times of day given in cfg if any, otherwise at the generation frequency.
+func (j *fullSitemapJob) schedule(cfg *config.Config, fn func(gocron.Job)) error {
+	var s *gocron.Scheduler
+	switch {
+	case cfg.SitemapGenerationCron != "" && cfg.SitemapGenerationAt != "":
+		return errors.New("only one of the full sitemap generation cron expression and times of day can be set")
+	case cfg.SitemapGenerationCron != "":
+		s = j.scheduler.Cron(cfg.SitemapGenerationCron)
+		j.description = fmt.Sprintf("cron %s", cfg.SitemapGenerationCron)
+	case cfg.SitemapGenerationAt != "":
+		loc, err := generationLocation(cfg)
+		if err != nil {
+			return err
+		}
+		// the times of day are taken in the location of the scheduler, which only runs this job
+		j.scheduler.ChangeLocation(loc)
+		s = j.scheduler.Every(1).Day().At(cfg.SitemapGenerationAt)
+		j.description = fmt.Sprintf("daily at %s %s", cfg.SitemapGenerationAt, loc)
+	default:
+		s = j.scheduler.Every(cfg.SitemapGenerationFrequency)
+		j.description = fmt.Sprintf("every %s", cfg.SitemapGenerationFrequency)
+	}
+	if cfg.SitemapGenerationOnStartup {
+		s = s.StartImmediately()
+	} else {
+		s = s.WaitForSchedule()
+	}
+	j.jitter = cfg.SitemapGenerationJitter
+
+	_, err := s.Tag(schedulerTagFullSitemap).DoWithJobDetails(fn)
+	return err
+}
+
+// maxCronRuns is the number of upcoming runs of a cron expression looked at to find the longest time between two of them
+const maxCronRuns = 1000
+
+// generationPeriod returns the longest time between two full sitemap generations of the schedule given in cfg, which is
+// the cron expression or the daily times of day if any, otherwise the generation frequency
+func generationPeriod(cfg *config.Config) (time.Duration, error) {
+	switch {
+	case cfg.SitemapGenerationCron != "":
+		schedule, err := cron.ParseStandard(cfg.SitemapGenerationCron)
+		if err != nil {
+			return 0, errors.Wrap(err, "invalid full sitemap generation cron expression")
+		}
+		return cronPeriod(schedule, time.Now()), nil
+	case cfg.SitemapGenerationAt != "":
+		loc, err := generationLocation(cfg)
+		if err != nil {
+			return 0, err
+		}
+		return dailyPeriod(cfg.SitemapGenerationAt, loc, time.Now())
+	default:
+		return cfg.SitemapGenerationFrequency, nil
+	}
+}
+
+// generationLocation returns the time zone of the daily times of day of the full sitemap generation
+func generationLocation(cfg *config.Config) (*time.Location, error) {
+	loc, err := time.LoadLocation(cfg.SitemapGenerationTZ)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid full sitemap generation time zone")
+	}
+	return loc, nil
+}
+
+// cronPeriod returns the longest time between two of the runs of schedule over the year following from, or its
+// next maxCronRuns runs if sooner
+func cronPeriod(schedule cron.Schedule, from time.Time) time.Duration {
+	var period time.Duration
+	end := from.AddDate(1, 0, 0)
+	previous := schedule.Next(from)
+	for i := 0; i < maxCronRuns && !previous.IsZero() && previous.Before(end); i++ {
+		next := schedule.Next(previous)
+		if next.IsZero() {
+			break
+		}
+		period = max(period, next.Sub(previous))
+		previous = next
+	}
+	return period
+}
+
+// dailyPeriod returns the longest time between two of the times of day ("HH:MM[:SS]", separated by ";") in at, in
+// location loc, over the year following from, so that days made longer by daylight saving time changes are accounted for
+func dailyPeriod(at string, loc *time.Location, from time.Time) (time.Duration, error) {
+	var times []time.Duration
+	for _, value := range strings.Split(at, ";") {
+		t, err := time.Parse("15:04:05", value)
+		if err != nil {
+			t, err = time.Parse("15:04", value)
+		}
+		if err != nil {
+			return 0, errors.Errorf("invalid full sitemap generation time of day %q", value)
+		}
+		times = append(times, time.Duration(t.Hour())*time.Hour+time.Duration(t.Minute())*time.Minute+time.Duration(t.Second())*time.Second)
+	}
+	slices.Sort(times)
+
+	var (
+		period   time.Duration
+		previous time.Time
+	)
+	year, month, day := from.In(loc).Date()
+	for d := 0; d <= 366; d++ {
+		for _, t := range times {
+			run := time.Date(year, month, day+d, 0, 0, int(t/time.Second), 0, loc)
+			if !previous.IsZero() {
+				period = max(period, run.Sub(previous))
+			}
+			previous = run
+		}
+	}
+	return period, nil
+}
+
+// logNextRun logs when the full sitemap generation job is next planned to run
+func (j *fullSitemapJob) logNextRun(ctx context.Context) {
+	job, err := j.job()
+	if err != nil {
+		log.Error(ctx, "failed to get full sitemap generation job", err)
+		return
+	}
+	log.Info(ctx, "full sitemap generation scheduled", log.Data{"schedule": j.description, "next_run": job.NextRun(), "jitter": j.jitter.String()})
+}
+
+// wait delays a scheduled run of the job by a random duration up to the configured jitter, so that instances
+// do not all generate at the same time. Runs started by Trigger are not delayed.
+// It returns false if the job was stopped while waiting.
+func (j *fullSitemapJob) wait(ctx context.Context) bool {
+	if j.triggered.Swap(false) || j.jitter <= 0 {
+		return true
+	}
+	delay := rand.N(j.jitter)
+	log.Info(ctx, "delaying full sitemap generation", log.Data{"delay": delay.String()})
+	select {
+	case <-time.After(delay):
+		return true
+	case <-j.stopped:
+		return false
+	}
+}
+
+// stop interrupts any run of the job waiting for its jitter delay
+func (j *fullSitemapJob) stop() {
+	j.stopOnce.Do(func() { close(j.stopped) })
+}
+
+// job returns the scheduled full sitemap generation job
+func (j *fullSitemapJob) job() (*gocron.Job, error) {
+	jobs, err := j.scheduler.FindJobsByTag(schedulerTagFullSitemap)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find full sitemap generation job")
+	}
+	if len(jobs) != 1 {
+		return nil, errors.Errorf("unexpected number of full sitemap generation jobs found: %d", len(jobs))
+	}
+	return jobs[0], nil
+}
+
+// Trigger runs the full sitemap generation job now, unless it is already running
+func (j *fullSitemapJob) Trigger() error {
+	job, err := j.job()
+	if err != nil {
+		return err
+	}
+	if job.IsRunning() {
+		return api.ErrGenerationRunning
+	}
+	j.triggered.Store(true)
+	if err = j.scheduler.RunByTag(schedulerTagFullSitemap); err != nil {
+		j.triggered.Store(false)
+		return errors.Wrap(err, "failed to run full sitemap generation job")
+	}
+	return nil
+}
+
+// Status reports the schedule of the full sitemap generation job and the outcome of its last run
+func (j *fullSitemapJob) Status() (api.GenerationStatus, error) {
+	job, err := j.job()
+	if err != nil {
+		return api.GenerationStatus{}, err
+	}
+
+	j.mx.RLock()
+	defer j.mx.RUnlock()
+
+	status := api.GenerationStatus{
+		Running:   job.IsRunning(),
+		Schedule:  j.description,
+		URLCounts: j.urlCounts,
+	}
+	if lastRun := job.LastRun(); !lastRun.IsZero() {
+		status.LastRun = &lastRun
+	}
+	if nextRun := job.NextRun(); !nextRun.IsZero() {
+		status.NextRun = &nextRun
+	}
+	if j.duration > 0 {
+		status.LastDuration = j.duration.String()
+	}
+	if j.err != nil {
+		status.LastError = j.err.Error()
+	}
+	return status, nil
+}
+
+// record keeps the outcome of a run of the full sitemap generation job
+func (j *fullSitemapJob) record(duration time.Duration, result *sitemap.FullSitemapResult, err error) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	j.duration = duration
+	j.err = err
+	if err == nil {
+		j.urlCounts = result.URLCounts
+	}
+}
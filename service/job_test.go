@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/go-co-op/gocron"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFullSitemapJobSchedule(t *testing.T) {
+	Convey("Given a full sitemap job", t, func() {
+		scheduler := gocron.NewScheduler(time.UTC)
+		scheduler.SingletonModeAll()
+		defer scheduler.Stop()
+		job := newFullSitemapJob(scheduler)
+		runs := make(chan struct{}, 1)
+		fn := func(gocron.Job) { runs <- struct{}{} }
+
+		Convey("When it is scheduled with a cron expression and no run on start-up", func() {
+			err := job.schedule(&config.Config{SitemapGenerationCron: "30 3 * * *"}, fn)
+			So(err, ShouldBeNil)
+			scheduler.StartAsync()
+
+			Convey("Then the next run is at the time given by the cron expression", func() {
+				next, err := job.job()
+				So(err, ShouldBeNil)
+				So(next.NextRun().Hour(), ShouldEqual, 3)
+				So(next.NextRun().Minute(), ShouldEqual, 30)
+				So(next.NextRun(), ShouldHappenAfter, time.Now())
+			})
+			Convey("Then the schedule is shown in the status", func() {
+				status, err := job.Status()
+				So(err, ShouldBeNil)
+				So(status.Schedule, ShouldEqual, "cron 30 3 * * *")
+				So(status.NextRun, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When it is scheduled at a time of day with a run on start-up", func() {
+			err := job.schedule(&config.Config{SitemapGenerationAt: "04:15", SitemapGenerationOnStartup: true}, fn)
+			So(err, ShouldBeNil)
+			scheduler.StartAsync()
+
+			Convey("Then the job runs straight away", func() {
+				select {
+				case <-runs:
+				case <-time.After(time.Second):
+					t.Error("job did not run on start-up")
+				}
+			})
+			Convey("Then the schedule is shown in the status", func() {
+				status, err := job.Status()
+				So(err, ShouldBeNil)
+				So(status.Schedule, ShouldEqual, "daily at 04:15 UTC")
+			})
+		})
+
+		Convey("When it is scheduled at a time of day in a time zone", func() {
+			err := job.schedule(&config.Config{SitemapGenerationAt: "04:15", SitemapGenerationTZ: "Europe/London"}, fn)
+			So(err, ShouldBeNil)
+			scheduler.StartAsync()
+
+			Convey("Then the next run is at the time of day in the time zone", func() {
+				london, err := time.LoadLocation("Europe/London")
+				So(err, ShouldBeNil)
+				next, err := job.job()
+				So(err, ShouldBeNil)
+				So(next.NextRun().In(london).Hour(), ShouldEqual, 4)
+				So(next.NextRun().In(london).Minute(), ShouldEqual, 15)
+				So(job.description, ShouldEqual, "daily at 04:15 Europe/London")
+			})
+		})
+
+		Convey("When it is scheduled at a time of day in an unknown time zone", func() {
+			err := job.schedule(&config.Config{SitemapGenerationAt: "04:15", SitemapGenerationTZ: "Europe/Nowhere"}, fn)
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "invalid full sitemap generation time zone")
+			})
+		})
+
+		Convey("When it is scheduled at a frequency", func() {
+			err := job.schedule(&config.Config{SitemapGenerationFrequency: time.Hour}, fn)
+
+			Convey("Then the schedule is described by the frequency", func() {
+				So(err, ShouldBeNil)
+				So(job.description, ShouldEqual, "every 1h0m0s")
+			})
+		})
+
+		Convey("When it is scheduled with both a cron expression and a time of day", func() {
+			err := job.schedule(&config.Config{SitemapGenerationCron: "30 3 * * *", SitemapGenerationAt: "04:15"}, fn)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When it is scheduled with an invalid cron expression", func() {
+			err := job.schedule(&config.Config{SitemapGenerationCron: "every day"}, fn)
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestGenerationPeriod(t *testing.T) {
+	Convey("Given the schedules of the full sitemap generation", t, func() {
+		Convey("Then the period of a frequency is the frequency", func() {
+			period, err := generationPeriod(&config.Config{SitemapGenerationFrequency: 6 * time.Hour})
+			So(err, ShouldBeNil)
+			So(period, ShouldEqual, 6*time.Hour)
+		})
+
+		Convey("Then the period of times of day is the longest time between two of them", func() {
+			period, err := generationPeriod(&config.Config{SitemapGenerationAt: "04:15", SitemapGenerationFrequency: time.Hour})
+			So(err, ShouldBeNil)
+			So(period, ShouldEqual, 24*time.Hour)
+			period, err = generationPeriod(&config.Config{SitemapGenerationAt: "20:00;02:00:00;08:00"})
+			So(err, ShouldBeNil)
+			So(period, ShouldEqual, 12*time.Hour)
+		})
+
+		Convey("Then the period of times of day in a time zone includes the hour gained when daylight saving time ends", func() {
+			london, err := time.LoadLocation("Europe/London")
+			So(err, ShouldBeNil)
+			period, err := dailyPeriod("03:00", london, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			So(err, ShouldBeNil)
+			So(period, ShouldEqual, 25*time.Hour)
+			_, err = generationPeriod(&config.Config{SitemapGenerationAt: "03:00", SitemapGenerationTZ: "Europe/Nowhere"})
+			So(err.Error(), ShouldContainSubstring, "invalid full sitemap generation time zone")
+		})
+
+		Convey("Then the period of a cron expression is the longest time between two of its runs", func() {
+			period, err := generationPeriod(&config.Config{SitemapGenerationCron: "30 3 * * 1", SitemapGenerationFrequency: time.Hour})
+			So(err, ShouldBeNil)
+			So(period, ShouldEqual, 7*24*time.Hour)
+			period, err = generationPeriod(&config.Config{SitemapGenerationCron: "0 9,17 * * 1-5"})
+			So(err, ShouldBeNil)
+			So(period, ShouldEqual, 64*time.Hour)
+		})
+
+		Convey("Then an invalid schedule is rejected", func() {
+			_, err := generationPeriod(&config.Config{SitemapGenerationCron: "every day"})
+			So(err.Error(), ShouldContainSubstring, "invalid full sitemap generation cron expression")
+			_, err = generationPeriod(&config.Config{SitemapGenerationAt: "04:15;noon"})
+			So(err.Error(), ShouldEqual, `invalid full sitemap generation time of day "noon"`)
+		})
+	})
+}
+
+func TestFullSitemapJobWait(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a full sitemap job with jitter", t, func() {
+		job := newFullSitemapJob(gocron.NewScheduler(time.UTC))
+		job.jitter = time.Hour
+
+		Convey("When a triggered run waits", func() {
+			job.triggered.Store(true)
+
+			Convey("Then it is not delayed", func() {
+				So(job.wait(ctx), ShouldBeTrue)
+				So(job.triggered.Load(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When the job is stopped while a scheduled run waits", func() {
+			done := make(chan bool)
+			go func() { done <- job.wait(ctx) }()
+			job.stop()
+
+			Convey("Then the run is abandoned", func() {
+				So(<-done, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a full sitemap job without jitter", t, func() {
+		job := newFullSitemapJob(gocron.NewScheduler(time.UTC))
+
+		Convey("Then a scheduled run is not delayed", func() {
+			So(job.wait(ctx), ShouldBeTrue)
+		})
+	})
+}
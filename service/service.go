@@ -2,25 +2,32 @@ package service
 
 import (
 	"context"
-	"strings"
+	"os"
 	"time"
 
 	dpEsClient "github.com/ONSdigital/dp-elasticsearch/v3/client"
 
 	kafka "github.com/ONSdigital/dp-kafka/v3"
+	"github.com/ONSdigital/dp-sitemap/api"
 	"github.com/ONSdigital/dp-sitemap/clients"
 	"github.com/ONSdigital/dp-sitemap/config"
 	"github.com/ONSdigital/dp-sitemap/event"
+	"github.com/ONSdigital/dp-sitemap/lock"
 	"github.com/ONSdigital/dp-sitemap/robotseo"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/go-co-op/gocron"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const schedulerTagFullSitemap = "full-sitemap"
 
+// minSitemapLockTTL is the shortest time to live of the full sitemap lock, which is renewed every third of it
+const minSitemapLockTTL = time.Second
+
 // Service contains all the configs, server and clients to run the event handler service
 type Service struct {
 	server          HTTPServer
@@ -30,8 +37,10 @@ type Service struct {
 	consumer        kafka.IConsumerGroup
 	shutdownTimeout time.Duration
 	scheduler       *gocron.Scheduler
+	fullSitemapJob  *fullSitemapJob
 	esClient        dpEsClient.Client
 	s3Client        sitemap.S3Client
+	stopRobotsWatch context.CancelFunc
 }
 
 // Run the service
@@ -44,6 +53,9 @@ func Run(ctx context.Context, serviceList *ExternalServiceList, buildTime, gitCo
 		return nil, errors.Wrap(err, "unable to retrieve service configuration")
 	}
 	log.Info(ctx, "got service configuration", log.Data{"config": cfg})
+	if cfg.SitemapLockTTL < minSitemapLockTTL {
+		return nil, errors.Errorf("invalid full sitemap lock time to live %s, it must be at least %s", cfg.SitemapLockTTL, minSitemapLockTTL)
+	}
 
 	// Get HTTP Server with collectionID checkHeader middleware
 	r := mux.NewRouter()
@@ -74,26 +86,101 @@ func Run(ctx context.Context, serviceList *ExternalServiceList, buildTime, gitCo
 
 	var (
 		store                 sitemap.FileStore
+		checkableStore        sitemap.CheckableStore
 		fullSitemapFiles      sitemap.Files
+		manifestFile          string
 		publishingSitemapFile string
+		fullSitemapLock       lock.Locker
 	)
 	switch cfg.SitemapSaveLocation {
 	case "s3":
-		store = sitemap.NewS3Store(
+		s3Store := sitemap.NewS3Store(
 			s3Client,
 		)
+		store = sitemap.NewInstrumentedStore(s3Store, "s3")
+		checkableStore = s3Store
 		fullSitemapFiles = cfg.S3Config.SitemapFileKey
+		manifestFile = cfg.S3Config.SitemapManifestKey
 		publishingSitemapFile = cfg.S3Config.PublishingSitemapFileKey
+		fullSitemapLock = lock.NewStoreLocker(s3Store, cfg.S3Config.LockFileKey, cfg.SitemapLockTTL)
 
 	default:
-		store = &sitemap.LocalStore{}
+		localStore := &sitemap.LocalStore{}
+		store = sitemap.NewInstrumentedStore(localStore, "local")
+		checkableStore = localStore
 		fullSitemapFiles = cfg.SitemapLocalFile
+		manifestFile = cfg.SitemapLocalManifestFile
 		publishingSitemapFile = cfg.PublishingSitemapLocalFile
+		fullSitemapLock = lock.NewMemoryLocker(cfg.SitemapLockTTL)
+	}
+	lockOwner := instanceID()
+
+	// robots files have their own store, in the sitemap save location unless configured otherwise
+	var (
+		robotsStore sitemap.FileStore
+		robotsFiles sitemap.Files
+	)
+	robotsSaveLocation := cfg.RobotsSaveLocation
+	if robotsSaveLocation == "" {
+		robotsSaveLocation = cfg.SitemapSaveLocation
+	}
+	switch robotsSaveLocation {
+	case "s3":
+		robotsStore = sitemap.NewInstrumentedStore(sitemap.NewS3Store(s3Client), "s3")
+		robotsFiles = cfg.S3Config.RobotsFileKey
+	default:
+		robotsStore = sitemap.NewInstrumentedStore(&sitemap.LocalStore{}, "local")
+		robotsFiles = cfg.RobotsFilePath
+	}
+
+	// full sitemaps are published as versioned generations, unless they are overwritten in place
+	var (
+		generations  *sitemap.Generations
+		sitemapFiles sitemap.FileResolver = fullSitemapFiles
+	)
+	if cfg.SitemapGenerationsKept > 0 {
+		generations = sitemap.NewGenerations(store, manifestFile, fullSitemapFiles, cfg.SitemapGenerationsKept)
+		sitemapFiles = generations
+	}
+
+	period, err := generationPeriod(cfg)
+	if err != nil {
+		return nil, err
+	}
+	storeChecker := sitemap.NewStoreChecker(
+		checkableStore,
+		sitemapFiles,
+		period*time.Duration(cfg.SitemapMaxAgeMultiplier),
+	)
+	generationChecker := sitemap.NewGenerationChecker(cfg.SitemapMaxURLDropPercent, cfg.SitemapMaxFailures)
+
+	// Write the robots files on start-up and whenever their rules change, independently of the sitemap generations
+	robotFileWriter, err := robotseo.NewRobotFileWriter(cfg.RobotsConfigDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load robots config")
+	}
+	robotFileWriter.Version = version
+	robotsPublisher, err := robotseo.NewPublisher(cfg, robotFileWriter, robotsStore, robotsFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err = robotsPublisher.Publish(); err != nil {
+		log.Error(ctx, "failed to write robots files", err)
+	} else {
+		log.Info(ctx, "wrote robots files")
+	}
+
+	robotsCheck, err := sitemap.NewRobotsCheck(robotFileWriter, cfg.SitemapRobotsCheck)
+	if err != nil {
+		return nil, err
 	}
 
 	scroll := sitemap.NewElasticScroll(esRawClient, cfg)
 	fetcher := sitemap.NewElasticFetcher(scroll, cfg, zebedeeClient)
-	handler := event.NewContentPublishedHandler(store, zebedeeClient, cfg, fetcher)
+	// sitemap updates wait for any running full sitemap generation, so that they are made to the generation it publishes
+	handler := event.NewContentPublishedHandler(store, sitemapFiles, zebedeeClient, cfg, fetcher,
+		event.WithGenerationLock(fullSitemapLock, lockOwner, cfg.SitemapLockTTL/3),
+		event.WithRobotsCheck(robotsCheck))
 
 	// Event Handler for Kafka Consumer
 	event.Consume(ctx, consumer, handler, cfg)
@@ -113,39 +200,28 @@ func Run(ctx context.Context, serviceList *ExternalServiceList, buildTime, gitCo
 		return nil, err
 	}
 
-	if err = registerCheckers(ctx, hc, consumer, esClient, zebedeeClient); err != nil {
+	if err = registerCheckers(ctx, hc, consumer, esClient, zebedeeClient, storeChecker, generationChecker); err != nil {
 		return nil, errors.Wrap(err, "unable to register checkers")
 	}
 
 	r.StrictSlash(true).Path("/health").HandlerFunc(hc.Handler)
+	r.StrictSlash(true).Path("/metrics").Handler(promhttp.Handler())
 	hc.Start(ctx)
 
-	// Run the http server in a new go-routine
-	go func() {
-		if serveErr := s.ListenAndServe(); serveErr != nil {
-			svcErrors <- errors.Wrap(err, "failure in http listen and serve")
-		}
-	}()
+	// Serve the sitemaps and robots files from the store
+	a := api.Setup(ctx, r, cfg, store, sitemapFiles, robotsStore, robotsFiles)
 
 	scheduler := gocron.NewScheduler(time.UTC)
 	scheduler.SingletonModeAll()
+	fullJob := newFullSitemapJob(scheduler)
 
 	runSitemapGeneration := func() {
 		log.Info(ctx, "full sitemap generation from callback start")
-		jobs, findErr := scheduler.FindJobsByTag(schedulerTagFullSitemap)
-		if findErr != nil {
-			log.Error(ctx, "failed to find full sitemap generation job", findErr)
-			return
-		}
-		if len(jobs) != 1 {
-			log.Error(ctx, "unexpected jobs found", findErr)
-			return
-		}
-		if jobs[0].IsRunning() {
+		runErr := fullJob.Trigger()
+		if errors.Is(runErr, api.ErrGenerationRunning) {
 			log.Info(ctx, "full sitemap generation from callback - job is already running")
 			return
 		}
-		runErr := scheduler.RunByTag(schedulerTagFullSitemap)
 		if runErr != nil {
 			log.Error(ctx, "failed to run full sitemap generation job", runErr)
 			return
@@ -155,7 +231,7 @@ func Run(ctx context.Context, serviceList *ExternalServiceList, buildTime, gitCo
 
 	scroller := sitemap.NewElasticScroll(esRawClient, cfg)
 
-	generator := sitemap.NewGenerator(
+	generatorOptions := []sitemap.GeneratorOptions{
 		sitemap.WithFetcher(sitemap.NewElasticFetcher(
 			scroller,
 			cfg,
@@ -164,41 +240,77 @@ func Run(ctx context.Context, serviceList *ExternalServiceList, buildTime, gitCo
 		sitemap.WithAdder(&sitemap.DefaultAdder{}),
 		sitemap.WithFileStore(store),
 		sitemap.WithFullSitemapFiles(fullSitemapFiles),
+		sitemap.WithGenerations(generations),
+		sitemap.WithMaxURLDropPercent(cfg.SitemapMaxPublishDropPercent),
+		sitemap.WithForce(cfg.SitemapForcePublish),
 		sitemap.WithPublishingSitemapFile(publishingSitemapFile),
 		sitemap.WithPublishingSitemapMaxSize(cfg.PublishingSitemapMaxSize, runSitemapGeneration),
-	)
-
-	robotFileWriter := robotseo.RobotFileWriter{}
+	}
+	if cfg.SitemapStaticMerge {
+		generatorOptions = append(generatorOptions, sitemap.WithStaticPages(sitemap.NewStaticPages(cfg, cfg.SitemapStaticDir)))
+	}
+	generatorOptions = append(generatorOptions, sitemap.WithRobotsCheck(robotsCheck))
+	generator := sitemap.NewGenerator(generatorOptions...)
 
 	generateSitemapJob := func(job gocron.Job) {
+		if !fullJob.wait(context.Background()) {
+			log.Info(context.Background(), "sitemap generation job stopped before start")
+			return
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.SitemapGenerationTimeout)
 		defer cancel()
-		log.Info(ctx, "sitemap generation job start", log.Data{"last_run": job.LastRun(), "next_run": job.NextRun(), "run_count": job.RunCount()})
-		genErr := generator.MakeFullSitemap(ctx)
+		var (
+			result *sitemap.FullSitemapResult
+			genErr error
+		)
+		ran, lockErr := lock.Do(ctx, fullSitemapLock, lockOwner, cfg.SitemapLockTTL/3, func(ctx context.Context) {
+			log.Info(ctx, "sitemap generation job start", log.Data{"last_run": job.LastRun(), "next_run": job.NextRun(), "run_count": job.RunCount()})
+			start := time.Now()
+			result, genErr = generator.MakeFullSitemap(ctx)
+			fullJob.record(time.Since(start), result, genErr)
+			generationChecker.Record(result, genErr)
+		})
+		if lockErr != nil {
+			log.Error(ctx, "failed to acquire full sitemap lock", lockErr, log.Data{"owner": lockOwner})
+			return
+		}
+		if !ran {
+			log.Info(ctx, "sitemap generation job skipped - another instance is generating the sitemap", log.Data{"owner": lockOwner})
+			return
+		}
 		if genErr != nil {
 			log.Error(ctx, "failed to generate sitemap", genErr)
 			return
 		}
-		log.Info(ctx, "sitemap generation job complete", log.Data{"last_run": job.LastRun(), "next_run": job.NextRun(), "run_count": job.RunCount()})
-
-		// write robots file
-		// TODO: pass sitemap file path (once URL is known)
-		for _, lang := range []config.Language{config.English, config.Welsh} {
-			body := robotFileWriter.GetRobotsFileBody(lang, cfg.SitemapLocalFile)
-			saveErr := store.SaveFile(cfg.RobotsFilePath[lang], strings.NewReader(body))
-			if saveErr != nil {
-				log.Error(ctx, "failed to save file", saveErr)
-				return
-			}
-		}
-		log.Info(ctx, "wrote robots file")
+		log.Info(ctx, "sitemap generation job complete", log.Data{"last_run": job.LastRun(), "next_run": job.NextRun(), "run_count": job.RunCount(), "url_counts": result.URLCounts, "blocked_urls": result.BlockedURLs})
 	}
 
-	_, err = scheduler.Every(cfg.SitemapGenerationFrequency).Tag(schedulerTagFullSitemap).DoWithJobDetails(generateSitemapJob)
+	err = fullJob.schedule(cfg, generateSitemapJob)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to run scheduler")
 	}
+
+	// Reload the robots config and write the robots files when it changes
+	robotsCtx, stopRobotsWatch := context.WithCancel(context.Background())
+	if cfg.RobotsConfigDir != "" {
+		if err = robotsPublisher.Watch(robotsCtx); err != nil {
+			stopRobotsWatch()
+			return nil, errors.Wrap(err, "unable to watch robots config")
+		}
+	}
+
+	// Admin endpoints to trigger and inspect the full sitemap generation
+	a.SetupAdmin(ctx, cfg.AdminAuthToken, fullJob, handler, robotsPublisher)
+
+	// Run the http server in a new go-routine
+	go func() {
+		if serveErr := s.ListenAndServe(); serveErr != nil {
+			svcErrors <- errors.Wrap(err, "failure in http listen and serve")
+		}
+	}()
+
 	scheduler.StartAsync()
+	fullJob.logNextRun(ctx)
 
 	return &Service{
 		server:          s,
@@ -208,8 +320,10 @@ func Run(ctx context.Context, serviceList *ExternalServiceList, buildTime, gitCo
 		consumer:        consumer,
 		shutdownTimeout: cfg.GracefulShutdownTimeout,
 		scheduler:       scheduler,
+		fullSitemapJob:  fullJob,
 		esClient:        esClient,
 		s3Client:        s3Client,
+		stopRobotsWatch: stopRobotsWatch,
 	}, nil
 }
 
@@ -230,12 +344,17 @@ func (svc *Service) Close(ctx context.Context) error {
 		if svc.serviceList.HealthCheck {
 			svc.healthCheck.Stop()
 		}
-		// stop the scheduler
+		// stop the scheduler, once any delayed run of the full sitemap generation is interrupted
 		log.Info(ctx, "stopping scheduler")
+		svc.fullSitemapJob.stop()
 		svc.scheduler.Stop()
 		if !svc.scheduler.IsRunning() {
 			log.Info(ctx, "stopped scheduler")
 		}
+		// stop watching the robots config
+		if svc.stopRobotsWatch != nil {
+			svc.stopRobotsWatch()
+		}
 
 		// If kafka consumer exists, stop listening to it.
 		// This will automatically stop the event consumer loops and no more messages will be processed.
@@ -283,11 +402,22 @@ func (svc *Service) Close(ctx context.Context) error {
 	return nil
 }
 
+// instanceID returns an identifier of this instance of the service, unique between restarts
+func instanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return hostname + "-" + uuid.NewString()
+}
+
 func registerCheckers(ctx context.Context,
 	hc HealthChecker,
 	consumer kafka.IConsumerGroup,
 	esClient dpEsClient.Client,
 	zebedeeClient clients.ZebedeeClient,
+	storeChecker *sitemap.StoreChecker,
+	generationChecker *sitemap.GenerationChecker,
 ) error {
 	hasErrors := false
 
@@ -306,6 +436,16 @@ func registerCheckers(ctx context.Context,
 		log.Error(ctx, "error adding check for ZebedeeClient", err)
 	}
 
+	if err := hc.AddCheck("Sitemap store", storeChecker.Checker); err != nil {
+		hasErrors = true
+		log.Error(ctx, "error adding check for sitemap store", err)
+	}
+
+	if err := hc.AddCheck("Sitemap generation", generationChecker.Checker); err != nil {
+		hasErrors = true
+		log.Error(ctx, "error adding check for sitemap generation", err)
+	}
+
 	if hasErrors {
 		return errors.New("Error(s) registering checkers for healthcheck")
 	}
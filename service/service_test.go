@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-api-clients-go/v2/zebedee"
 	dpEsClient "github.com/ONSdigital/dp-elasticsearch/v3/client"
@@ -117,6 +118,23 @@ func TestRun(t *testing.T) {
 			return zebedeeMock
 		}
 
+		Convey("Given a full sitemap lock time to live that is too short", func() {
+			cfg, err := config.Get()
+			So(err, ShouldBeNil)
+			ttl := cfg.SitemapLockTTL
+			cfg.SitemapLockTTL = 2 * time.Nanosecond
+			Reset(func() { cfg.SitemapLockTTL = ttl })
+			initMock := &serviceMock.InitialiserMock{}
+			svcErrors := make(chan error, 1)
+			svcList := service.NewServiceList(initMock)
+			_, err = service.Run(ctx, svcList, testBuildTime, testGitCommit, testVersion, svcErrors)
+
+			Convey("Then service Run fails before initialising any dependency", func() {
+				So(err.Error(), ShouldEqual, "invalid full sitemap lock time to live 2ns, it must be at least 1s")
+				So(svcList.KafkaConsumer, ShouldBeFalse)
+			})
+		})
+
 		Convey("Given that initialising Kafka consumer returns an error", func() {
 			initMock := &serviceMock.InitialiserMock{
 				DoGetHTTPServerFunc:    funcDoGetHTTPServerNil,
@@ -175,10 +193,12 @@ func TestRun(t *testing.T) {
 			})
 
 			Convey("The checkers are registered and the healthcheck and http server started", func() {
-				So(len(hcMock.AddCheckCalls()), ShouldEqual, 3)
+				So(len(hcMock.AddCheckCalls()), ShouldEqual, 5)
 				So(hcMock.AddCheckCalls()[0].Name, ShouldResemble, "Kafka consumer")
 				So(hcMock.AddCheckCalls()[1].Name, ShouldResemble, "Elasticsearch")
 				So(hcMock.AddCheckCalls()[2].Name, ShouldResemble, "Zebedee client")
+				So(hcMock.AddCheckCalls()[3].Name, ShouldResemble, "Sitemap store")
+				So(hcMock.AddCheckCalls()[4].Name, ShouldResemble, "Sitemap generation")
 				So(len(initMock.DoGetHTTPServerCalls()), ShouldEqual, 1)
 				So(initMock.DoGetHTTPServerCalls()[0].BindAddr, ShouldEqual, "localhost:")
 				So(len(hcMock.StartCalls()), ShouldEqual, 1)
@@ -213,7 +233,7 @@ func TestRun(t *testing.T) {
 				So(err.Error(), ShouldResemble, fmt.Sprintf("unable to register checkers: %s", errAddheckFail.Error()))
 				So(svcList.HealthCheck, ShouldBeTrue)
 				So(svcList.KafkaConsumer, ShouldBeTrue)
-				So(len(hcMockAddFail.AddCheckCalls()), ShouldEqual, 3)
+				So(len(hcMockAddFail.AddCheckCalls()), ShouldEqual, 5)
 				So(hcMockAddFail.AddCheckCalls()[0].Name, ShouldResemble, "Kafka consumer")
 				So(hcMockAddFail.AddCheckCalls()[1].Name, ShouldResemble, "Elasticsearch")
 			})
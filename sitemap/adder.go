@@ -13,27 +13,46 @@ import (
 
 type DefaultAdder struct{}
 
-func (a *DefaultAdder) Add(oldSitemap io.Reader, url *URL) (fileName string, size int, err error) {
+// Add writes the urls of the old sitemap along with url to a new temporary sitemap file. An entry of the old sitemap
+// with the same loc as url is replaced by it, so that a page added again is listed once, with its latest lastmod.
+func (a *DefaultAdder) Add(ctx context.Context, oldSitemap io.Reader, url *URL) (fileName string, size int, err error) {
+	return rewriteSitemap(ctx, oldSitemap, func(urls []URL) []URL {
+		if url == nil {
+			return urls
+		}
+		// replace the entry of the page if it is already there, or add it
+		for i := range urls {
+			if urls[i].Loc == url.Loc {
+				urls[i] = *url
+				return urls
+			}
+		}
+		return append(urls, *url)
+	})
+}
+
+// rewriteSitemap writes the urls of the old sitemap, as changed by update, to a new temporary sitemap file
+func rewriteSitemap(ctx context.Context, oldSitemap io.Reader, update func(urls []URL) []URL) (fileName string, size int, err error) {
 	// create a temporary file
 	file, err := os.CreateTemp("", "sitemap-incr")
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to create publishing sitemap file: %w", err)
 	}
 	fileName = file.Name()
-	log.Info(context.Background(), "created publishing sitemap file", log.Data{"filename": fileName})
+	log.Info(ctx, "created publishing sitemap file", log.Data{"filename": fileName})
 	defer func() {
 		closeErr := file.Close()
 		if closeErr != nil {
-			log.Error(context.Background(), "failed to close publishing sitemap file", closeErr)
+			log.Error(ctx, "failed to close publishing sitemap file", closeErr)
 		}
 		// clean up the temporary file if we're returning with an error
 		if err != nil {
 			removeErr := os.Remove(fileName)
 			if removeErr != nil {
-				log.Error(context.Background(), "failed to remove publishing sitemap file", removeErr)
+				log.Error(ctx, "failed to remove publishing sitemap file", removeErr)
 				return
 			}
-			log.Info(context.Background(), "removed publishing sitemap file", log.Data{"filename": fileName})
+			log.Info(ctx, "removed publishing sitemap file", log.Data{"filename": fileName})
 		}
 	}()
 
@@ -56,23 +75,11 @@ func (a *DefaultAdder) Add(oldSitemap io.Reader, url *URL) (fileName string, siz
 		Xhtml: "http://www.w3.org/1999/xhtml",
 	}
 
-	for _, oldURL := range sitemapReader.URL {
-		var newURL URL
-		newURL.Loc = oldURL.Loc
-		newURL.Lastmod = oldURL.Lastmod
-		newURL.Alternate = &AlternateURL{}
-		if oldURL.Alternate != nil {
-			newURL.Alternate.Rel = oldURL.Alternate.Rel
-			newURL.Alternate.Link = oldURL.Alternate.Link
-			newURL.Alternate.Lang = oldURL.Alternate.Lang
-		}
-		sitemap.URL = append(sitemap.URL, newURL)
+	for i := range sitemapReader.URL {
+		sitemap.URL = append(sitemap.URL, sitemapReader.URL[i].URL())
 	}
 
-	// add new URL
-	if url != nil {
-		sitemap.URL = append(sitemap.URL, *url)
-	}
+	sitemap.URL = update(sitemap.URL)
 
 	// output result into the file
 	_, err = file.WriteString(xml.Header)
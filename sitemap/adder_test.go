@@ -1,6 +1,7 @@
 package sitemap_test
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -14,7 +15,7 @@ func TestAdder(t *testing.T) {
 		oldSitemap := strings.NewReader("<<<")
 
 		a := &sitemap.DefaultAdder{}
-		filename, _, err := a.Add(oldSitemap, nil)
+		filename, _, err := a.Add(context.Background(), oldSitemap, nil)
 
 		Convey("Adder should return correct error", func() {
 			So(err.Error(), ShouldContainSubstring, "failed to decode old sitemap")
@@ -31,7 +32,7 @@ func TestAdder(t *testing.T) {
 		oldSitemap := strings.NewReader("")
 
 		a := &sitemap.DefaultAdder{}
-		filename, size, err := a.Add(oldSitemap, &sitemap.URL{Loc: "a", Lastmod: "b"})
+		filename, size, err := a.Add(context.Background(), oldSitemap, &sitemap.URL{Loc: "a", Lastmod: "b"})
 		defer func() {
 			removeErr := os.Remove(filename)
 			So(removeErr, ShouldBeNil)
@@ -76,7 +77,7 @@ func TestAdder(t *testing.T) {
 		</urlset>`)
 
 		a := &sitemap.DefaultAdder{}
-		filename, size, err := a.Add(oldSitemap, &sitemap.URL{Loc: "e", Lastmod: "f", Alternate: &sitemap.AlternateURL{Rel: "G", Lang: "H", Link: "I"}})
+		filename, size, err := a.Add(context.Background(), oldSitemap, &sitemap.URL{Loc: "e", Lastmod: "f", Alternate: &sitemap.AlternateURL{Rel: "G", Lang: "H", Link: "I"}})
 		defer func() {
 			removeErr := os.Remove(filename)
 			So(removeErr, ShouldBeNil)
@@ -113,6 +114,49 @@ func TestAdder(t *testing.T) {
     <lastmod>f</lastmod>
     <xhtml:link rel="G" hreflang="H" href="I"></xhtml:link>
   </url>
+</urlset>`)
+		})
+	})
+	Convey("When old sitemap already contains the url", t, func() {
+		oldSitemap := strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+		<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+		  <url>
+			<loc>a</loc>
+			<lastmod>b</lastmod>
+			<xhtml:link rel="A" hreflang="B" href="C"></xhtml:link>
+		  </url>
+		  <url>
+			<loc>c</loc>
+			<lastmod>d</lastmod>
+		  </url>
+		</urlset>`)
+
+		a := &sitemap.DefaultAdder{}
+		filename, size, err := a.Add(context.Background(), oldSitemap, &sitemap.URL{Loc: "a", Lastmod: "e"})
+		defer func() {
+			removeErr := os.Remove(filename)
+			So(removeErr, ShouldBeNil)
+		}()
+
+		Convey("Adder should return with no error", func() {
+			So(err, ShouldBeNil)
+		})
+		Convey("Sitemap size should be unchanged", func() {
+			So(size, ShouldEqual, 2)
+		})
+		Convey("Sitemap should list the url once, replaced by the new one", func() {
+			sitemapContent, err := os.ReadFile(filename)
+			So(err, ShouldBeNil)
+			So(string(sitemapContent), ShouldEqual, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>a</loc>
+    <lastmod>e</lastmod>
+  </url>
+  <url>
+    <loc>c</loc>
+    <lastmod>d</lastmod>
+  </url>
 </urlset>`)
 		})
 	})
@@ -132,7 +176,7 @@ func TestAdder(t *testing.T) {
 		</urlset>`)
 
 		a := &sitemap.DefaultAdder{}
-		filename, size, err := a.Add(oldSitemap, nil)
+		filename, size, err := a.Add(context.Background(), oldSitemap, nil)
 		defer func() {
 			removeErr := os.Remove(filename)
 			So(removeErr, ShouldBeNil)
@@ -0,0 +1,31 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+)
+
+// URLCounts holds the number of URLs in the sitemap of each language
+type URLCounts map[config.Language]int
+
+// CountURLs returns the number of url entries in a sitemap, decoding it as a stream
+func CountURLs(sitemap io.Reader) (int, error) {
+	count := 0
+	decoder := xml.NewDecoder(sitemap)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to decode sitemap: %w", err)
+		}
+		if el, ok := token.(xml.StartElement); ok && el.Name.Local == "url" {
+			count++
+		}
+	}
+}
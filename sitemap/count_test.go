@@ -0,0 +1,48 @@
+package sitemap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCountURLs(t *testing.T) {
+	Convey("When counting the urls of a sitemap", t, func() {
+		count, err := sitemap.CountURLs(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>https://www.ons.gov.uk/a</loc>
+    <lastmod>2024-01-01</lastmod>
+    <xhtml:link rel="alternate" hreflang="cy" href="https://cy.ons.gov.uk/a"></xhtml:link>
+  </url>
+  <url>
+    <loc>https://www.ons.gov.uk/b</loc>
+    <lastmod>2024-01-01</lastmod>
+  </url>
+</urlset>`))
+
+		Convey("The number of url entries is returned", func() {
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+		})
+	})
+
+	Convey("When counting the urls of an empty sitemap", t, func() {
+		count, err := sitemap.CountURLs(strings.NewReader(""))
+
+		Convey("Zero is returned", func() {
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 0)
+		})
+	})
+
+	Convey("When counting the urls of an invalid sitemap", t, func() {
+		_, err := sitemap.CountURLs(strings.NewReader("<urlset><url></urlset>"))
+
+		Convey("An error is returned", func() {
+			So(err.Error(), ShouldContainSubstring, "failed to decode sitemap")
+		})
+	})
+}
@@ -0,0 +1,93 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// URLChange is a URL whose lastmod or alternate differs between two sitemaps
+type URLChange struct {
+	Loc          string `json:"loc"`
+	OldLastmod   string `json:"old_lastmod"`
+	NewLastmod   string `json:"new_lastmod"`
+	OldAlternate string `json:"old_alternate,omitempty"`
+	NewAlternate string `json:"new_alternate,omitempty"`
+}
+
+// SitemapDiff lists the URLs added, removed and changed from one sitemap to another, sorted by loc
+type SitemapDiff struct {
+	Added   []string    `json:"added"`
+	Removed []string    `json:"removed"`
+	Changed []URLChange `json:"changed"`
+}
+
+// Empty returns whether the sitemaps have the same URLs
+func (d *SitemapDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffSitemaps compares the URLs of an old and a new sitemap. Empty sitemaps have no URL.
+func DiffSitemaps(oldSitemap, newSitemap io.Reader) (*SitemapDiff, error) {
+	oldURLs, err := readURLs(oldSitemap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode old sitemap: %w", err)
+	}
+	newURLs, err := readURLs(newSitemap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode new sitemap: %w", err)
+	}
+
+	diff := &SitemapDiff{Added: []string{}, Removed: []string{}, Changed: []URLChange{}}
+	for loc, newURL := range newURLs {
+		oldURL, ok := oldURLs[loc]
+		if !ok {
+			diff.Added = append(diff.Added, loc)
+			continue
+		}
+		oldAlternate, newAlternate := alternateString(oldURL.Alternate), alternateString(newURL.Alternate)
+		if oldURL.Lastmod != newURL.Lastmod || oldAlternate != newAlternate {
+			diff.Changed = append(diff.Changed, URLChange{
+				Loc:          loc,
+				OldLastmod:   oldURL.Lastmod,
+				NewLastmod:   newURL.Lastmod,
+				OldAlternate: oldAlternate,
+				NewAlternate: newAlternate,
+			})
+		}
+	}
+	for loc := range oldURLs {
+		if _, ok := newURLs[loc]; !ok {
+			diff.Removed = append(diff.Removed, loc)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Loc < diff.Changed[j].Loc })
+	return diff, nil
+}
+
+// readURLs decodes the urls of a sitemap by loc
+func readURLs(sitemap io.Reader) (map[string]URLReader, error) {
+	var urlset UrlsetReader
+	err := xml.NewDecoder(sitemap).Decode(&urlset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	urls := make(map[string]URLReader, len(urlset.URL))
+	for _, u := range urlset.URL {
+		urls[u.Loc] = u
+	}
+	return urls, nil
+}
+
+// alternateString describes an alternate link as its hreflang and href, or returns an empty string if there is none
+func alternateString(alternate *AlternateURLReader) string {
+	if alternate == nil || alternate.Link == "" {
+		return ""
+	}
+	return alternate.Lang + " " + alternate.Link
+}
@@ -0,0 +1,86 @@
+package sitemap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffSitemaps(t *testing.T) {
+	Convey("Given an old and a new sitemap", t, func() {
+		oldSitemap := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url><loc>https://www.ons.gov.uk/kept</loc><lastmod>2023-01-01</lastmod></url>
+  <url><loc>https://www.ons.gov.uk/removed</loc><lastmod>2023-01-01</lastmod></url>
+  <url><loc>https://www.ons.gov.uk/updated</loc><lastmod>2023-01-01</lastmod></url>
+  <url>
+    <loc>https://www.ons.gov.uk/translated</loc>
+    <lastmod>2023-01-01</lastmod>
+    <xhtml:link></xhtml:link>
+  </url>
+</urlset>`
+		newSitemap := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url><loc>https://www.ons.gov.uk/kept</loc><lastmod>2023-01-01</lastmod></url>
+  <url><loc>https://www.ons.gov.uk/updated</loc><lastmod>2023-02-01</lastmod></url>
+  <url>
+    <loc>https://www.ons.gov.uk/translated</loc>
+    <lastmod>2023-01-01</lastmod>
+    <xhtml:link rel="alternate" hreflang="cy" href="https://cy.ons.gov.uk/translated"></xhtml:link>
+  </url>
+  <url><loc>https://www.ons.gov.uk/b-added</loc><lastmod>2023-02-01</lastmod></url>
+  <url><loc>https://www.ons.gov.uk/a-added</loc><lastmod>2023-02-01</lastmod></url>
+</urlset>`
+
+		Convey("When they are compared", func() {
+			diff, err := sitemap.DiffSitemaps(strings.NewReader(oldSitemap), strings.NewReader(newSitemap))
+
+			Convey("Then the added, removed and changed urls are listed in order", func() {
+				So(err, ShouldBeNil)
+				So(diff.Empty(), ShouldBeFalse)
+				So(diff.Added, ShouldResemble, []string{"https://www.ons.gov.uk/a-added", "https://www.ons.gov.uk/b-added"})
+				So(diff.Removed, ShouldResemble, []string{"https://www.ons.gov.uk/removed"})
+				So(diff.Changed, ShouldResemble, []sitemap.URLChange{
+					{
+						Loc:          "https://www.ons.gov.uk/translated",
+						OldLastmod:   "2023-01-01",
+						NewLastmod:   "2023-01-01",
+						NewAlternate: "cy https://cy.ons.gov.uk/translated",
+					},
+					{
+						Loc:        "https://www.ons.gov.uk/updated",
+						OldLastmod: "2023-01-01",
+						NewLastmod: "2023-02-01",
+					},
+				})
+			})
+		})
+
+		Convey("When a sitemap is compared with itself", func() {
+			diff, err := sitemap.DiffSitemaps(strings.NewReader(oldSitemap), strings.NewReader(oldSitemap))
+
+			Convey("Then there is no difference", func() {
+				So(err, ShouldBeNil)
+				So(diff.Empty(), ShouldBeTrue)
+			})
+		})
+
+		Convey("When it is compared with an empty sitemap", func() {
+			diff, err := sitemap.DiffSitemaps(strings.NewReader(""), strings.NewReader(newSitemap))
+
+			Convey("Then all its urls are added", func() {
+				So(err, ShouldBeNil)
+				So(diff.Added, ShouldHaveLength, 5)
+				So(diff.Removed, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When it is compared with a malformed sitemap", func() {
+			_, err := sitemap.DiffSitemaps(strings.NewReader("<urlset><url>"), strings.NewReader(newSitemap))
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "failed to decode old sitemap")
+			})
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 
+	"github.com/ONSdigital/dp-net/v2/request"
 	"github.com/ONSdigital/dp-sitemap/config"
 	es710 "github.com/elastic/go-elasticsearch/v7"
 )
@@ -26,6 +27,7 @@ func (f *ElasticScroll) GetScroll(ctx context.Context, id string, result interfa
 		f.elastic.Scroll.WithScroll(f.cfg.OpenSearchConfig.ScrollTimeout),
 		f.elastic.Scroll.WithScrollID(id),
 		f.elastic.Scroll.WithContext(ctx),
+		f.elastic.Scroll.WithHeader(requestHeaders(ctx)),
 	)
 	if err != nil {
 		return err
@@ -44,7 +46,9 @@ func (f *ElasticScroll) StartScroll(ctx context.Context, result interface{}) err
 		f.elastic.Search.WithIndex(f.cfg.OpenSearchConfig.ElasticSearchIndex),
 		f.elastic.Search.WithScroll(f.cfg.OpenSearchConfig.ScrollTimeout),
 		f.elastic.Search.WithSize(f.cfg.OpenSearchConfig.ScrollSize),
+		f.elastic.Search.WithTrackTotalHits(true),
 		f.elastic.Search.WithContext(ctx),
+		f.elastic.Search.WithHeader(requestHeaders(ctx)),
 		f.elastic.Search.WithBody(strings.NewReader(`
 		{
 			"query": {
@@ -67,3 +71,12 @@ func (f *ElasticScroll) StartScroll(ctx context.Context, result interface{}) err
 	}
 	return nil
 }
+
+// requestHeaders returns the headers used to forward the request ID held in ctx, if any, to OpenSearch
+func requestHeaders(ctx context.Context) map[string]string {
+	requestID := request.GetRequestId(ctx)
+	if requestID == "" {
+		return nil
+	}
+	return map[string]string{request.RequestHeaderKey: requestID}
+}
@@ -1,7 +1,6 @@
 package sitemap
 
 import (
-	"bufio"
 	"context"
 	"encoding/xml"
 	"fmt"
@@ -11,6 +10,7 @@ import (
 
 	"github.com/ONSdigital/dp-sitemap/clients"
 	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/metrics"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
@@ -102,6 +102,18 @@ type AlternateURLReader struct {
 	Lang    string   `xml:"hreflang,omitempty,attr"`
 	Link    string   `xml:"href,omitempty,attr"`
 }
+type SitemapIndex struct {
+	XMLName xml.Name       `xml:"sitemapindex"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	Sitemap []IndexSitemap `xml:"sitemap"`
+}
+
+type IndexSitemap struct {
+	XMLName xml.Name `xml:"sitemap"`
+	Loc     string   `xml:"loc"`
+	Lastmod string   `xml:"lastmod,omitempty"`
+}
+
 type PageInfo struct {
 	ReleaseDate string
 	URLs        map[config.Language]*URL
@@ -124,6 +136,7 @@ func (f *ElasticFetcher) HasWelshContent(ctx context.Context, path string) bool
 	welshPath := path + "/data_cy.json"
 	log.Info(ctx, "checking welsh content", log.Data{"welsh_path": welshPath})
 	_, err := f.zClient.GetFileSize(ctx, "", "", config.Welsh.String(), welshPath)
+	metrics.WelshContentChecks.WithLabelValues(metrics.Outcome(err)).Inc()
 	return err == nil
 }
 
@@ -169,14 +182,16 @@ var (
 	tempSitemapFileCy = "sitemap_cy"
 )
 
-func (f *ElasticFetcher) GetFullSitemap(ctx context.Context) (fileNames Files, err error) {
+// GetFullSitemap writes the sitemap of each language for all the documents in the search index
+// to temporary files and returns their names along with the number of documents in the index
+func (f *ElasticFetcher) GetFullSitemap(ctx context.Context) (fileNames Files, searchHits int, err error) {
 	fileEn, err := os.CreateTemp("", tempSitemapFileEn)
 	if err != nil {
-		return fileNames, fmt.Errorf("failed to create sitemap_en file: %w", err)
+		return fileNames, searchHits, fmt.Errorf("failed to create sitemap_en file: %w", err)
 	}
 	fileCy, err := os.CreateTemp("", tempSitemapFileCy)
 	if err != nil {
-		return fileNames, fmt.Errorf("failed to create sitemap_cy file: %w", err)
+		return fileNames, searchHits, fmt.Errorf("failed to create sitemap_cy file: %w", err)
 	}
 	fileNameEn := fileEn.Name()
 	fileNameCy := fileCy.Name()
@@ -200,31 +215,22 @@ func (f *ElasticFetcher) GetFullSitemap(ctx context.Context) (fileNames Files, e
 		}
 	}()
 
-	bufferedFileEn := bufio.NewWriter(fileEn)
-	defer bufferedFileEn.Flush()
-	encEn := xml.NewEncoder(bufferedFileEn)
-	encEn.Indent("", "  ")
-
-	bufferedFileCy := bufio.NewWriter(fileCy)
-	defer bufferedFileCy.Flush()
-	encCy := xml.NewEncoder(bufferedFileCy)
-	encCy.Indent("", "  ")
-
-	sitemapHdContent := xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">` + "\n"
-	_, err = bufferedFileEn.WriteString(sitemapHdContent)
+	writerEn, err := newSitemapWriter(fileEn)
 	if err != nil {
-		return fileNames, fmt.Errorf("sitemap_en page xml header write error: %w", err)
+		return fileNames, searchHits, fmt.Errorf("sitemap_en page xml header write error: %w", err)
 	}
-	_, err = bufferedFileCy.WriteString(sitemapHdContent)
+	writerCy, err := newSitemapWriter(fileCy)
 	if err != nil {
-		return fileNames, fmt.Errorf("sitemap_cy page xml header write error: %w", err)
+		return fileNames, searchHits, fmt.Errorf("sitemap_cy page xml header write error: %w", err)
 	}
 
 	var result ElasticResult
 	err = f.scroll.StartScroll(ctx, &result)
 	if err != nil {
-		return fileNames, fmt.Errorf("failed to start scroll: %w", err)
+		return fileNames, searchHits, fmt.Errorf("failed to start scroll: %w", err)
 	}
+	metrics.ScrollPages.Inc()
+	searchHits = result.Hits.Total.Value
 
 	scrollID := result.ScrollID
 	for len(result.Hits.Hits) > 0 {
@@ -235,14 +241,14 @@ func (f *ElasticFetcher) GetFullSitemap(ctx context.Context) (fileNames Files, e
 				result.Hits.Hits[i].Source.ReleaseDate.Format("2006-01-02"),
 			)
 
-			err = encEn.Encode(urlEn)
+			err = writerEn.Write(*urlEn)
 			if err != nil {
-				return fileNames, fmt.Errorf("sitemap_en page xml encode error: %w", err)
+				return fileNames, searchHits, fmt.Errorf("sitemap_en page xml encode error: %w", err)
 			}
 			if urlCy != nil {
-				err = encCy.Encode(urlCy)
+				err = writerCy.Write(*urlCy)
 				if err != nil {
-					return fileNames, fmt.Errorf("sitemap_cy page xml encode error: %w", err)
+					return fileNames, searchHits, fmt.Errorf("sitemap_cy page xml encode error: %w", err)
 				}
 			}
 		}
@@ -252,20 +258,21 @@ func (f *ElasticFetcher) GetFullSitemap(ctx context.Context) (fileNames Files, e
 		result = ElasticResult{}
 		err = f.scroll.GetScroll(ctx, scrollID, &result)
 		if err != nil {
-			return fileNames, fmt.Errorf("failed to get scroll: %w", err)
+			return fileNames, searchHits, fmt.Errorf("failed to get scroll: %w", err)
 		}
+		metrics.ScrollPages.Inc()
 	}
 
-	_, err = bufferedFileEn.WriteString("\n" + `</urlset>`)
+	err = writerEn.Close()
 	if err != nil {
-		return fileNames, fmt.Errorf("sitemap_en page xml footer write error: %w", err)
+		return fileNames, searchHits, fmt.Errorf("sitemap_en page xml footer write error: %w", err)
 	}
-	_, err = bufferedFileCy.WriteString("\n" + `</urlset>`)
+	err = writerCy.Close()
 	if err != nil {
-		return fileNames, fmt.Errorf("sitemap_cy page xml footer write error: %w", err)
+		return fileNames, searchHits, fmt.Errorf("sitemap_cy page xml footer write error: %w", err)
 	}
 
-	return fileNames, nil
+	return fileNames, searchHits, nil
 }
 
 func (f *ElasticFetcher) GetPageInfo(ctx context.Context, path string) (*PageInfo, error) {
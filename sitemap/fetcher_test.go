@@ -37,7 +37,7 @@ func TestFetcher(t *testing.T) {
 		scroller := sitemap.NewElasticScroll(esMock, cfg)
 
 		f := sitemap.NewElasticFetcher(scroller, cfg, &zc)
-		filename, err := f.GetFullSitemap(context.Background())
+		filename, _, err := f.GetFullSitemap(context.Background())
 
 		Convey("Generator should return correct error", func() {
 			So(err.Error(), ShouldContainSubstring, "failed to start scroll")
@@ -61,7 +61,7 @@ func TestFetcher(t *testing.T) {
 		scroller := sitemap.NewElasticScroll(esMock, cfg)
 
 		f := sitemap.NewElasticFetcher(scroller, cfg, &zc)
-		filenames, err := f.GetFullSitemap(context.Background())
+		filenames, _, err := f.GetFullSitemap(context.Background())
 		defer func() {
 			for _, fl := range filenames {
 				os.Remove(fl)
@@ -94,6 +94,7 @@ func TestFetcher(t *testing.T) {
 					{
 						"_scroll_id": "scroll_id_1",
 						"hits": {
+							"total": {"value": 2, "relation": "eq"},
 							"hits": [
 								{
 									"_source": {
@@ -128,7 +129,7 @@ func TestFetcher(t *testing.T) {
 		scroller := sitemap.NewElasticScroll(esMock, cfg)
 
 		f := sitemap.NewElasticFetcher(scroller, cfg, &zc)
-		filenames, err := f.GetFullSitemap(context.Background())
+		filenames, searchHits, err := f.GetFullSitemap(context.Background())
 		defer func() {
 			for _, fl := range filenames {
 				os.Remove(fl)
@@ -141,6 +142,9 @@ func TestFetcher(t *testing.T) {
 		Convey("Correct scroll ID should be passed", func() {
 			So(receivedScrollID, ShouldEqual, "scroll_id_1")
 		})
+		Convey("Fetcher should return the number of documents in the index", func() {
+			So(searchHits, ShouldEqual, 2)
+		})
 		Convey("Temporary sitemap file should be created and available", func() {
 			So(filenames[config.English], ShouldContainSubstring, "sitemap")
 			_, err := os.Stat(filenames[config.English])
@@ -198,7 +202,7 @@ func TestFetcher(t *testing.T) {
 
 		scroller := sitemap.NewElasticScroll(esMock, cfg)
 		f := sitemap.NewElasticFetcher(scroller, cfg, &zc)
-		filenames, err := f.GetFullSitemap(context.Background())
+		filenames, _, err := f.GetFullSitemap(context.Background())
 		defer func() {
 			for _, fl := range filenames {
 				os.Remove(fl)
@@ -270,7 +274,7 @@ func TestFetcher(t *testing.T) {
 		scroller := sitemap.NewElasticScroll(esMock, cfg)
 
 		f := sitemap.NewElasticFetcher(scroller, cfg, &zc)
-		filename, err := f.GetFullSitemap(context.Background())
+		filename, _, err := f.GetFullSitemap(context.Background())
 
 		Convey("Generator should return correct error", func() {
 			So(err.Error(), ShouldContainSubstring, "failed to get scroll")
@@ -355,7 +359,7 @@ func TestFetcher(t *testing.T) {
 		scroller := sitemap.NewElasticScroll(esMock, cfg)
 
 		f := sitemap.NewElasticFetcher(scroller, cfg, &zc)
-		filenames, err := f.GetFullSitemap(context.Background())
+		filenames, _, err := f.GetFullSitemap(context.Background())
 		defer func() {
 			for _, fl := range filenames {
 				os.Remove(fl)
@@ -480,7 +484,7 @@ func TestFetcher(t *testing.T) {
 		scroller := sitemap.NewElasticScroll(esMock, cfg)
 
 		f := sitemap.NewElasticFetcher(scroller, cfg, &zcWithWelsh)
-		filenames, err := f.GetFullSitemap(context.Background())
+		filenames, _, err := f.GetFullSitemap(context.Background())
 		defer func() {
 			for _, fl := range filenames {
 				os.Remove(fl)
@@ -0,0 +1,231 @@
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// generationsDir is the directory, next to each full sitemap, holding its generations
+const generationsDir = "sitemap-generations"
+
+// generationIDFormat formats the creation time of a generation into its ID, which sorts chronologically
+const generationIDFormat = "20060102T150405.000Z"
+
+// FileResolver returns the names in the store of the live full sitemaps
+type FileResolver interface {
+	LiveFiles() (Files, error)
+}
+
+// LiveFiles returns the files themselves, for full sitemaps that are overwritten in place
+func (f Files) LiveFiles() (Files, error) {
+	return f, nil
+}
+
+// Manifest lists the kept generations of the full sitemaps, newest first, and points to the live one
+type Manifest struct {
+	Current     string       `json:"current"`
+	Generations []Generation `json:"generations"`
+}
+
+// Generation is a set of full sitemaps, one per language, generated together
+type Generation struct {
+	ID        string    `json:"id"`
+	Created   time.Time `json:"created"`
+	Files     Files     `json:"files"`
+	URLCounts URLCounts `json:"url_counts,omitempty"`
+}
+
+// Generations publishes each full sitemap generation under its own prefix in the store. The live
+// generation is only switched, by rewriting the manifest, once every language has been saved, and
+// the last generations are kept so that the live sitemaps can be rolled back.
+type Generations struct {
+	store    FileStore
+	manifest string
+	files    Files
+	keep     int
+}
+
+// NewGenerations returns the generations of the given full sitemap files, listed in the manifest file
+// of store. The keep most recent generations are kept, or all of them if keep is zero. The files
+// themselves are live until a first generation is published.
+func NewGenerations(store FileStore, manifest string, files Files, keep int) *Generations {
+	return &Generations{
+		store:    store,
+		manifest: manifest,
+		files:    files,
+		keep:     keep,
+	}
+}
+
+// New returns a generation created at t, with the names of its files in the store
+func (g *Generations) New(t time.Time) *Generation {
+	id := t.UTC().Format(generationIDFormat)
+	files := Files{}
+	for lang, name := range g.files {
+		files[lang] = path.Join(path.Dir(name), generationsDir, id, path.Base(name))
+	}
+	return &Generation{
+		ID:      id,
+		Created: t.UTC(),
+		Files:   files,
+	}
+}
+
+// LiveFiles returns the files of the live generation
+func (g *Generations) LiveFiles() (Files, error) {
+	live, err := g.Live()
+	if err != nil {
+		return nil, err
+	}
+	if live != nil {
+		return live.Files, nil
+	}
+	return g.files, nil
+}
+
+// Live returns the live generation, or nil if no generation has been published yet
+func (g *Generations) Live() (*Generation, error) {
+	manifest, _, err := g.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Find(manifest.Current), nil
+}
+
+// Manifest returns the current manifest and its version, which are empty if no generation has been published yet
+func (g *Generations) Manifest() (*Manifest, string, error) {
+	manifest := &Manifest{}
+	body, version, err := g.store.GetFileWithVersion(g.manifest)
+	if errors.Is(err, ErrFileNotFound) {
+		return manifest, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get sitemap manifest: %w", err)
+	}
+	defer body.Close()
+
+	if err = json.NewDecoder(body).Decode(manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to decode sitemap manifest: %w", err)
+	}
+	return manifest, version, nil
+}
+
+// Publish makes gen the live generation and removes the generations that are no longer kept
+func (g *Generations) Publish(ctx context.Context, gen *Generation) error {
+	var removed []Generation
+	err := g.update(ctx, func(manifest *Manifest) error {
+		manifest.Current = gen.ID
+		manifest.Generations = append([]Generation{*gen}, manifest.Generations...)
+		removed = nil
+		if g.keep > 0 && len(manifest.Generations) > g.keep {
+			removed = manifest.Generations[g.keep:]
+			manifest.Generations = manifest.Generations[:g.keep]
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Info(ctx, "published sitemap generation", log.Data{"generation": gen.ID, "files": gen.Files})
+
+	for i := range removed {
+		g.Discard(ctx, &removed[i])
+	}
+	return nil
+}
+
+// Rollback makes the generation with the given ID live again, or the one published before the
+// live generation if id is empty, and returns it
+func (g *Generations) Rollback(ctx context.Context, id string) (*Generation, error) {
+	var live Generation
+	err := g.update(ctx, func(manifest *Manifest) error {
+		target := id
+		if target == "" {
+			target = manifest.Previous()
+			if target == "" {
+				return errors.New("no previous sitemap generation to roll back to")
+			}
+		}
+		gen := manifest.Find(target)
+		if gen == nil {
+			return fmt.Errorf("sitemap generation %s not found", target)
+		}
+		manifest.Current = gen.ID
+		live = *gen
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "rolled back sitemap generation", log.Data{"generation": live.ID, "files": live.Files})
+	return &live, nil
+}
+
+// Discard deletes the files of a generation that is not live, logging any failure
+func (g *Generations) Discard(ctx context.Context, gen *Generation) {
+	for _, name := range gen.Files {
+		if err := g.store.DeleteFile(name); err != nil {
+			log.Error(ctx, "failed to delete sitemap generation file", err, log.Data{"generation": gen.ID, "file": name})
+		}
+	}
+}
+
+// update changes the manifest with change, starting again from the new manifest if it is
+// changed by another process in the meantime
+func (g *Generations) update(ctx context.Context, change func(manifest *Manifest) error) error {
+	var err error
+	for attempt := 1; attempt <= DefaultUpdateAttempts; attempt++ {
+		var (
+			manifest *Manifest
+			version  string
+		)
+		manifest, version, err = g.Manifest()
+		if err != nil {
+			return err
+		}
+		if err = change(manifest); err != nil {
+			return err
+		}
+		var b []byte
+		b, err = json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode sitemap manifest: %w", err)
+		}
+		err = g.store.SaveFileIfVersion(g.manifest, bytes.NewReader(b), version)
+		if !errors.Is(err, ErrVersionConflict) {
+			break
+		}
+		log.Info(ctx, "sitemap manifest changed while being updated, retrying", log.Data{"attempt": attempt})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save sitemap manifest: %w", err)
+	}
+	return nil
+}
+
+// Find returns the generation with the given ID, or nil if it is not kept
+func (m *Manifest) Find(id string) *Generation {
+	for i := range m.Generations {
+		if m.Generations[i].ID == id {
+			return &m.Generations[i]
+		}
+	}
+	return nil
+}
+
+// Previous returns the ID of the generation published before the live one, or an empty string if there is none
+func (m *Manifest) Previous() string {
+	for i := range m.Generations {
+		if m.Generations[i].ID == m.Current && i+1 < len(m.Generations) {
+			return m.Generations[i+1].ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package sitemap_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGenerations(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given the generations of full sitemaps in a local store", t, func() {
+		dir := t.TempDir()
+		store := &sitemap.LocalStore{}
+		files := sitemap.Files{
+			config.English: filepath.Join(dir, "sitemap-en.xml"),
+			config.Welsh:   filepath.Join(dir, "sitemap-cy.xml"),
+		}
+		generations := sitemap.NewGenerations(store, filepath.Join(dir, "manifest.json"), files, 2)
+
+		publish := func(t time.Time) *sitemap.Generation {
+			gen := generations.New(t)
+			for _, name := range gen.Files {
+				So(store.SaveFile(name, strings.NewReader(gen.ID)), ShouldBeNil)
+			}
+			So(generations.Publish(ctx, gen), ShouldBeNil)
+			return gen
+		}
+		first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		Convey("When no generation has been published", func() {
+			live, err := generations.LiveFiles()
+
+			Convey("Then the full sitemap files are live", func() {
+				So(err, ShouldBeNil)
+				So(live, ShouldResemble, files)
+			})
+			Convey("Then there is nothing to roll back to", func() {
+				_, err := generations.Rollback(ctx, "")
+				So(err.Error(), ShouldContainSubstring, "no previous sitemap generation")
+			})
+		})
+
+		Convey("When a generation is created", func() {
+			gen := generations.New(first)
+
+			Convey("Then its files are under its own prefix", func() {
+				So(gen.ID, ShouldEqual, "20240102T030405.000Z")
+				So(gen.Files[config.English], ShouldEqual, filepath.Join(dir, "sitemap-generations", gen.ID, "sitemap-en.xml"))
+				So(gen.Files[config.Welsh], ShouldEqual, filepath.Join(dir, "sitemap-generations", gen.ID, "sitemap-cy.xml"))
+			})
+		})
+
+		Convey("When several generations are published", func() {
+			gen1 := publish(first)
+			gen2 := publish(first.Add(time.Hour))
+			gen3 := publish(first.Add(2 * time.Hour))
+
+			Convey("Then the last one is live", func() {
+				live, err := generations.LiveFiles()
+				So(err, ShouldBeNil)
+				So(live, ShouldResemble, gen3.Files)
+			})
+			Convey("Then only the last ones are kept", func() {
+				manifest, _, err := generations.Manifest()
+				So(err, ShouldBeNil)
+				So(manifest.Current, ShouldEqual, gen3.ID)
+				So(manifest.Generations, ShouldHaveLength, 2)
+				So(manifest.Generations[0].ID, ShouldEqual, gen3.ID)
+				So(manifest.Generations[1].ID, ShouldEqual, gen2.ID)
+			})
+			Convey("Then the files of the generations no longer kept are deleted", func() {
+				_, err := os.Stat(gen1.Files[config.English])
+				So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+				_, err = os.Stat(gen2.Files[config.English])
+				So(err, ShouldBeNil)
+			})
+
+			Convey("Then they can be rolled back to the previous generation", func() {
+				gen, err := generations.Rollback(ctx, "")
+				So(err, ShouldBeNil)
+				So(gen.ID, ShouldEqual, gen2.ID)
+				live, err := generations.LiveFiles()
+				So(err, ShouldBeNil)
+				So(live, ShouldResemble, gen2.Files)
+
+				Convey("And forward again to a given generation", func() {
+					gen, err := generations.Rollback(ctx, gen3.ID)
+					So(err, ShouldBeNil)
+					So(gen.ID, ShouldEqual, gen3.ID)
+				})
+			})
+
+			Convey("Then they can not be rolled back to a generation that is no longer kept", func() {
+				_, err := generations.Rollback(ctx, gen1.ID)
+				So(err.Error(), ShouldContainSubstring, "sitemap generation "+gen1.ID+" not found")
+			})
+		})
+	})
+
+	Convey("Given the generations of full sitemaps in an s3 bucket", t, func() {
+		objects := map[string]string{}
+		s3Client := &mock.S3ClientMock{
+			BucketNameFunc: func() string { return "bucket" },
+			HeadFunc: func(key string) (*s3.HeadObjectOutput, error) {
+				if _, ok := objects[key]; !ok {
+					return nil, awserr.New("NotFound", "not found", nil)
+				}
+				return &s3.HeadObjectOutput{ETag: aws.String(`"` + objects[key] + `"`)}, nil
+			},
+			GetFunc: func(key string) (io.ReadCloser, *int64, error) {
+				return io.NopCloser(strings.NewReader(objects[key])), nil, nil
+			},
+			UploadFunc: func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+				b, err := io.ReadAll(input.Body)
+				objects[*input.Key] = string(b)
+				return &s3manager.UploadOutput{}, err
+			},
+			DeleteFunc: func(key string) error {
+				delete(objects, key)
+				return nil
+			},
+		}
+		store := sitemap.NewS3Store(s3Client)
+		generations := sitemap.NewGenerations(store, "manifest.json", sitemap.Files{config.English: "sitemap-en.xml"}, 1)
+
+		publish := func(t time.Time) *sitemap.Generation {
+			gen := generations.New(t)
+			for _, name := range gen.Files {
+				So(store.SaveFile(name, strings.NewReader(gen.ID)), ShouldBeNil)
+			}
+			So(generations.Publish(ctx, gen), ShouldBeNil)
+			return gen
+		}
+
+		Convey("When more generations than are kept are published", func() {
+			gen1 := publish(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+			gen2 := publish(time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC))
+
+			Convey("Then the objects of the generation no longer kept are deleted from the bucket", func() {
+				So(objects, ShouldNotContainKey, gen1.Files[config.English])
+				So(objects, ShouldContainKey, gen2.Files[config.English])
+				So(s3Client.DeleteCalls(), ShouldHaveLength, 1)
+				So(s3Client.DeleteCalls()[0].Key, ShouldEqual, gen1.Files[config.English])
+			})
+		})
+	})
+
+	Convey("Given a manifest that can not be read", t, func() {
+		store := &mock.FileStoreMock{
+			GetFileWithVersionFunc: func(name string) (io.ReadCloser, string, error) {
+				return nil, "", errors.New("s3 error")
+			},
+		}
+		generations := sitemap.NewGenerations(store, "manifest.json", sitemap.Files{config.English: "sitemap-en"}, 2)
+
+		Convey("When the live files are requested", func() {
+			_, err := generations.LiveFiles()
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "failed to get sitemap manifest")
+				So(err.Error(), ShouldContainSubstring, "s3 error")
+			})
+		})
+	})
+}
@@ -2,13 +2,17 @@ package sitemap
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/metrics"
 	"github.com/ONSdigital/log.go/v2/log"
 )
 
@@ -18,23 +22,50 @@ import (
 
 type Files map[config.Language]string
 
+var (
+	// ErrFileNotFound is returned when a file does not exist in the store
+	ErrFileNotFound = errors.New("file not found")
+	// ErrVersionConflict is returned when a file is saved while its version in the store is not the expected one
+	ErrVersionConflict = errors.New("file version conflict")
+	// ErrURLCountDropped is returned when a full sitemap is not published because it has too few URLs
+	// compared to the published one
+	ErrURLCountDropped = errors.New("full sitemap url count dropped")
+)
+
+// FileInfo holds the metadata of a file in the store
+type FileInfo struct {
+	Size    int64
+	ModTime time.Time
+	ETag    string
+}
+
 type FileStore interface {
 	SaveFile(name string, body io.Reader) error
 	GetFile(name string) (body io.ReadCloser, err error)
+	GetFileInfo(name string) (*FileInfo, error)
+	GetFileWithVersion(name string) (body io.ReadCloser, version string, err error)
+	SaveFileIfVersion(name string, body io.Reader, version string) error
 	CopyFile(src io.Reader, dest io.Writer) error
 	CreateFile(name string) (io.ReadWriteCloser, error)
 	DeleteFile(name string) error
 }
 
 type Fetcher interface {
-	GetFullSitemap(ctx context.Context) (Files, error)
+	GetFullSitemap(ctx context.Context) (files Files, searchHits int, err error)
 	HasWelshContent(ctx context.Context, path string) bool
 	URLVersions(ctx context.Context, path string, lastmod string) (en, cy *URL)
 	URLVersion(ctx context.Context, path, lastmod, lang string) *URL
 	GetPageInfo(ctx context.Context, path string) (*PageInfo, error)
 }
 type Adder interface {
-	Add(oldSitemap io.Reader, url *URL) (file string, size int, err error)
+	Add(ctx context.Context, oldSitemap io.Reader, url *URL) (file string, size int, err error)
+}
+
+// FullSitemapResult describes the outcome of a full sitemap generation
+type FullSitemapResult struct {
+	URLCounts   URLCounts
+	SearchHits  int
+	BlockedURLs URLCounts // urls disallowed by the robots rules, when they are checked
 }
 
 type Generator struct {
@@ -42,9 +73,15 @@ type Generator struct {
 	adder                 Adder
 	store                 FileStore
 	publishingSitemapMx   sync.Mutex
+	maxAttempts           int
 	maxSize               int
 	maxSizeCallback       func()
+	maxURLDropPercent     float64
+	force                 bool
 	fullSitemapFiles      Files
+	static                StaticSource
+	robots                *RobotsCheck
+	generations           *Generations
 	publishingSitemapFile string
 }
 type GeneratorOptions func(*Generator) *Generator
@@ -53,6 +90,7 @@ func NewGenerator(opts ...GeneratorOptions) *Generator {
 	g := &Generator{
 		fullSitemapFiles:      Files{config.English: "sitemap.xml"},
 		publishingSitemapFile: "publishing-sitemap.xml",
+		maxAttempts:           DefaultUpdateAttempts,
 	}
 	for _, opt := range opts {
 		g = opt(g)
@@ -88,6 +126,50 @@ func WithFullSitemapFiles(f Files) GeneratorOptions {
 	}
 }
 
+// WithStaticPages merges static pages into every full sitemap. When a page is also in the search
+// index, the entry with the newer lastmod is kept.
+func WithStaticPages(s StaticSource) GeneratorOptions {
+	return func(g *Generator) *Generator {
+		g.static = s
+		return g
+	}
+}
+
+// WithRobotsCheck checks the urls of the full and publishing sitemaps against the robots rules, dropping or warning
+// about the disallowed ones. A nil check disables it.
+func WithRobotsCheck(c *RobotsCheck) GeneratorOptions {
+	return func(g *Generator) *Generator {
+		g.robots = c
+		return g
+	}
+}
+
+// WithGenerations publishes each full sitemap generation under its own prefix, only making it live
+// once all its languages have been saved, instead of overwriting the full sitemap files in place
+func WithGenerations(gens *Generations) GeneratorOptions {
+	return func(g *Generator) *Generator {
+		g.generations = gens
+		return g
+	}
+}
+
+// WithMaxURLDropPercent refuses to publish a full sitemap having lost more than percent of the URLs of
+// the published one, as happens when the search index is empty or being rebuilt. Zero disables the check.
+func WithMaxURLDropPercent(percent float64) GeneratorOptions {
+	return func(g *Generator) *Generator {
+		g.maxURLDropPercent = percent
+		return g
+	}
+}
+
+// WithForce publishes the full sitemaps whatever their number of URLs
+func WithForce(force bool) GeneratorOptions {
+	return func(g *Generator) *Generator {
+		g.force = force
+		return g
+	}
+}
+
 func WithPublishingSitemapFile(f string) GeneratorOptions {
 	return func(g *Generator) *Generator {
 		g.publishingSitemapFile = f
@@ -99,35 +181,47 @@ func WithPublishingSitemapMaxSize(size int, callback func()) GeneratorOptions {
 	return func(g *Generator) *Generator {
 		g.maxSize = size
 		g.maxSizeCallback = callback
+		metrics.PublishingSitemapMaxSize.Set(float64(size))
+		return g
+	}
+}
+
+// WithPublishingSitemapMaxAttempts sets the number of times a URL is added to the publishing sitemap
+// before giving up, when the sitemap keeps being changed concurrently by other processes
+func WithPublishingSitemapMaxAttempts(attempts int) GeneratorOptions {
+	return func(g *Generator) *Generator {
+		g.maxAttempts = attempts
 		return g
 	}
 }
 
+// MakePublishingSitemap adds url to the publishing sitemap. The sitemap may be updated by several
+// processes at once, so it is only saved if it has not changed since it was read, and the update is
+// retried from the new sitemap otherwise.
 func (g *Generator) MakePublishingSitemap(ctx context.Context, url URL) error {
 	g.publishingSitemapMx.Lock()
 	defer g.publishingSitemapMx.Unlock()
 
-	currentSitemap, err := g.store.GetFile(g.publishingSitemapFile)
-	if err != nil {
-		return fmt.Errorf("failed to get current sitemap: %w", err)
-	}
-	defer func() {
-		closeErr := currentSitemap.Close()
-		if closeErr != nil {
-			log.Error(ctx, "failed to close current sitemap file", closeErr)
-		}
-	}()
-
-	urlEn, _ := g.fetcher.URLVersions(
+	urlEn, urlCy := g.fetcher.URLVersions(
 		ctx,
 		url.Loc,
 		url.Lastmod,
 	)
 
-	size, err := g.AppendURL(ctx, currentSitemap, urlEn, g.publishingSitemapFile)
+	if urlEn != nil {
+		page := g.robots.FilterPage(ctx, "publishing", map[config.Language]*URL{config.English: urlEn, config.Welsh: urlCy})
+		if urlEn = page[config.English]; urlEn == nil {
+			return nil
+		}
+	}
+
+	size, err := UpdateFile(ctx, g.store, g.publishingSitemapFile, g.maxAttempts, func(current io.Reader) (string, int, error) {
+		return g.addURL(ctx, current, urlEn)
+	})
 	if err != nil {
 		return err
 	}
+	metrics.PublishingSitemapSize.Set(float64(size))
 
 	if g.maxSize > 0 && size > g.maxSize {
 		go g.maxSizeCallback()
@@ -141,52 +235,103 @@ func (g *Generator) TruncatePublishingSitemap(ctx context.Context) error {
 	defer g.publishingSitemapMx.Unlock()
 
 	_, err := g.AppendURL(ctx, io.NopCloser(strings.NewReader("")), nil, g.publishingSitemapFile)
-	return err
+	if err != nil {
+		return err
+	}
+	metrics.PublishingSitemapSize.Set(0)
+	return nil
 }
 
-func (g *Generator) AppendURL(ctx context.Context, sitemap io.ReadCloser, url *URL, destination string) (int, error) {
-	fileName, size, err := g.adder.Add(sitemap, url)
+// publishingSitemapVersion returns the version of the publishing sitemap, empty if there is none
+func (g *Generator) publishingSitemapVersion(ctx context.Context) (string, error) {
+	g.publishingSitemapMx.Lock()
+	defer g.publishingSitemapMx.Unlock()
+
+	currentSitemap, version, err := g.store.GetFileWithVersion(g.publishingSitemapFile)
+	if errors.Is(err, ErrFileNotFound) {
+		return "", nil
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to add to sitemap: %w", err)
+		return "", err
 	}
-	defer func() {
-		err = os.Remove(fileName)
-		if err != nil {
-			log.Error(ctx, "failed to remove temporary sitemap file", err, log.Data{"filename": fileName})
-			return
-		}
-		log.Info(ctx, "removed temporary sitemap file", log.Data{"filename": fileName})
-	}()
+	if err = currentSitemap.Close(); err != nil {
+		log.Error(ctx, "failed to close current sitemap file", err)
+	}
+	return version, nil
+}
 
-	file, err := os.Open(fileName)
+// truncatePublishingSitemap empties the publishing sitemap if it is still at the given version. If URLs have been
+// added to it since, which the full sitemap may not include, it is kept as it is until the next full sitemap.
+func (g *Generator) truncatePublishingSitemap(ctx context.Context, version string) error {
+	g.publishingSitemapMx.Lock()
+	defer g.publishingSitemapMx.Unlock()
+
+	_, err := g.appendURL(ctx, io.NopCloser(strings.NewReader("")), nil, func(file io.Reader) error {
+		return g.store.SaveFileIfVersion(g.publishingSitemapFile, file, version)
+	})
+	if errors.Is(err, ErrVersionConflict) {
+		log.Info(ctx, "publishing sitemap changed during the full sitemap generation, keeping it")
+		return nil
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to open publishing sitemap: %w", err)
+		return err
 	}
-	defer func() {
-		closeErr := file.Close()
-		if closeErr != nil {
-			log.Error(ctx, "failed to close publishing sitemap file", closeErr)
-		}
-	}()
+	metrics.PublishingSitemapSize.Set(0)
+	return nil
+}
 
-	err = g.store.SaveFile(destination, file)
+func (g *Generator) AppendURL(ctx context.Context, sitemap io.ReadCloser, url *URL, destination string) (int, error) {
+	return g.appendURL(ctx, sitemap, url, func(file io.Reader) error {
+		return g.store.SaveFile(destination, file)
+	})
+}
+
+// appendURL adds url to sitemap and saves the result with save
+func (g *Generator) appendURL(ctx context.Context, sitemap io.Reader, url *URL, save func(file io.Reader) error) (int, error) {
+	fileName, size, err := g.addURL(ctx, sitemap, url)
 	if err != nil {
-		return 0, fmt.Errorf("failed to save publishing sitemap file: %w", err)
+		return 0, err
+	}
+	if err = saveTempFile(ctx, fileName, save); err != nil {
+		return 0, err
 	}
 	return size, nil
 }
 
-func (g *Generator) MakeFullSitemap(ctx context.Context) error {
-	// first truncate the publishing sitemap as all URLs that are
-	// currently there will be automatically included in the full sitemap
-	err := g.TruncatePublishingSitemap(ctx)
+// addURL writes sitemap with url added to a temporary file
+func (g *Generator) addURL(ctx context.Context, sitemap io.Reader, url *URL) (string, int, error) {
+	fileName, size, err := g.adder.Add(ctx, sitemap, url)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to add to sitemap: %w", err)
+	}
+	return fileName, size, nil
+}
+
+func (g *Generator) MakeFullSitemap(ctx context.Context) (*FullSitemapResult, error) {
+	start := time.Now()
+	result, err := g.makeFullSitemap(ctx)
+	metrics.FullSitemapDuration.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(start).Seconds())
 	if err != nil {
-		return fmt.Errorf("failed to truncate publishing sitemap: %w", err)
+		return nil, err
+	}
+	for lang, count := range result.URLCounts {
+		metrics.FullSitemapURLs.WithLabelValues(lang.String()).Set(float64(count))
+		metrics.FullSitemapURLsEmitted.WithLabelValues(lang.String()).Add(float64(count))
 	}
+	return result, nil
+}
 
-	sitemaps, err := g.fetcher.GetFullSitemap(ctx)
+func (g *Generator) makeFullSitemap(ctx context.Context) (*FullSitemapResult, error) {
+	// all URLs that are currently in the publishing sitemap will be automatically included
+	// in the full sitemap, so it is truncated once the full sitemap is published
+	publishingVersion, err := g.publishingSitemapVersion(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch sitemap: %w", err)
+		return nil, fmt.Errorf("failed to get publishing sitemap: %w", err)
+	}
+
+	sitemaps, searchHits, err := g.fetcher.GetFullSitemap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
 	}
 	defer func() {
 		for _, fl := range sitemaps {
@@ -199,17 +344,150 @@ func (g *Generator) MakeFullSitemap(ctx context.Context) error {
 		}
 	}()
 
+	if g.static != nil {
+		if err = g.mergeStaticPages(ctx, sitemaps); err != nil {
+			return nil, fmt.Errorf("failed to merge static pages: %w", err)
+		}
+	}
+
+	var blocked URLCounts
+	if g.robots != nil {
+		if blocked, err = g.checkRobots(ctx, sitemaps); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := URLCounts{}
 	for lang, fl := range sitemaps {
-		file, err := os.Open(fl)
+		count, err := countFileURLs(fl)
 		if err != nil {
-			return fmt.Errorf("failed to open sitemap: %w", err)
+			return nil, err
+		}
+		counts[lang] = count
+	}
+	if err = g.checkURLCounts(ctx, counts); err != nil {
+		return nil, err
+	}
+
+	destinations := g.fullSitemapFiles
+	var generation *Generation
+	if g.generations != nil {
+		generation = g.generations.New(time.Now())
+		destinations = generation.Files
+	}
+
+	for lang, fl := range sitemaps {
+		err = g.saveFullSitemap(fl, destinations[lang])
+		if err != nil {
+			if generation != nil {
+				g.generations.Discard(ctx, generation)
+			}
+			return nil, err
+		}
+	}
+
+	if generation != nil {
+		generation.URLCounts = counts
+		if err = g.generations.Publish(ctx, generation); err != nil {
+			g.generations.Discard(ctx, generation)
+			return nil, fmt.Errorf("failed to publish sitemap generation: %w", err)
+		}
+	}
+	if err = g.truncatePublishingSitemap(ctx, publishingVersion); err != nil {
+		log.Error(ctx, "failed to truncate publishing sitemap", err)
+	}
+	log.Info(ctx, "full sitemap generated", log.Data{"url_counts": counts, "search_hits": searchHits, "blocked_urls": blocked})
+	return &FullSitemapResult{URLCounts: counts, SearchHits: searchHits, BlockedURLs: blocked}, nil
+}
+
+// checkURLCounts fails with ErrURLCountDropped if a new full sitemap has lost more than the maximum
+// percentage of the URLs of the published one, unless publication is forced
+func (g *Generator) checkURLCounts(ctx context.Context, counts URLCounts) error {
+	if g.maxURLDropPercent <= 0 {
+		return nil
+	}
+	published, err := g.publishedURLCounts()
+	if err != nil {
+		return fmt.Errorf("failed to count published sitemap urls: %w", err)
+	}
+
+	var drops []string
+	for lang, count := range counts {
+		if previous, ok := published[lang]; ok && dropPercent(previous, count) > g.maxURLDropPercent {
+			drops = append(drops, fmt.Sprintf("%s full sitemap dropped from %d to %d urls", lang, previous, count))
+		}
+	}
+	if len(drops) == 0 {
+		return nil
+	}
+	sort.Strings(drops)
+	logData := log.Data{"published": published, "generated": counts, "max_drop_percent": g.maxURLDropPercent}
+	if g.force {
+		log.Warn(ctx, "publishing full sitemap despite url count drop as publication is forced", logData)
+		return nil
+	}
+	metrics.FullSitemapPublicationsRefused.Inc()
+	err = fmt.Errorf("%w: %s", ErrURLCountDropped, strings.Join(drops, ", "))
+	log.Error(ctx, "refusing to publish full sitemap - check the search index and force publication if the drop is expected", err, logData)
+	return err
+}
+
+// publishedURLCounts returns the number of URLs in each published full sitemap, leaving out the
+// languages with no published sitemap. A published sitemap that can not be read fails the count.
+func (g *Generator) publishedURLCounts() (URLCounts, error) {
+	files := g.fullSitemapFiles
+	if g.generations != nil {
+		var err error
+		if files, err = g.generations.LiveFiles(); err != nil {
+			return nil, err
 		}
+	}
 
-		err = g.store.SaveFile(g.fullSitemapFiles[lang], file)
-		file.Close()
+	counts := URLCounts{}
+	for lang, name := range files {
+		body, _, err := g.store.GetFileWithVersion(name)
+		if errors.Is(err, ErrFileNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		count, err := CountURLs(body)
+		body.Close()
 		if err != nil {
-			return fmt.Errorf("failed to save sitemap file: %w", err)
+			return nil, err
 		}
+		counts[lang] = count
+	}
+	return counts, nil
+}
+
+// countFileURLs returns the number of URLs in a generated sitemap file
+func countFileURLs(fileName string) (int, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open sitemap: %w", err)
+	}
+	defer file.Close()
+
+	count, err := CountURLs(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count sitemap urls: %w", err)
+	}
+	return count, nil
+}
+
+// saveFullSitemap saves a generated sitemap file to the store
+func (g *Generator) saveFullSitemap(fileName, destination string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open sitemap: %w", err)
+	}
+	defer file.Close()
+
+	err = g.store.SaveFile(destination, file)
+	if err != nil {
+		return fmt.Errorf("failed to save sitemap file: %w", err)
 	}
 	return nil
 }
@@ -1,12 +1,17 @@
 package sitemap_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-sitemap/config"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
@@ -22,12 +27,13 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 		return &sitemap.URL{Loc: path, Lastmod: lastmod}, nil
 	}
 	Convey("When getting current sitemap returns an error", t, func() {
-		store.GetFileFunc = func(name string) (io.ReadCloser, error) {
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
 			So(name, ShouldEqual, "sitemap.xml")
-			return nil, errors.New("get file error")
+			return nil, "", errors.New("get file error")
 		}
 
 		g := sitemap.NewGenerator(
+			sitemap.WithFetcher(fetcher),
 			sitemap.WithFileStore(store),
 			sitemap.WithPublishingSitemapFile("sitemap.xml"),
 		)
@@ -40,12 +46,13 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 		})
 	})
 	Convey("When getting current sitemap returns an error", t, func() {
-		store.GetFileFunc = func(name string) (io.ReadCloser, error) {
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
 			So(name, ShouldEqual, "sitemap.xml")
-			return nil, errors.New("get file error")
+			return nil, "", errors.New("get file error")
 		}
 
 		g := sitemap.NewGenerator(
+			sitemap.WithFetcher(fetcher),
 			sitemap.WithFileStore(store),
 			sitemap.WithPublishingSitemapFile("sitemap.xml"),
 		)
@@ -57,10 +64,10 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 		})
 	})
 	Convey("When adder returns an error", t, func() {
-		store.GetFileFunc = func(name string) (io.ReadCloser, error) {
-			return io.NopCloser(strings.NewReader("")), nil
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
 		}
-		adder.AddFunc = func(oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
+		adder.AddFunc = func(ctx context.Context, oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
 			return "", 0, errors.New("adder error")
 		}
 
@@ -78,10 +85,10 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 	})
 
 	Convey("When adder returns a non-existent file", t, func() {
-		store.GetFileFunc = func(name string) (io.ReadCloser, error) {
-			return io.NopCloser(strings.NewReader("")), nil
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
 		}
-		adder.AddFunc = func(oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
+		adder.AddFunc = func(ctx context.Context, oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
 			return "filename", 0, nil
 		}
 		g := sitemap.NewGenerator(
@@ -99,11 +106,11 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 
 	Convey("When adder returns a file with known content", t, func() {
 		store := &mock.FileStoreMock{}
-		store.GetFileFunc = func(name string) (io.ReadCloser, error) {
-			return io.NopCloser(strings.NewReader("")), nil
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
 		}
 		var tempFile string
-		adder.AddFunc = func(oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
+		adder.AddFunc = func(ctx context.Context, oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
 			So(url, ShouldResemble, &sitemap.URL{Loc: "a", Lastmod: "b"})
 			file, err := os.CreateTemp("", "sitemap-incr")
 			So(err, ShouldBeNil)
@@ -113,8 +120,9 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 			return tempFile, 1, nil
 		}
 		var uploadedFile string
-		store.SaveFileFunc = func(name string, reader io.Reader) error {
+		store.SaveFileIfVersionFunc = func(name string, reader io.Reader, version string) error {
 			So(name, ShouldEqual, "sitemap.xml")
+			So(version, ShouldEqual, `"v1"`)
 			body, err := io.ReadAll(reader)
 			So(err, ShouldBeNil)
 			uploadedFile = string(body)
@@ -133,7 +141,7 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 		Convey("Generator should call store", func() {
-			So(store.SaveFileCalls(), ShouldHaveLength, 1)
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 1)
 		})
 		Convey("Generator should pass correct file content to store", func() {
 			So(uploadedFile, ShouldEqual, "file content")
@@ -145,11 +153,11 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 	})
 	Convey("When save file returns with an error", t, func() {
 		store := &mock.FileStoreMock{}
-		store.GetFileFunc = func(name string) (io.ReadCloser, error) {
-			return io.NopCloser(strings.NewReader("")), nil
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
 		}
 		var tempFile string
-		adder.AddFunc = func(oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
+		adder.AddFunc = func(ctx context.Context, oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
 			file, err := os.CreateTemp("", "sitemap-incr")
 			So(err, ShouldBeNil)
 			_, err = file.WriteString("file content")
@@ -158,8 +166,9 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 			return tempFile, 1, nil
 		}
 		var uploadedFile string
-		store.SaveFileFunc = func(name string, reader io.Reader) error {
+		store.SaveFileIfVersionFunc = func(name string, reader io.Reader, version string) error {
 			So(name, ShouldEqual, "sitemap.xml")
+			So(version, ShouldEqual, `"v1"`)
 			body, err := io.ReadAll(reader)
 			So(err, ShouldBeNil)
 			uploadedFile = string(body)
@@ -175,7 +184,7 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 		err := g.MakePublishingSitemap(context.Background(), sitemap.URL{})
 
 		Convey("Generator should call store", func() {
-			So(store.SaveFileCalls(), ShouldHaveLength, 1)
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 1)
 		})
 		Convey("Generator should pass correct file content to store", func() {
 			So(uploadedFile, ShouldEqual, "file content")
@@ -189,6 +198,153 @@ func TestGeneratePublishingSitemap(t *testing.T) {
 			So(err.Error(), ShouldContainSubstring, "no such file or directory")
 		})
 	})
+
+	Convey("When there is no publishing sitemap yet", t, func() {
+		store := &mock.FileStoreMock{}
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return nil, "", sitemap.ErrFileNotFound
+		}
+		store.SaveFileIfVersionFunc = func(name string, reader io.Reader, version string) error { return nil }
+
+		g := sitemap.NewGenerator(
+			sitemap.WithFetcher(fetcher),
+			sitemap.WithAdder(&sitemap.DefaultAdder{}),
+			sitemap.WithFileStore(store),
+		)
+		err := g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: "a"})
+
+		Convey("Generator should only save it if it has not been created since", func() {
+			So(err, ShouldBeNil)
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 1)
+			So(store.SaveFileIfVersionCalls()[0].Version, ShouldEqual, "")
+		})
+	})
+
+	Convey("When the publishing sitemap is changed by another process while it is updated", t, func() {
+		store := &mock.FileStoreMock{}
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			version := fmt.Sprintf(`"v%d"`, len(store.GetFileWithVersionCalls()))
+			return io.NopCloser(strings.NewReader("")), version, nil
+		}
+		store.SaveFileIfVersionFunc = func(name string, reader io.Reader, version string) error {
+			if version == `"v1"` {
+				return sitemap.ErrVersionConflict
+			}
+			return nil
+		}
+
+		g := sitemap.NewGenerator(
+			sitemap.WithFetcher(fetcher),
+			sitemap.WithAdder(&sitemap.DefaultAdder{}),
+			sitemap.WithFileStore(store),
+		)
+		err := g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: "a"})
+
+		Convey("Generator should add the url to the new publishing sitemap", func() {
+			So(err, ShouldBeNil)
+			So(store.GetFileWithVersionCalls(), ShouldHaveLength, 2)
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 2)
+			So(store.SaveFileIfVersionCalls()[1].Version, ShouldEqual, `"v2"`)
+		})
+	})
+
+	Convey("When the publishing sitemap keeps being changed by other processes", t, func() {
+		store := &mock.FileStoreMock{}
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
+		}
+		store.SaveFileIfVersionFunc = func(name string, reader io.Reader, version string) error {
+			return sitemap.ErrVersionConflict
+		}
+
+		g := sitemap.NewGenerator(
+			sitemap.WithFetcher(fetcher),
+			sitemap.WithAdder(&sitemap.DefaultAdder{}),
+			sitemap.WithFileStore(store),
+			sitemap.WithPublishingSitemapMaxAttempts(3),
+		)
+		err := g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: "a"})
+
+		Convey("Generator should give up after the maximum number of attempts", func() {
+			So(errors.Is(err, sitemap.ErrVersionConflict), ShouldBeTrue)
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 3)
+		})
+	})
+
+	Convey("When the publishing sitemap keeps being changed and the context is cancelled", t, func() {
+		store := &mock.FileStoreMock{}
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
+		}
+		store.SaveFileIfVersionFunc = func(name string, reader io.Reader, version string) error {
+			return sitemap.ErrVersionConflict
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		g := sitemap.NewGenerator(
+			sitemap.WithFetcher(fetcher),
+			sitemap.WithAdder(&sitemap.DefaultAdder{}),
+			sitemap.WithFileStore(store),
+			sitemap.WithPublishingSitemapMaxAttempts(1000),
+		)
+		err := g.MakePublishingSitemap(ctx, sitemap.URL{Loc: "a"})
+
+		Convey("Generator should stop retrying", func() {
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 1)
+		})
+	})
+}
+
+func TestGeneratePublishingSitemapConcurrently(t *testing.T) {
+	Convey("Given several processes sharing a local publishing sitemap", t, func() {
+		publishingSitemap := filepath.Join(t.TempDir(), "publishing-sitemap.xml")
+		fetcher := &mock.FetcherMock{}
+		fetcher.URLVersionsFunc = func(ctx context.Context, path, lastmod string) (*sitemap.URL, *sitemap.URL) {
+			return &sitemap.URL{Loc: path, Lastmod: lastmod}, nil
+		}
+		const processes, urlsPerProcess = 8, 20
+		generators := make([]*sitemap.Generator, processes)
+		for i := range generators {
+			// each process has its own generator and store
+			generators[i] = sitemap.NewGenerator(
+				sitemap.WithFetcher(fetcher),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithFileStore(&sitemap.LocalStore{}),
+				sitemap.WithPublishingSitemapFile(publishingSitemap),
+				sitemap.WithPublishingSitemapMaxAttempts(100),
+			)
+		}
+
+		Convey("When they all add urls at the same time", func() {
+			var wg sync.WaitGroup
+			errs := make(chan error, processes*urlsPerProcess)
+			for i, g := range generators {
+				wg.Add(1)
+				go func(i int, g *sitemap.Generator) {
+					defer wg.Done()
+					for j := 0; j < urlsPerProcess; j++ {
+						errs <- g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: fmt.Sprintf("/%d/%d", i, j)})
+					}
+				}(i, g)
+			}
+			wg.Wait()
+			close(errs)
+
+			Convey("Then no url is lost", func() {
+				for err := range errs {
+					So(err, ShouldBeNil)
+				}
+				file, err := os.Open(publishingSitemap)
+				So(err, ShouldBeNil)
+				defer file.Close()
+				count, err := sitemap.CountURLs(file)
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, processes*urlsPerProcess)
+			})
+		})
+	})
 }
 
 func TestGenerateFullSitemap(t *testing.T) {
@@ -196,20 +352,19 @@ func TestGenerateFullSitemap(t *testing.T) {
 	fetcher := &mock.FetcherMock{}
 
 	fetcher.HasWelshContentFunc = func(ctx context.Context, path string) bool { return false }
+	store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+		return nil, "", sitemap.ErrFileNotFound
+	}
 	Convey("When fetcher returns an error", t, func() {
-		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, error) {
-			return nil, errors.New("fetcher error")
-		}
-		store.SaveFileFunc = func(name string, reader io.Reader) error {
-			So(name, ShouldEqual, "publishing-sitemap.xml")
-			return nil
+		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
+			return nil, 0, errors.New("fetcher error")
 		}
 		g := sitemap.NewGenerator(
 			sitemap.WithFetcher(fetcher),
 			sitemap.WithFileStore(store),
 			sitemap.WithAdder(&sitemap.DefaultAdder{}),
 		)
-		err := g.MakeFullSitemap(context.Background())
+		_, err := g.MakeFullSitemap(context.Background())
 
 		Convey("Generator should return correct error", func() {
 			So(err.Error(), ShouldContainSubstring, "failed to fetch sitemap")
@@ -218,8 +373,8 @@ func TestGenerateFullSitemap(t *testing.T) {
 	})
 
 	Convey("When fetcher returns a non-existent file", t, func() {
-		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, error) {
-			return sitemap.Files{config.English: "filename"}, nil
+		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
+			return sitemap.Files{config.English: "filename"}, 0, nil
 		}
 
 		g := sitemap.NewGenerator(
@@ -227,7 +382,7 @@ func TestGenerateFullSitemap(t *testing.T) {
 			sitemap.WithFileStore(store),
 			sitemap.WithAdder(&sitemap.DefaultAdder{}),
 		)
-		err := g.MakeFullSitemap(context.Background())
+		_, err := g.MakeFullSitemap(context.Background())
 
 		Convey("Generator should return correct error", func() {
 			So(err.Error(), ShouldContainSubstring, "failed to open sitemap")
@@ -237,39 +392,45 @@ func TestGenerateFullSitemap(t *testing.T) {
 
 	Convey("When fetcher returns a file with known content", t, func() {
 		var tempFile string
-		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, error) {
+		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
 			file, err := os.CreateTemp("", "sitemap")
 			So(err, ShouldBeNil)
 			_, err = file.WriteString("file content")
 			So(err, ShouldBeNil)
 			tempFile = file.Name()
-			return sitemap.Files{config.English: tempFile}, nil
+			return sitemap.Files{config.English: tempFile}, 1, nil
 		}
 		var uploadedFile string
 		store := &mock.FileStoreMock{}
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
+		}
 		store.SaveFileFunc = func(name string, reader io.Reader) error {
 			body, err := io.ReadAll(reader)
 			So(err, ShouldBeNil)
 			uploadedFile = string(body)
 			return nil
 		}
+		store.SaveFileIfVersionFunc = func(name string, reader io.Reader, version string) error { return nil }
 
 		g := sitemap.NewGenerator(
 			sitemap.WithFetcher(fetcher),
 			sitemap.WithFileStore(store),
 			sitemap.WithAdder(&sitemap.DefaultAdder{}),
 		)
-		err := g.MakeFullSitemap(context.Background())
+		_, err := g.MakeFullSitemap(context.Background())
 
 		Convey("Generator should return with no error", func() {
 			So(err, ShouldBeNil)
 		})
 		Convey("Generator should call store", func() {
-			So(store.SaveFileCalls(), ShouldHaveLength, 2)
+			So(store.SaveFileCalls(), ShouldHaveLength, 1)
 		})
-		Convey("Generator should truncate publishing file and write the main file", func() {
-			So(store.SaveFileCalls()[0].Name, ShouldEqual, "publishing-sitemap.xml")
-			So(store.SaveFileCalls()[1].Name, ShouldEqual, "sitemap.xml")
+		Convey("Generator should write the main file and truncate the publishing file if it is unchanged", func() {
+			So(store.SaveFileCalls()[0].Name, ShouldEqual, "sitemap.xml")
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 1)
+			So(store.SaveFileIfVersionCalls()[0].Name, ShouldEqual, "publishing-sitemap.xml")
+			So(store.SaveFileIfVersionCalls()[0].Version, ShouldEqual, `"v1"`)
 		})
 		Convey("Generator should pass correct file content to store", func() {
 			So(uploadedFile, ShouldEqual, "file content")
@@ -282,18 +443,20 @@ func TestGenerateFullSitemap(t *testing.T) {
 
 	Convey("When save file returns with an error", t, func() {
 		var tempFile string
-		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, error) {
+		fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
 			file, err := os.CreateTemp("", "sitemap")
 			So(err, ShouldBeNil)
 			_, err = file.WriteString("file content")
 			So(err, ShouldBeNil)
 			tempFile = file.Name()
-			return sitemap.Files{config.English: tempFile}, nil
+			return sitemap.Files{config.English: tempFile}, 1, nil
 		}
 		store := &mock.FileStoreMock{}
-
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return io.NopCloser(strings.NewReader("")), `"v1"`, nil
+		}
 		store.SaveFileFunc = func(name string, reader io.Reader) error {
-			So(name, ShouldEqual, "publishing-sitemap.xml")
+			So(name, ShouldEqual, "sitemap.xml")
 			return errors.New("uploader error")
 		}
 
@@ -302,18 +465,527 @@ func TestGenerateFullSitemap(t *testing.T) {
 			sitemap.WithFileStore(store),
 			sitemap.WithAdder(&sitemap.DefaultAdder{}),
 		)
-		err := g.MakeFullSitemap(context.Background())
+		_, err := g.MakeFullSitemap(context.Background())
 
 		Convey("Generator should call store", func() {
 			So(store.SaveFileCalls(), ShouldHaveLength, 1)
 		})
 		Convey("Generator should return correct error", func() {
-			So(err.Error(), ShouldContainSubstring, "failed to save publishing sitemap file")
+			So(err.Error(), ShouldContainSubstring, "failed to save sitemap file")
 			So(err.Error(), ShouldContainSubstring, "uploader error")
 		})
+		Convey("Generator should not truncate the publishing file", func() {
+			So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 0)
+		})
 		Convey("Generator should remove the temporary file", func() {
 			_, err := os.Stat(tempFile)
 			So(err.Error(), ShouldContainSubstring, "no such file or directory")
 		})
 	})
 }
+
+func TestGenerateFullSitemapGenerations(t *testing.T) {
+	fetcher := &mock.FetcherMock{}
+	fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
+		files := sitemap.Files{}
+		for _, lang := range []config.Language{config.English, config.Welsh} {
+			file, err := os.CreateTemp("", "sitemap")
+			So(err, ShouldBeNil)
+			_, err = file.WriteString("<urlset><url><loc>" + lang.String() + "</loc></url></urlset>")
+			So(err, ShouldBeNil)
+			So(file.Close(), ShouldBeNil)
+			files[lang] = file.Name()
+		}
+		return files, 1, nil
+	}
+	fullSitemapFiles := sitemap.Files{config.English: "sitemap-en", config.Welsh: "sitemap-cy"}
+
+	Convey("Given full sitemaps published as generations", t, func() {
+		dir := t.TempDir()
+		files := sitemap.Files{
+			config.English: filepath.Join(dir, "sitemap-en.xml"),
+			config.Welsh:   filepath.Join(dir, "sitemap-cy.xml"),
+		}
+		generations := sitemap.NewGenerations(&sitemap.LocalStore{}, filepath.Join(dir, "manifest.json"), files, 2)
+
+		Convey("When a full sitemap is generated", func() {
+			g := sitemap.NewGenerator(
+				sitemap.WithFetcher(fetcher),
+				sitemap.WithFileStore(&sitemap.LocalStore{}),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithFullSitemapFiles(files),
+				sitemap.WithPublishingSitemapFile(filepath.Join(dir, "publishing-sitemap.xml")),
+				sitemap.WithGenerations(generations),
+			)
+			_, err := g.MakeFullSitemap(context.Background())
+			So(err, ShouldBeNil)
+
+			Convey("Then the new generation is live", func() {
+				manifest, _, err := generations.Manifest()
+				So(err, ShouldBeNil)
+				So(manifest.Generations, ShouldHaveLength, 1)
+				So(manifest.Current, ShouldEqual, manifest.Generations[0].ID)
+				So(manifest.Generations[0].URLCounts, ShouldResemble, sitemap.URLCounts{config.English: 1, config.Welsh: 1})
+
+				live, err := generations.LiveFiles()
+				So(err, ShouldBeNil)
+				content, err := os.ReadFile(live[config.Welsh])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "<loc>cy</loc>")
+			})
+			Convey("Then the full sitemap files are not overwritten in place", func() {
+				_, err := os.Stat(files[config.English])
+				So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a store failing to save the welsh sitemap of a generation", t, func() {
+		store := &mock.FileStoreMock{}
+		store.SaveFileFunc = func(name string, reader io.Reader) error {
+			if strings.HasSuffix(name, "sitemap-cy") {
+				return errors.New("uploader error")
+			}
+			return nil
+		}
+		store.DeleteFileFunc = func(name string) error { return nil }
+		store.GetFileWithVersionFunc = func(name string) (io.ReadCloser, string, error) {
+			return nil, "", sitemap.ErrFileNotFound
+		}
+		generations := sitemap.NewGenerations(store, "manifest.json", fullSitemapFiles, 2)
+
+		Convey("When a full sitemap is generated", func() {
+			g := sitemap.NewGenerator(
+				sitemap.WithFetcher(fetcher),
+				sitemap.WithFileStore(store),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithFullSitemapFiles(fullSitemapFiles),
+				sitemap.WithGenerations(generations),
+			)
+			_, err := g.MakeFullSitemap(context.Background())
+
+			Convey("Then the error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "uploader error")
+			})
+			Convey("Then the live generation is not switched", func() {
+				for _, call := range store.GetFileWithVersionCalls() {
+					So(call.Name, ShouldNotEqual, "manifest.json")
+				}
+				So(store.SaveFileIfVersionCalls(), ShouldHaveLength, 0)
+			})
+			Convey("Then the files of the failed generation are deleted", func() {
+				deleted := []string{}
+				for _, call := range store.DeleteFileCalls() {
+					deleted = append(deleted, filepath.Base(call.Name))
+				}
+				So(deleted, ShouldContain, "sitemap-en")
+				So(deleted, ShouldContain, "sitemap-cy")
+			})
+		})
+	})
+}
+
+func TestGenerateFullSitemapURLCountDrop(t *testing.T) {
+	fetcher := &mock.FetcherMock{}
+	fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
+		files := sitemap.Files{}
+		for _, lang := range []config.Language{config.English, config.Welsh} {
+			file, err := os.CreateTemp("", "sitemap")
+			So(err, ShouldBeNil)
+			_, err = file.WriteString("<urlset><url><loc>" + lang.String() + "</loc></url></urlset>")
+			So(err, ShouldBeNil)
+			So(file.Close(), ShouldBeNil)
+			files[lang] = file.Name()
+		}
+		return files, 1, nil
+	}
+	published := "<urlset><url><loc>a</loc></url><url><loc>b</loc></url><url><loc>c</loc></url><url><loc>d</loc></url></urlset>"
+
+	Convey("Given a published english sitemap with 4 urls and no welsh sitemap", t, func() {
+		dir := t.TempDir()
+		files := sitemap.Files{
+			config.English: filepath.Join(dir, "sitemap-en.xml"),
+			config.Welsh:   filepath.Join(dir, "sitemap-cy.xml"),
+		}
+		So(os.WriteFile(files[config.English], []byte(published), 0o600), ShouldBeNil)
+		publishingSitemap := filepath.Join(dir, "publishing-sitemap.xml")
+		publishing := "<urlset><url><loc>published-since</loc></url></urlset>"
+		So(os.WriteFile(publishingSitemap, []byte(publishing), 0o600), ShouldBeNil)
+		generator := func(opts ...sitemap.GeneratorOptions) *sitemap.Generator {
+			return sitemap.NewGenerator(append([]sitemap.GeneratorOptions{
+				sitemap.WithFetcher(fetcher),
+				sitemap.WithFileStore(&sitemap.LocalStore{}),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithFullSitemapFiles(files),
+				sitemap.WithPublishingSitemapFile(publishingSitemap),
+			}, opts...)...)
+		}
+
+		Convey("When a sitemap with 1 url is generated with a maximum drop of 50%", func() {
+			_, err := generator(sitemap.WithMaxURLDropPercent(50)).MakeFullSitemap(context.Background())
+
+			Convey("Then it is not published", func() {
+				So(errors.Is(err, sitemap.ErrURLCountDropped), ShouldBeTrue)
+				So(err.Error(), ShouldContainSubstring, "en full sitemap dropped from 4 to 1 urls")
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, published)
+				_, err = os.Stat(files[config.Welsh])
+				So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+			})
+			Convey("Then the publishing sitemap is left intact", func() {
+				content, err := os.ReadFile(publishingSitemap)
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, publishing)
+			})
+		})
+
+		Convey("When a sitemap with 1 url is generated with a maximum drop of 80%", func() {
+			result, err := generator(sitemap.WithMaxURLDropPercent(80)).MakeFullSitemap(context.Background())
+
+			Convey("Then it is published", func() {
+				So(err, ShouldBeNil)
+				So(result.URLCounts, ShouldResemble, sitemap.URLCounts{config.English: 1, config.Welsh: 1})
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "<loc>en</loc>")
+			})
+			Convey("Then the publishing sitemap is truncated", func() {
+				content, err := os.ReadFile(publishingSitemap)
+				So(err, ShouldBeNil)
+				So(string(content), ShouldNotContainSubstring, "published-since")
+			})
+		})
+
+		Convey("When a url is published while a sitemap is generated", func() {
+			publishingFetcher := &mock.FetcherMock{
+				GetFullSitemapFunc: func(ctx context.Context) (sitemap.Files, int, error) {
+					So(os.WriteFile(publishingSitemap, []byte("<urlset><url><loc>published-during</loc></url></urlset>"), 0o600), ShouldBeNil)
+					return fetcher.GetFullSitemap(ctx)
+				},
+			}
+			_, err := generator(sitemap.WithMaxURLDropPercent(80), sitemap.WithFetcher(publishingFetcher)).MakeFullSitemap(context.Background())
+
+			Convey("Then the publishing sitemap is kept for the next full sitemap", func() {
+				So(err, ShouldBeNil)
+				content, err := os.ReadFile(publishingSitemap)
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "published-during")
+			})
+		})
+
+		Convey("When a sitemap is generated while the published sitemap can not be read", func() {
+			localStore := &sitemap.LocalStore{}
+			store := &mock.FileStoreMock{
+				GetFileWithVersionFunc: func(name string) (io.ReadCloser, string, error) {
+					if name == files[config.English] {
+						return nil, "", errors.New("read failure")
+					}
+					return localStore.GetFileWithVersion(name)
+				},
+				SaveFileFunc:          localStore.SaveFile,
+				SaveFileIfVersionFunc: localStore.SaveFileIfVersion,
+			}
+			_, err := generator(sitemap.WithMaxURLDropPercent(50), sitemap.WithFileStore(store)).MakeFullSitemap(context.Background())
+
+			Convey("Then it is not published", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "failed to count published sitemap urls: read failure")
+				So(store.SaveFileCalls(), ShouldBeEmpty)
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, published)
+			})
+		})
+
+		Convey("When a sitemap with 1 url is generated with a maximum drop of 50% and publication is forced", func() {
+			_, err := generator(sitemap.WithMaxURLDropPercent(50), sitemap.WithForce(true)).MakeFullSitemap(context.Background())
+
+			Convey("Then it is published", func() {
+				So(err, ShouldBeNil)
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "<loc>en</loc>")
+			})
+		})
+	})
+
+	Convey("Given a published sitemap generation with 4 urls", t, func() {
+		dir := t.TempDir()
+		store := &sitemap.LocalStore{}
+		files := sitemap.Files{config.English: filepath.Join(dir, "sitemap-en.xml")}
+		generations := sitemap.NewGenerations(store, filepath.Join(dir, "manifest.json"), files, 2)
+		gen := generations.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		So(store.SaveFile(gen.Files[config.English], strings.NewReader(published)), ShouldBeNil)
+		So(generations.Publish(context.Background(), gen), ShouldBeNil)
+
+		Convey("When a sitemap with 1 url is generated with a maximum drop of 50%", func() {
+			_, err := sitemap.NewGenerator(
+				sitemap.WithFetcher(fetcher),
+				sitemap.WithFileStore(store),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithFullSitemapFiles(files),
+				sitemap.WithPublishingSitemapFile(filepath.Join(dir, "publishing-sitemap.xml")),
+				sitemap.WithGenerations(generations),
+				sitemap.WithMaxURLDropPercent(50),
+			).MakeFullSitemap(context.Background())
+
+			Convey("Then the live generation is not switched", func() {
+				So(errors.Is(err, sitemap.ErrURLCountDropped), ShouldBeTrue)
+				manifest, _, err := generations.Manifest()
+				So(err, ShouldBeNil)
+				So(manifest.Current, ShouldEqual, gen.ID)
+				So(manifest.Generations, ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestGenerateFullSitemapStaticPages(t *testing.T) {
+	fetcher := &mock.FetcherMock{}
+	fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
+		files := sitemap.Files{}
+		for lang, content := range map[config.Language]string{
+			config.English: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url><loc>https://www.ons.gov.uk/older</loc><lastmod>2023-01-01</lastmod></url>
+  <url>
+    <loc>https://www.ons.gov.uk/newer</loc>
+    <lastmod>2023-03-01</lastmod>
+    <xhtml:link rel="alternate" hreflang="cy" href="https://cy.ons.gov.uk/newer"></xhtml:link>
+  </url>
+</urlset>`,
+			config.Welsh: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+</urlset>`,
+		} {
+			file, err := os.CreateTemp("", "sitemap")
+			So(err, ShouldBeNil)
+			_, err = file.WriteString(content)
+			So(err, ShouldBeNil)
+			So(file.Close(), ShouldBeNil)
+			files[lang] = file.Name()
+		}
+		return files, 2, nil
+	}
+	cfg := &config.Config{
+		DpOnsURLHostNameEn: "https://www.ons.gov.uk/",
+		DpOnsURLHostNameCy: "https://cy.ons.gov.uk/",
+	}
+
+	Convey("Given static pages overlapping the search index documents", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "sitemap_en.json"), []byte(`[
+  {"url": "older", "releaseDate": "01-02-2023", "hasAltLang": true},
+  {"url": "newer", "releaseDate": "01-02-2023", "hasAltLang": false},
+  {"url": "static", "releaseDate": "01-02-2023", "hasAltLang": false},
+  {"url": "static", "releaseDate": "01-02-2023", "hasAltLang": false}
+]`), 0o600), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "sitemap_cy.json"), []byte(`[
+  {"url": "static", "releaseDate": "01-02-2023", "hasAltLang": true}
+]`), 0o600), ShouldBeNil)
+		files := sitemap.Files{
+			config.English: filepath.Join(dir, "sitemap-en.xml"),
+			config.Welsh:   filepath.Join(dir, "sitemap-cy.xml"),
+		}
+
+		Convey("When a full sitemap is generated with the static pages", func() {
+			result, err := sitemap.NewGenerator(
+				sitemap.WithFetcher(fetcher),
+				sitemap.WithFileStore(&sitemap.LocalStore{}),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithFullSitemapFiles(files),
+				sitemap.WithPublishingSitemapFile(filepath.Join(dir, "publishing-sitemap.xml")),
+				sitemap.WithStaticPages(sitemap.NewStaticPages(cfg, dir)),
+			).MakeFullSitemap(context.Background())
+
+			Convey("Then the static pages are merged once into each language", func() {
+				So(err, ShouldBeNil)
+				So(result.URLCounts, ShouldResemble, sitemap.URLCounts{config.English: 3, config.Welsh: 1})
+			})
+			Convey("Then the entry with the newer lastmod is kept", func() {
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				diff, err := sitemap.DiffSitemaps(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>https://www.ons.gov.uk/older</loc>
+    <lastmod>2023-02-01</lastmod>
+    <xhtml:link rel="alternate" hreflang="cy" href="https://cy.ons.gov.uk/older"></xhtml:link>
+  </url>
+  <url>
+    <loc>https://www.ons.gov.uk/newer</loc>
+    <lastmod>2023-03-01</lastmod>
+    <xhtml:link rel="alternate" hreflang="cy" href="https://cy.ons.gov.uk/newer"></xhtml:link>
+  </url>
+  <url><loc>https://www.ons.gov.uk/static</loc><lastmod>2023-02-01</lastmod></url>
+</urlset>`), bytes.NewReader(content))
+				So(err, ShouldBeNil)
+				So(diff.Empty(), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestGenerateSitemapsRobotsCheck(t *testing.T) {
+	rules := &mock.RobotsRulesMock{
+		AllowedFunc: func(lang config.Language, userAgent, path string) bool {
+			return !strings.HasPrefix(path, "/search") && (userAgent != "Googlebot" || path != "/private")
+		},
+	}
+	fetcher := &mock.FetcherMock{}
+	fetcher.GetFullSitemapFunc = func(ctx context.Context) (sitemap.Files, int, error) {
+		files := sitemap.Files{}
+		for lang, content := range map[config.Language]string{
+			config.English: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url><loc>https://www.ons.gov.uk/economy</loc><lastmod>2023-01-01</lastmod></url>
+  <url><loc>https://www.ons.gov.uk/search?q=cpi</loc><lastmod>2023-01-01</lastmod></url>
+  <url><loc>https://www.ons.gov.uk/private</loc><lastmod>2023-01-01</lastmod></url>
+</urlset>`,
+			config.Welsh: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url><loc>https://cy.ons.gov.uk/economy</loc><lastmod>2023-01-01</lastmod></url>
+</urlset>`,
+		} {
+			file, err := os.CreateTemp("", "sitemap")
+			So(err, ShouldBeNil)
+			_, err = file.WriteString(content)
+			So(err, ShouldBeNil)
+			So(file.Close(), ShouldBeNil)
+			files[lang] = file.Name()
+		}
+		return files, 4, nil
+	}
+	fetcher.URLVersionsFunc = func(ctx context.Context, path, lastmod string) (*sitemap.URL, *sitemap.URL) {
+		return &sitemap.URL{Loc: "https://www.ons.gov.uk" + path, Lastmod: lastmod}, nil
+	}
+
+	Convey("Given robots rules disallowing some of the sitemap urls", t, func() {
+		dir := t.TempDir()
+		files := sitemap.Files{
+			config.English: filepath.Join(dir, "sitemap-en.xml"),
+			config.Welsh:   filepath.Join(dir, "sitemap-cy.xml"),
+		}
+		newGenerator := func(mode string) *sitemap.Generator {
+			check, err := sitemap.NewRobotsCheck(rules, mode)
+			So(err, ShouldBeNil)
+			return sitemap.NewGenerator(
+				sitemap.WithFetcher(fetcher),
+				sitemap.WithFileStore(&sitemap.LocalStore{}),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithFullSitemapFiles(files),
+				sitemap.WithPublishingSitemapFile(filepath.Join(dir, "publishing-sitemap.xml")),
+				sitemap.WithRobotsCheck(check),
+			)
+		}
+
+		Convey("When a full sitemap is generated dropping the disallowed urls", func() {
+			result, err := newGenerator(sitemap.RobotsCheckDrop).MakeFullSitemap(context.Background())
+
+			Convey("Then the urls disallowed for all user agents or Googlebot are dropped and counted", func() {
+				So(err, ShouldBeNil)
+				So(result.URLCounts, ShouldResemble, sitemap.URLCounts{config.English: 1, config.Welsh: 1})
+				So(result.BlockedURLs, ShouldResemble, sitemap.URLCounts{config.English: 2, config.Welsh: 0})
+				content, err := os.ReadFile(files[config.English])
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "https://www.ons.gov.uk/economy")
+				So(string(content), ShouldNotContainSubstring, "https://www.ons.gov.uk/search")
+				So(string(content), ShouldNotContainSubstring, "https://www.ons.gov.uk/private")
+			})
+		})
+
+		Convey("When a full sitemap is generated warning about the disallowed urls", func() {
+			result, err := newGenerator(sitemap.RobotsCheckWarn).MakeFullSitemap(context.Background())
+
+			Convey("Then they are counted and kept", func() {
+				So(err, ShouldBeNil)
+				So(result.URLCounts, ShouldResemble, sitemap.URLCounts{config.English: 3, config.Welsh: 1})
+				So(result.BlockedURLs, ShouldResemble, sitemap.URLCounts{config.English: 2, config.Welsh: 0})
+			})
+		})
+
+		Convey("When a disallowed url is added to the publishing sitemap dropping the disallowed urls", func() {
+			g := newGenerator(sitemap.RobotsCheckDrop)
+			So(g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: "/search", Lastmod: "2023-01-01"}), ShouldBeNil)
+			So(g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: "/economy", Lastmod: "2023-01-01"}), ShouldBeNil)
+
+			Convey("Then only the allowed url is added", func() {
+				content, err := os.ReadFile(filepath.Join(dir, "publishing-sitemap.xml"))
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "https://www.ons.gov.uk/economy")
+				So(string(content), ShouldNotContainSubstring, "https://www.ons.gov.uk/search")
+			})
+		})
+
+		Convey("When a disallowed url is added to the publishing sitemap warning about the disallowed urls", func() {
+			g := newGenerator(sitemap.RobotsCheckWarn)
+			So(g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: "/search", Lastmod: "2023-01-01"}), ShouldBeNil)
+
+			Convey("Then it is added", func() {
+				content, err := os.ReadFile(filepath.Join(dir, "publishing-sitemap.xml"))
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "https://www.ons.gov.uk/search")
+			})
+		})
+
+		Convey("When a url whose welsh version is disallowed is added to the publishing sitemap dropping the disallowed urls", func() {
+			welshFetcher := &mock.FetcherMock{
+				URLVersionsFunc: func(ctx context.Context, path, lastmod string) (*sitemap.URL, *sitemap.URL) {
+					return &sitemap.URL{Loc: "https://www.ons.gov.uk" + path, Lastmod: lastmod, Alternate: &sitemap.AlternateURL{Rel: "alternate", Lang: "cy", Link: "https://cy.ons.gov.uk" + path}},
+						&sitemap.URL{Loc: "https://cy.ons.gov.uk" + path, Lastmod: lastmod}
+				},
+			}
+			welshRules := &mock.RobotsRulesMock{
+				AllowedFunc: func(lang config.Language, userAgent, path string) bool {
+					return lang != config.Welsh
+				},
+			}
+			check, err := sitemap.NewRobotsCheck(welshRules, sitemap.RobotsCheckDrop)
+			So(err, ShouldBeNil)
+			g := sitemap.NewGenerator(
+				sitemap.WithFetcher(welshFetcher),
+				sitemap.WithFileStore(&sitemap.LocalStore{}),
+				sitemap.WithAdder(&sitemap.DefaultAdder{}),
+				sitemap.WithPublishingSitemapFile(filepath.Join(dir, "publishing-sitemap.xml")),
+				sitemap.WithRobotsCheck(check),
+			)
+			So(g.MakePublishingSitemap(context.Background(), sitemap.URL{Loc: "/economy", Lastmod: "2023-01-01"}), ShouldBeNil)
+
+			Convey("Then the english url is added without its alternate link to the welsh one", func() {
+				content, err := os.ReadFile(filepath.Join(dir, "publishing-sitemap.xml"))
+				So(err, ShouldBeNil)
+				So(string(content), ShouldContainSubstring, "https://www.ons.gov.uk/economy")
+				So(string(content), ShouldNotContainSubstring, "https://cy.ons.gov.uk/economy")
+			})
+		})
+
+		Convey("When static sitemaps are made dropping the disallowed urls", func() {
+			So(os.WriteFile(filepath.Join(dir, "sitemap_en.json"), []byte(`[
+  {"url": "economy", "releaseDate": "01-02-2023", "hasAltLang": true},
+  {"url": "search", "releaseDate": "01-02-2023", "hasAltLang": true}
+]`), 0o600), ShouldBeNil)
+			check, err := sitemap.NewRobotsCheck(rules, sitemap.RobotsCheckDrop)
+			So(err, ShouldBeNil)
+			cfg := &config.Config{DpOnsURLHostNameEn: "https://www.ons.gov.uk/", DpOnsURLHostNameCy: "https://cy.ons.gov.uk/"}
+			sitemaps, err := sitemap.StaticSitemaps(context.Background(), cfg, filepath.Join(dir, "sitemap_en.json"), check)
+
+			Convey("Then the disallowed urls of each language are dropped", func() {
+				So(err, ShouldBeNil)
+				for _, lang := range []config.Language{config.English, config.Welsh} {
+					So(string(sitemaps[lang]), ShouldContainSubstring, "ons.gov.uk/economy</loc>")
+					So(string(sitemaps[lang]), ShouldNotContainSubstring, "ons.gov.uk/search</loc>")
+				}
+			})
+		})
+	})
+
+	Convey("Given an unknown robots check mode", t, func() {
+		Convey("When the robots check is created", func() {
+			_, err := sitemap.NewRobotsCheck(rules, "block")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldWrap, sitemap.ErrInvalidRobotsCheck)
+			})
+		})
+	})
+}
@@ -0,0 +1,187 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+//go:generate moq -out mock/checkablestore.go -pkg mock . CheckableStore
+
+// healthCheckFileName is the file written next to the full sitemaps to check that the store can be written
+const healthCheckFileName = ".healthcheck"
+
+// CheckableStore is a store that can report whether it is writable
+type CheckableStore interface {
+	GetFileInfo(name string) (*FileInfo, error)
+	CheckWritable(name string) error
+}
+
+// generationResolver is implemented by the FileResolver of full sitemaps published as generations
+type generationResolver interface {
+	Live() (*Generation, error)
+}
+
+// StoreChecker checks that the store the sitemaps are written to is writable
+// and that the full sitemaps in it are not older than a maximum age
+type StoreChecker struct {
+	store  CheckableStore
+	files  FileResolver
+	maxAge time.Duration
+}
+
+// NewStoreChecker returns a checker for the live full sitemap files in store. The age of the sitemaps is
+// that of their live generation when they are published as generations, as published content keeps
+// updating the files themselves, and the time they were last modified otherwise. A maxAge of zero
+// disables the check.
+func NewStoreChecker(store CheckableStore, files FileResolver, maxAge time.Duration) *StoreChecker {
+	return &StoreChecker{
+		store:  store,
+		files:  files,
+		maxAge: maxAge,
+	}
+}
+
+// Checker updates state with the health of the store
+func (c *StoreChecker) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	files, live, err := c.liveFiles()
+	if err != nil {
+		log.Error(ctx, "failed to get live sitemap files", err)
+		return state.Update(healthcheck.StatusCritical, fmt.Sprintf("failed to get live sitemap files: %s", err), 0)
+	}
+
+	err = c.store.CheckWritable(path.Join(path.Dir(files[config.English]), healthCheckFileName))
+	if err != nil {
+		log.Error(ctx, "sitemap store is not writable", err)
+		return state.Update(healthcheck.StatusCritical, fmt.Sprintf("sitemap store is not writable: %s", err), 0)
+	}
+
+	var warnings []string
+	for _, lang := range []config.Language{config.English, config.Welsh} {
+		fileName, ok := files[lang]
+		if !ok {
+			continue
+		}
+		info, err := c.store.GetFileInfo(fileName)
+		if errors.Is(err, ErrFileNotFound) {
+			warnings = append(warnings, fmt.Sprintf("%s full sitemap has not been generated", lang))
+			continue
+		}
+		if err != nil {
+			log.Error(ctx, "failed to get full sitemap info", err, log.Data{"file": fileName})
+			return state.Update(healthcheck.StatusCritical, fmt.Sprintf("failed to get %s full sitemap info: %s", lang, err), 0)
+		}
+		if c.maxAge > 0 && live == nil && time.Since(info.ModTime) > c.maxAge {
+			warnings = append(warnings, fmt.Sprintf("%s full sitemap is older than %s", lang, c.maxAge))
+		}
+	}
+	if c.maxAge > 0 && live != nil && time.Since(live.Created) > c.maxAge {
+		warnings = append(warnings, fmt.Sprintf("live full sitemap generation %s is older than %s", live.ID, c.maxAge))
+	}
+	if len(warnings) > 0 {
+		return state.Update(healthcheck.StatusWarning, strings.Join(warnings, ", "), 0)
+	}
+	return state.Update(healthcheck.StatusOK, "sitemap store is healthy", 0)
+}
+
+// liveFiles returns the live full sitemap files, with their generation if they are published as generations
+func (c *StoreChecker) liveFiles() (Files, *Generation, error) {
+	if generations, ok := c.files.(generationResolver); ok {
+		live, err := generations.Live()
+		if err != nil {
+			return nil, nil, err
+		}
+		if live != nil {
+			return live.Files, live, nil
+		}
+	}
+	files, err := c.files.LiveFiles()
+	return files, nil, err
+}
+
+// GenerationChecker checks that the full sitemap generation keeps succeeding and that the
+// generated sitemaps have not lost a large share of their URLs
+type GenerationChecker struct {
+	mx             sync.RWMutex
+	maxDropPercent float64
+	maxFailures    int
+	previous       *FullSitemapResult
+	latest         *FullSitemapResult
+	failures       int
+	lastErr        error
+}
+
+// NewGenerationChecker returns a checker failing when the number of URLs in a full sitemap drops
+// by more than maxDropPercent from the previous generation, or when the generation fails maxFailures
+// times in a row. Either check is disabled if its limit is zero.
+func NewGenerationChecker(maxDropPercent float64, maxFailures int) *GenerationChecker {
+	return &GenerationChecker{
+		maxDropPercent: maxDropPercent,
+		maxFailures:    maxFailures,
+	}
+}
+
+// Record keeps the outcome of a full sitemap generation
+func (c *GenerationChecker) Record(result *FullSitemapResult, err error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if err != nil {
+		c.failures++
+		c.lastErr = err
+		return
+	}
+	c.failures = 0
+	c.lastErr = nil
+	c.previous = c.latest
+	c.latest = result
+}
+
+// Checker updates state with the health of the full sitemap generation
+func (c *GenerationChecker) Checker(ctx context.Context, state *healthcheck.CheckState) error {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	if c.maxFailures > 0 && c.failures >= c.maxFailures {
+		return state.Update(healthcheck.StatusCritical, fmt.Sprintf("full sitemap generation failed %d times in a row: %s", c.failures, c.lastErr), 0)
+	}
+	if c.latest == nil {
+		return state.Update(healthcheck.StatusOK, "full sitemap not generated yet", 0)
+	}
+
+	if c.maxDropPercent > 0 && c.previous != nil {
+		var drops []string
+		for _, lang := range []config.Language{config.English, config.Welsh} {
+			previous, latest := c.previous.URLCounts[lang], c.latest.URLCounts[lang]
+			if dropPercent(previous, latest) > c.maxDropPercent {
+				drops = append(drops, fmt.Sprintf("%s full sitemap dropped from %d to %d urls", lang, previous, latest))
+			}
+		}
+		if len(drops) > 0 {
+			log.Warn(ctx, "full sitemap url count dropped", log.Data{"previous": c.previous.URLCounts, "latest": c.latest.URLCounts})
+			return state.Update(healthcheck.StatusCritical, strings.Join(drops, ", "), 0)
+		}
+	}
+
+	// every document in the search index gets a URL in the english sitemap
+	if c.maxDropPercent > 0 && dropPercent(c.latest.SearchHits, c.latest.URLCounts[config.English]) > c.maxDropPercent {
+		return state.Update(healthcheck.StatusWarning, fmt.Sprintf("en full sitemap has %d urls for %d search index documents", c.latest.URLCounts[config.English], c.latest.SearchHits), 0)
+	}
+	return state.Update(healthcheck.StatusOK, "full sitemap generation is healthy", 0)
+}
+
+// dropPercent returns the drop from one count to another as a percentage of the first, or zero if it did not drop
+func dropPercent(from, to int) float64 {
+	if from <= 0 || to >= from {
+		return 0
+	}
+	return float64(from-to) / float64(from) * 100
+}
@@ -0,0 +1,260 @@
+package sitemap_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStoreChecker(t *testing.T) {
+	files := sitemap.Files{config.English: "/sitemaps/sitemap-en.xml", config.Welsh: "/sitemaps/sitemap-cy.xml"}
+
+	Convey("Given a writable store with recent full sitemaps", t, func() {
+		store := &mock.CheckableStoreMock{
+			CheckWritableFunc: func(name string) error { return nil },
+			GetFileInfoFunc: func(name string) (*sitemap.FileInfo, error) {
+				return &sitemap.FileInfo{ModTime: time.Now().Add(-time.Hour)}, nil
+			},
+		}
+		checker := sitemap.NewStoreChecker(store, files, 3*time.Hour)
+		state := healthcheck.NewCheckState("Sitemap store")
+
+		Convey("When the store is checked", func() {
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+			Convey("Then a check file is written next to the sitemaps", func() {
+				So(store.CheckWritableCalls(), ShouldHaveLength, 1)
+				So(store.CheckWritableCalls()[0].Name, ShouldEqual, "/sitemaps/.healthcheck")
+			})
+		})
+
+		Convey("When the store is not writable", func() {
+			store.CheckWritableFunc = func(name string) error { return errors.New("permission denied") }
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+				So(state.Message(), ShouldContainSubstring, "permission denied")
+			})
+		})
+
+		Convey("When the welsh sitemap is older than the maximum age", func() {
+			store.GetFileInfoFunc = func(name string) (*sitemap.FileInfo, error) {
+				if name == files[config.Welsh] {
+					return &sitemap.FileInfo{ModTime: time.Now().Add(-4 * time.Hour)}, nil
+				}
+				return &sitemap.FileInfo{ModTime: time.Now()}, nil
+			}
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is WARNING", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldEqual, "cy full sitemap is older than 3h0m0s")
+			})
+		})
+
+		Convey("When the sitemaps have not been generated", func() {
+			store.GetFileInfoFunc = func(name string) (*sitemap.FileInfo, error) { return nil, sitemap.ErrFileNotFound }
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is WARNING", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldEqual, "en full sitemap has not been generated, cy full sitemap has not been generated")
+			})
+		})
+
+		Convey("When the sitemap info cannot be read", func() {
+			store.GetFileInfoFunc = func(name string) (*sitemap.FileInfo, error) { return nil, errors.New("access denied") }
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+			})
+		})
+	})
+
+	Convey("Given full sitemaps published as generations and kept up to date by published content", t, func() {
+		dir := t.TempDir()
+		generations := sitemap.NewGenerations(&sitemap.LocalStore{}, filepath.Join(dir, "manifest.json"), files, 2)
+		store := &mock.CheckableStoreMock{
+			CheckWritableFunc: func(name string) error { return nil },
+			GetFileInfoFunc: func(name string) (*sitemap.FileInfo, error) {
+				return &sitemap.FileInfo{ModTime: time.Now()}, nil
+			},
+		}
+		checker := sitemap.NewStoreChecker(store, generations, 3*time.Hour)
+		state := healthcheck.NewCheckState("Sitemap store")
+
+		Convey("When the live generation is older than the maximum age", func() {
+			gen := generations.New(time.Now().Add(-4 * time.Hour))
+			So(generations.Publish(context.Background(), gen), ShouldBeNil)
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is WARNING", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldEqual, "live full sitemap generation "+gen.ID+" is older than 3h0m0s")
+			})
+			Convey("Then the files of the live generation are checked", func() {
+				So(store.GetFileInfoCalls(), ShouldHaveLength, 2)
+				So(store.GetFileInfoCalls()[0].Name, ShouldEqual, gen.Files[config.English])
+			})
+		})
+
+		Convey("When the live generation is recent", func() {
+			So(generations.Publish(context.Background(), generations.New(time.Now().Add(-time.Hour))), ShouldBeNil)
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a checker with no maximum age", t, func() {
+		store := &mock.CheckableStoreMock{
+			CheckWritableFunc: func(name string) error { return nil },
+			GetFileInfoFunc: func(name string) (*sitemap.FileInfo, error) {
+				return &sitemap.FileInfo{ModTime: time.Now().Add(-24 * 365 * time.Hour)}, nil
+			},
+		}
+		checker := sitemap.NewStoreChecker(store, files, 0)
+		state := healthcheck.NewCheckState("Sitemap store")
+
+		Convey("When old sitemaps are checked", func() {
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+	})
+}
+
+func TestGenerationChecker(t *testing.T) {
+	result := func(en, cy, hits int) *sitemap.FullSitemapResult {
+		return &sitemap.FullSitemapResult{URLCounts: sitemap.URLCounts{config.English: en, config.Welsh: cy}, SearchHits: hits}
+	}
+
+	Convey("Given a generation checker", t, func() {
+		checker := sitemap.NewGenerationChecker(20, 3)
+		state := healthcheck.NewCheckState("Sitemap generation")
+
+		Convey("When no sitemap has been generated", func() {
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+
+		Convey("When the url counts are steady", func() {
+			checker.Record(result(100, 10, 100), nil)
+			checker.Record(result(90, 9, 91), nil)
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+
+		Convey("When a url count drops by more than the maximum percentage", func() {
+			checker.Record(result(100, 10, 100), nil)
+			checker.Record(result(100, 7, 100), nil)
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+				So(state.Message(), ShouldEqual, "cy full sitemap dropped from 10 to 7 urls")
+			})
+
+			Convey("And the state recovers once the next generation is steady", func() {
+				checker.Record(result(100, 7, 100), nil)
+				err = checker.Checker(context.Background(), state)
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+
+		Convey("When the english sitemap has far fewer urls than the search index has documents", func() {
+			checker.Record(result(50, 10, 100), nil)
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is WARNING", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusWarning)
+				So(state.Message(), ShouldEqual, "en full sitemap has 50 urls for 100 search index documents")
+			})
+		})
+
+		Convey("When the generation fails fewer times in a row than the maximum", func() {
+			checker.Record(result(100, 10, 100), nil)
+			checker.Record(nil, errors.New("scroll error"))
+			checker.Record(nil, errors.New("scroll error"))
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+
+		Convey("When the generation fails the maximum number of times in a row", func() {
+			checker.Record(nil, errors.New("scroll error"))
+			checker.Record(nil, errors.New("scroll error"))
+			checker.Record(nil, errors.New("timeout"))
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusCritical)
+				So(state.Message(), ShouldEqual, "full sitemap generation failed 3 times in a row: timeout")
+			})
+
+			Convey("And a successful generation resets the failures", func() {
+				checker.Record(result(100, 10, 100), nil)
+				err = checker.Checker(context.Background(), state)
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a generation checker with the checks disabled", t, func() {
+		checker := sitemap.NewGenerationChecker(0, 0)
+		state := healthcheck.NewCheckState("Sitemap generation")
+		checker.Record(result(100, 10, 100), nil)
+		checker.Record(result(1, 1, 100), nil)
+		checker.Record(nil, errors.New("scroll error"))
+
+		Convey("When the generation is checked", func() {
+			err := checker.Checker(context.Background(), state)
+
+			Convey("Then the state is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, healthcheck.StatusOK)
+			})
+		})
+	})
+}
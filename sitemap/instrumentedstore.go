@@ -0,0 +1,89 @@
+package sitemap
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/ONSdigital/dp-sitemap/metrics"
+)
+
+// InstrumentedStore records the latency and errors of the operations of the wrapped store
+type InstrumentedStore struct {
+	store FileStore
+	name  string
+}
+
+// NewInstrumentedStore wraps store, labelling its metrics with name
+func NewInstrumentedStore(store FileStore, name string) *InstrumentedStore {
+	return &InstrumentedStore{
+		store: store,
+		name:  name,
+	}
+}
+
+func (s *InstrumentedStore) SaveFile(name string, body io.Reader) error {
+	defer s.observe("save", time.Now())
+	return s.count("save", s.store.SaveFile(name, body))
+}
+
+func (s *InstrumentedStore) GetFile(name string) (io.ReadCloser, error) {
+	defer s.observe("get", time.Now())
+	body, err := s.store.GetFile(name)
+	return body, s.count("get", err)
+}
+
+func (s *InstrumentedStore) GetFileInfo(name string) (*FileInfo, error) {
+	defer s.observe("get_info", time.Now())
+	info, err := s.store.GetFileInfo(name)
+	if errors.Is(err, ErrFileNotFound) {
+		return info, err
+	}
+	return info, s.count("get_info", err)
+}
+
+func (s *InstrumentedStore) GetFileWithVersion(name string) (io.ReadCloser, string, error) {
+	defer s.observe("get_versioned", time.Now())
+	body, version, err := s.store.GetFileWithVersion(name)
+	if errors.Is(err, ErrFileNotFound) {
+		return body, version, err
+	}
+	return body, version, s.count("get_versioned", err)
+}
+
+func (s *InstrumentedStore) SaveFileIfVersion(name string, body io.Reader, version string) error {
+	defer s.observe("save_versioned", time.Now())
+	err := s.store.SaveFileIfVersion(name, body, version)
+	if errors.Is(err, ErrVersionConflict) {
+		metrics.FileStoreConflicts.WithLabelValues(s.name).Inc()
+		return err
+	}
+	return s.count("save_versioned", err)
+}
+
+func (s *InstrumentedStore) CopyFile(src io.Reader, dest io.Writer) error {
+	defer s.observe("copy", time.Now())
+	return s.count("copy", s.store.CopyFile(src, dest))
+}
+
+func (s *InstrumentedStore) CreateFile(name string) (io.ReadWriteCloser, error) {
+	defer s.observe("create", time.Now())
+	file, err := s.store.CreateFile(name)
+	return file, s.count("create", err)
+}
+
+func (s *InstrumentedStore) DeleteFile(name string) error {
+	defer s.observe("delete", time.Now())
+	return s.count("delete", s.store.DeleteFile(name))
+}
+
+func (s *InstrumentedStore) observe(operation string, start time.Time) {
+	metrics.FileStoreDuration.WithLabelValues(s.name, operation).Observe(time.Since(start).Seconds())
+}
+
+func (s *InstrumentedStore) count(operation string, err error) error {
+	if err != nil {
+		metrics.FileStoreErrors.WithLabelValues(s.name, operation).Inc()
+	}
+	return err
+}
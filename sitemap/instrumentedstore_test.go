@@ -0,0 +1,87 @@
+package sitemap_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-sitemap/metrics"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInstrumentedStore(t *testing.T) {
+	Convey("Given an instrumented store", t, func() {
+		store := &mock.FileStoreMock{
+			SaveFileFunc: func(name string, body io.Reader) error { return nil },
+			GetFileFunc: func(name string) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("content")), nil
+			},
+		}
+		s := sitemap.NewInstrumentedStore(store, "test")
+		saveErrors := metrics.FileStoreErrors.WithLabelValues("test", "save")
+		infoErrors := metrics.FileStoreErrors.WithLabelValues("test", "get_info")
+
+		Convey("When a file is read", func() {
+			body, err := s.GetFile("sitemap.xml")
+
+			Convey("Then the wrapped store is called", func() {
+				So(err, ShouldBeNil)
+				b, err := io.ReadAll(body)
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, "content")
+				So(store.GetFileCalls(), ShouldHaveLength, 1)
+				So(store.GetFileCalls()[0].Name, ShouldEqual, "sitemap.xml")
+			})
+		})
+
+		Convey("When saving a file fails", func() {
+			before := testutil.ToFloat64(saveErrors)
+			store.SaveFileFunc = func(name string, body io.Reader) error { return errors.New("save error") }
+			err := s.SaveFile("sitemap.xml", strings.NewReader(""))
+
+			Convey("Then the error is returned and counted", func() {
+				So(err.Error(), ShouldEqual, "save error")
+				So(testutil.ToFloat64(saveErrors), ShouldEqual, before+1)
+			})
+		})
+
+		Convey("When a file is saved", func() {
+			before := testutil.ToFloat64(saveErrors)
+			err := s.SaveFile("sitemap.xml", strings.NewReader(""))
+
+			Convey("Then no error is counted", func() {
+				So(err, ShouldBeNil)
+				So(testutil.ToFloat64(saveErrors), ShouldEqual, before)
+			})
+		})
+
+		Convey("When the info of a missing file is requested", func() {
+			before := testutil.ToFloat64(infoErrors)
+			store.GetFileInfoFunc = func(name string) (*sitemap.FileInfo, error) { return nil, sitemap.ErrFileNotFound }
+			_, err := s.GetFileInfo("sitemap.xml")
+
+			Convey("Then not found is returned without counting an error", func() {
+				So(err, ShouldEqual, sitemap.ErrFileNotFound)
+				So(testutil.ToFloat64(infoErrors), ShouldEqual, before)
+			})
+		})
+
+		Convey("When a conditional save conflicts with another change", func() {
+			conflicts := metrics.FileStoreConflicts.WithLabelValues("test")
+			saveErrors := metrics.FileStoreErrors.WithLabelValues("test", "save_versioned")
+			beforeConflicts, beforeErrors := testutil.ToFloat64(conflicts), testutil.ToFloat64(saveErrors)
+			store.SaveFileIfVersionFunc = func(name string, body io.Reader, version string) error { return sitemap.ErrVersionConflict }
+			err := s.SaveFileIfVersion("sitemap.xml", strings.NewReader(""), `"v1"`)
+
+			Convey("Then the conflict is returned and counted as a conflict, not an error", func() {
+				So(err, ShouldEqual, sitemap.ErrVersionConflict)
+				So(testutil.ToFloat64(conflicts), ShouldEqual, beforeConflicts+1)
+				So(testutil.ToFloat64(saveErrors), ShouldEqual, beforeErrors)
+			})
+		})
+	})
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package sitemap
+
+import "sync"
+
+// localFileMx serialises the saves of local files where file locks are not available,
+// which only protects them from concurrent saves within this process
+var localFileMx sync.Mutex
+
+// lockLocalFile takes an exclusive lock on local files and returns a function releasing it
+func lockLocalFile(_ string) (unlock func(), err error) {
+	localFileMx.Lock()
+	return localFileMx.Unlock, nil
+}
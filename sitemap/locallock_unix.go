@@ -0,0 +1,25 @@
+//go:build unix
+
+package sitemap
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockLocalFile takes an exclusive lock on the file name, shared with other processes,
+// and returns a function releasing it
+func lockLocalFile(name string) (unlock func(), err error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
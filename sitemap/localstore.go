@@ -1,10 +1,15 @@
 package sitemap
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/ONSdigital/log.go/v2/log"
@@ -13,6 +18,10 @@ import (
 type LocalStore struct{}
 
 func (s *LocalStore) SaveFile(name string, body io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(name), 0o700)
+	if err != nil {
+		return fmt.Errorf("failed to create a local directory: %w", err)
+	}
 	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return fmt.Errorf("failed to open a local file: %w", err)
@@ -40,6 +49,82 @@ func (s *LocalStore) GetFile(name string) (body io.ReadCloser, err error) {
 	return file, nil
 }
 
+func (s *LocalStore) GetFileInfo(name string) (*FileInfo, error) {
+	info, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat a local file: %w", err)
+	}
+	return &FileInfo{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		ETag:    fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}, nil
+}
+
+// GetFileWithVersion returns the content of a file along with its version, a hash of its content,
+// which can be passed to SaveFileIfVersion to only overwrite the file if it has not changed since
+func (s *LocalStore) GetFileWithVersion(name string) (body io.ReadCloser, version string, err error) {
+	content, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", ErrFileNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read a local file: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(content)), contentVersion(content), nil
+}
+
+// SaveFileIfVersion saves a file only if its version is still the given one, or if it does not exist
+// when version is empty. ErrVersionConflict is returned otherwise. Processes sharing the file serialise
+// their saves with a lock on a ".lock" file next to it.
+func (s *LocalStore) SaveFileIfVersion(name string, body io.Reader, version string) error {
+	unlock, err := lockLocalFile(name + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock a local file: %w", err)
+	}
+	defer unlock()
+
+	var current string
+	content, err := os.ReadFile(name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read a local file: %w", err)
+	default:
+		current = contentVersion(content)
+	}
+	if current != version {
+		return ErrVersionConflict
+	}
+
+	// the file is replaced by renaming a complete temporary file,
+	// so that it is never read partially written
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary local file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy to a local file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to replace a local file: %w", err)
+	}
+	return nil
+}
+
+// contentVersion returns the version of a local file with the given content
+func contentVersion(content []byte) string {
+	return fmt.Sprintf(`"%x"`, sha256.Sum256(content))
+}
+
 func (s *LocalStore) CopyFile(src io.Reader, dest io.Writer) error {
 	_, err := io.Copy(dest, src)
 	if err != nil {
@@ -63,3 +148,20 @@ func (s *LocalStore) DeleteFile(name string) error {
 	}
 	return nil
 }
+
+// CheckWritable checks that the directory of name exists and that name can be written to it
+func (s *LocalStore) CheckWritable(name string) error {
+	dir := filepath.Dir(name)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to stat a local directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	err = s.SaveFile(name, strings.NewReader(""))
+	if err != nil {
+		return err
+	}
+	return s.DeleteFile(name)
+}
@@ -103,6 +103,39 @@ func TestLocalStore(t *testing.T) {
 		})
 	})
 
+	Convey("When getting the info of a missing file", t, func() {
+		randomFilename := path.Join(dir, "sitemap-test-"+uuid.NewString())
+
+		s := &sitemap.LocalStore{}
+		info, err := s.GetFileInfo(randomFilename)
+
+		Convey("LocalStore should return a not found error", func() {
+			So(err, ShouldEqual, sitemap.ErrFileNotFound)
+			So(info, ShouldBeNil)
+		})
+	})
+
+	Convey("When getting the info of an existing file", t, func() {
+		randomFilename := path.Join(dir, "sitemap-test-"+uuid.NewString())
+		err := os.WriteFile(randomFilename, []byte("file content"), 0o600)
+		So(err, ShouldBeNil)
+
+		defer func() {
+			removeErr := os.Remove(randomFilename)
+			So(removeErr, ShouldBeNil)
+		}()
+
+		s := &sitemap.LocalStore{}
+		info, err := s.GetFileInfo(randomFilename)
+
+		Convey("LocalStore should return the file info", func() {
+			So(err, ShouldBeNil)
+			So(info.Size, ShouldEqual, len("file content"))
+			So(info.ModTime.IsZero(), ShouldBeFalse)
+			So(info.ETag, ShouldNotBeEmpty)
+		})
+	})
+
 	Convey("When a file deletion succeeds", t, func() {
 		randomFilename := path.Join(dir, "sitemap-test-"+uuid.NewString())
 		err := os.WriteFile(randomFilename, []byte("file content"), 0o600)
@@ -127,4 +160,69 @@ func TestLocalStore(t *testing.T) {
 			So(err.Error(), ShouldContainSubstring, "failed to delete file")
 		})
 	})
+
+	Convey("When checking a writable directory", t, func() {
+		randomFilename := path.Join(dir, "sitemap-test-"+uuid.NewString())
+
+		s := &sitemap.LocalStore{}
+		err := s.CheckWritable(randomFilename)
+
+		Convey("CheckWritable should return no error and leave no file behind", func() {
+			So(err, ShouldBeNil)
+			_, statErr := os.Stat(randomFilename)
+			So(errors.Is(statErr, os.ErrNotExist), ShouldBeTrue)
+		})
+	})
+
+	Convey("When checking a directory that does not exist", t, func() {
+		s := &sitemap.LocalStore{}
+		err := s.CheckWritable(path.Join(dir, "sitemap-test-"+uuid.NewString(), "file"))
+
+		Convey("CheckWritable should return correct error", func() {
+			So(err.Error(), ShouldContainSubstring, "failed to stat a local directory")
+		})
+	})
+
+	Convey("Given a file saved if its version is unchanged", t, func() {
+		randomFilename := path.Join(t.TempDir(), "sitemap-test-"+uuid.NewString())
+		s := &sitemap.LocalStore{}
+
+		Convey("When it does not exist", func() {
+			_, _, err := s.GetFileWithVersion(randomFilename)
+
+			Convey("Then not found is returned", func() {
+				So(err, ShouldEqual, sitemap.ErrFileNotFound)
+			})
+			Convey("Then it can only be created without a version", func() {
+				So(s.SaveFileIfVersion(randomFilename, strings.NewReader("first"), `"v1"`), ShouldEqual, sitemap.ErrVersionConflict)
+				So(s.SaveFileIfVersion(randomFilename, strings.NewReader("first"), ""), ShouldBeNil)
+			})
+		})
+
+		Convey("When it has been read with its version", func() {
+			So(s.SaveFileIfVersion(randomFilename, strings.NewReader("first"), ""), ShouldBeNil)
+			body, version, err := s.GetFileWithVersion(randomFilename)
+			So(err, ShouldBeNil)
+			content, err := io.ReadAll(body)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "first")
+
+			Convey("Then it can be saved at that version", func() {
+				So(s.SaveFileIfVersion(randomFilename, strings.NewReader("second"), version), ShouldBeNil)
+				content, err := os.ReadFile(randomFilename)
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, "second")
+			})
+			Convey("Then it can not be saved at that version once it has changed", func() {
+				So(s.SaveFile(randomFilename, strings.NewReader("changed")), ShouldBeNil)
+				So(s.SaveFileIfVersion(randomFilename, strings.NewReader("second"), version), ShouldEqual, sitemap.ErrVersionConflict)
+				content, err := os.ReadFile(randomFilename)
+				So(err, ShouldBeNil)
+				So(string(content), ShouldEqual, "changed")
+			})
+			Convey("Then it can not be created again", func() {
+				So(s.SaveFileIfVersion(randomFilename, strings.NewReader("second"), ""), ShouldEqual, sitemap.ErrVersionConflict)
+			})
+		})
+	})
 }
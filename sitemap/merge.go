@@ -0,0 +1,85 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// StaticSource provides the pages merged into the full sitemaps on top of the search index documents
+type StaticSource interface {
+	URLs(lang config.Language) ([]URL, error)
+}
+
+// mergeStaticPages merges the static pages of each language into the generated sitemaps, replacing
+// their temporary files with the merged ones
+func (g *Generator) mergeStaticPages(ctx context.Context, sitemaps Files) error {
+	for lang, fileName := range sitemaps {
+		urls, err := g.static.URLs(lang)
+		if err != nil {
+			return err
+		}
+		merged, added, err := mergeURLs(fileName, urls)
+		if err != nil {
+			return fmt.Errorf("failed to merge %s static pages: %w", lang, err)
+		}
+		if err = os.Remove(fileName); err != nil {
+			log.Error(ctx, "failed to remove temporary sitemap file", err, log.Data{"filename": fileName})
+		}
+		sitemaps[lang] = merged
+		log.Info(ctx, "merged static pages into full sitemap", log.Data{"lang": lang, "static_pages": len(urls), "added": added})
+	}
+	return nil
+}
+
+// mergeURLs writes to a new temporary file the urls of the sitemap in fileName merged with urls, and
+// returns its name along with the number of urls that were not in the sitemap. When a url is in both,
+// the entry with the newer lastmod is kept.
+func mergeURLs(fileName string, urls []URL) (merged string, added int, err error) {
+	extra := make(map[string]*URL, len(urls))
+	for i := range urls {
+		extra[urls[i].Loc] = &urls[i]
+	}
+
+	merged, err = rewriteFile(fileName, func(entry URL, w *sitemapWriter) error {
+		if other, ok := extra[entry.Loc]; ok {
+			if lastmodTime(other.Lastmod).After(lastmodTime(entry.Lastmod)) {
+				entry = *other
+			}
+			delete(extra, entry.Loc)
+		}
+		return w.Write(entry)
+	}, func(w *sitemapWriter) error {
+		for i := range urls {
+			if _, ok := extra[urls[i].Loc]; !ok {
+				continue
+			}
+			delete(extra, urls[i].Loc)
+			added++
+			if err := w.Write(urls[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return merged, added, nil
+}
+
+// URL returns the entry read as one that can be written to a sitemap
+func (u *URLReader) URL() URL {
+	entry := URL{Loc: u.Loc, Lastmod: u.Lastmod}
+	if u.Alternate != nil && u.Alternate.Link != "" {
+		entry.Alternate = &AlternateURL{
+			Rel:  u.Alternate.Rel,
+			Lang: u.Alternate.Lang,
+			Link: u.Alternate.Link,
+		}
+	}
+	return entry
+}
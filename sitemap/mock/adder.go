@@ -4,6 +4,7 @@
 package mock
 
 import (
+	"context"
 	"github.com/ONSdigital/dp-sitemap/sitemap"
 	"io"
 	"sync"
@@ -19,7 +20,7 @@ var _ sitemap.Adder = &AdderMock{}
 //
 //		// make and configure a mocked sitemap.Adder
 //		mockedAdder := &AdderMock{
-//			AddFunc: func(oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
+//			AddFunc: func(ctx context.Context, oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
 //				panic("mock out the Add method")
 //			},
 //		}
@@ -30,12 +31,14 @@ var _ sitemap.Adder = &AdderMock{}
 //	}
 type AdderMock struct {
 	// AddFunc mocks the Add method.
-	AddFunc func(oldSitemap io.Reader, url *sitemap.URL) (string, int, error)
+	AddFunc func(ctx context.Context, oldSitemap io.Reader, url *sitemap.URL) (string, int, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
 		// Add holds details about calls to the Add method.
 		Add []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
 			// OldSitemap is the oldSitemap argument value.
 			OldSitemap io.Reader
 			// URL is the url argument value.
@@ -46,21 +49,23 @@ type AdderMock struct {
 }
 
 // Add calls AddFunc.
-func (mock *AdderMock) Add(oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
+func (mock *AdderMock) Add(ctx context.Context, oldSitemap io.Reader, url *sitemap.URL) (string, int, error) {
 	if mock.AddFunc == nil {
 		panic("AdderMock.AddFunc: method is nil but Adder.Add was just called")
 	}
 	callInfo := struct {
+		Ctx        context.Context
 		OldSitemap io.Reader
 		URL        *sitemap.URL
 	}{
+		Ctx:        ctx,
 		OldSitemap: oldSitemap,
 		URL:        url,
 	}
 	mock.lockAdd.Lock()
 	mock.calls.Add = append(mock.calls.Add, callInfo)
 	mock.lockAdd.Unlock()
-	return mock.AddFunc(oldSitemap, url)
+	return mock.AddFunc(ctx, oldSitemap, url)
 }
 
 // AddCalls gets all the calls that were made to Add.
@@ -68,10 +73,12 @@ func (mock *AdderMock) Add(oldSitemap io.Reader, url *sitemap.URL) (string, int,
 //
 //	len(mockedAdder.AddCalls())
 func (mock *AdderMock) AddCalls() []struct {
+	Ctx        context.Context
 	OldSitemap io.Reader
 	URL        *sitemap.URL
 } {
 	var calls []struct {
+		Ctx        context.Context
 		OldSitemap io.Reader
 		URL        *sitemap.URL
 	}
@@ -0,0 +1,119 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"sync"
+)
+
+// Ensure, that CheckableStoreMock does implement sitemap.CheckableStore.
+// If this is not the case, regenerate this file with moq.
+var _ sitemap.CheckableStore = &CheckableStoreMock{}
+
+// CheckableStoreMock is a mock implementation of sitemap.CheckableStore.
+//
+//	func TestSomethingThatUsesCheckableStore(t *testing.T) {
+//
+//		// make and configure a mocked sitemap.CheckableStore
+//		mockedCheckableStore := &CheckableStoreMock{
+//			CheckWritableFunc: func(name string) error {
+//				panic("mock out the CheckWritable method")
+//			},
+//			GetFileInfoFunc: func(name string) (*sitemap.FileInfo, error) {
+//				panic("mock out the GetFileInfo method")
+//			},
+//		}
+//
+//		// use mockedCheckableStore in code that requires sitemap.CheckableStore
+//		// and then make assertions.
+//
+//	}
+type CheckableStoreMock struct {
+	// CheckWritableFunc mocks the CheckWritable method.
+	CheckWritableFunc func(name string) error
+
+	// GetFileInfoFunc mocks the GetFileInfo method.
+	GetFileInfoFunc func(name string) (*sitemap.FileInfo, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// CheckWritable holds details about calls to the CheckWritable method.
+		CheckWritable []struct {
+			// Name is the name argument value.
+			Name string
+		}
+		// GetFileInfo holds details about calls to the GetFileInfo method.
+		GetFileInfo []struct {
+			// Name is the name argument value.
+			Name string
+		}
+	}
+	lockCheckWritable sync.RWMutex
+	lockGetFileInfo   sync.RWMutex
+}
+
+// CheckWritable calls CheckWritableFunc.
+func (mock *CheckableStoreMock) CheckWritable(name string) error {
+	if mock.CheckWritableFunc == nil {
+		panic("CheckableStoreMock.CheckWritableFunc: method is nil but CheckableStore.CheckWritable was just called")
+	}
+	callInfo := struct {
+		Name string
+	}{
+		Name: name,
+	}
+	mock.lockCheckWritable.Lock()
+	mock.calls.CheckWritable = append(mock.calls.CheckWritable, callInfo)
+	mock.lockCheckWritable.Unlock()
+	return mock.CheckWritableFunc(name)
+}
+
+// CheckWritableCalls gets all the calls that were made to CheckWritable.
+// Check the length with:
+//
+//	len(mockedCheckableStore.CheckWritableCalls())
+func (mock *CheckableStoreMock) CheckWritableCalls() []struct {
+	Name string
+} {
+	var calls []struct {
+		Name string
+	}
+	mock.lockCheckWritable.RLock()
+	calls = mock.calls.CheckWritable
+	mock.lockCheckWritable.RUnlock()
+	return calls
+}
+
+// GetFileInfo calls GetFileInfoFunc.
+func (mock *CheckableStoreMock) GetFileInfo(name string) (*sitemap.FileInfo, error) {
+	if mock.GetFileInfoFunc == nil {
+		panic("CheckableStoreMock.GetFileInfoFunc: method is nil but CheckableStore.GetFileInfo was just called")
+	}
+	callInfo := struct {
+		Name string
+	}{
+		Name: name,
+	}
+	mock.lockGetFileInfo.Lock()
+	mock.calls.GetFileInfo = append(mock.calls.GetFileInfo, callInfo)
+	mock.lockGetFileInfo.Unlock()
+	return mock.GetFileInfoFunc(name)
+}
+
+// GetFileInfoCalls gets all the calls that were made to GetFileInfo.
+// Check the length with:
+//
+//	len(mockedCheckableStore.GetFileInfoCalls())
+func (mock *CheckableStoreMock) GetFileInfoCalls() []struct {
+	Name string
+} {
+	var calls []struct {
+		Name string
+	}
+	mock.lockGetFileInfo.RLock()
+	calls = mock.calls.GetFileInfo
+	mock.lockGetFileInfo.RUnlock()
+	return calls
+}
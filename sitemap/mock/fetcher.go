@@ -19,7 +19,7 @@ var _ sitemap.Fetcher = &FetcherMock{}
 //
 //		// make and configure a mocked sitemap.Fetcher
 //		mockedFetcher := &FetcherMock{
-//			GetFullSitemapFunc: func(ctx context.Context) (sitemap.Files, error) {
+//			GetFullSitemapFunc: func(ctx context.Context) (sitemap.Files, int, error) {
 //				panic("mock out the GetFullSitemap method")
 //			},
 //			GetPageInfoFunc: func(ctx context.Context, path string) (*sitemap.PageInfo, error) {
@@ -42,7 +42,7 @@ var _ sitemap.Fetcher = &FetcherMock{}
 //	}
 type FetcherMock struct {
 	// GetFullSitemapFunc mocks the GetFullSitemap method.
-	GetFullSitemapFunc func(ctx context.Context) (sitemap.Files, error)
+	GetFullSitemapFunc func(ctx context.Context) (sitemap.Files, int, error)
 
 	// GetPageInfoFunc mocks the GetPageInfo method.
 	GetPageInfoFunc func(ctx context.Context, path string) (*sitemap.PageInfo, error)
@@ -106,7 +106,7 @@ type FetcherMock struct {
 }
 
 // GetFullSitemap calls GetFullSitemapFunc.
-func (mock *FetcherMock) GetFullSitemap(ctx context.Context) (sitemap.Files, error) {
+func (mock *FetcherMock) GetFullSitemap(ctx context.Context) (sitemap.Files, int, error) {
 	if mock.GetFullSitemapFunc == nil {
 		panic("FetcherMock.GetFullSitemapFunc: method is nil but Fetcher.GetFullSitemap was just called")
 	}
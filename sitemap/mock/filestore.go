@@ -31,9 +31,18 @@ var _ sitemap.FileStore = &FileStoreMock{}
 //			GetFileFunc: func(name string) (io.ReadCloser, error) {
 //				panic("mock out the GetFile method")
 //			},
+//			GetFileInfoFunc: func(name string) (*sitemap.FileInfo, error) {
+//				panic("mock out the GetFileInfo method")
+//			},
+//			GetFileWithVersionFunc: func(name string) (io.ReadCloser, string, error) {
+//				panic("mock out the GetFileWithVersion method")
+//			},
 //			SaveFileFunc: func(name string, body io.Reader) error {
 //				panic("mock out the SaveFile method")
 //			},
+//			SaveFileIfVersionFunc: func(name string, body io.Reader, version string) error {
+//				panic("mock out the SaveFileIfVersion method")
+//			},
 //		}
 //
 //		// use mockedFileStore in code that requires sitemap.FileStore
@@ -53,9 +62,18 @@ type FileStoreMock struct {
 	// GetFileFunc mocks the GetFile method.
 	GetFileFunc func(name string) (io.ReadCloser, error)
 
+	// GetFileInfoFunc mocks the GetFileInfo method.
+	GetFileInfoFunc func(name string) (*sitemap.FileInfo, error)
+
+	// GetFileWithVersionFunc mocks the GetFileWithVersion method.
+	GetFileWithVersionFunc func(name string) (io.ReadCloser, string, error)
+
 	// SaveFileFunc mocks the SaveFile method.
 	SaveFileFunc func(name string, body io.Reader) error
 
+	// SaveFileIfVersionFunc mocks the SaveFileIfVersion method.
+	SaveFileIfVersionFunc func(name string, body io.Reader, version string) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// CopyFile holds details about calls to the CopyFile method.
@@ -80,6 +98,16 @@ type FileStoreMock struct {
 			// Name is the name argument value.
 			Name string
 		}
+		// GetFileInfo holds details about calls to the GetFileInfo method.
+		GetFileInfo []struct {
+			// Name is the name argument value.
+			Name string
+		}
+		// GetFileWithVersion holds details about calls to the GetFileWithVersion method.
+		GetFileWithVersion []struct {
+			// Name is the name argument value.
+			Name string
+		}
 		// SaveFile holds details about calls to the SaveFile method.
 		SaveFile []struct {
 			// Name is the name argument value.
@@ -87,12 +115,24 @@ type FileStoreMock struct {
 			// Body is the body argument value.
 			Body io.Reader
 		}
+		// SaveFileIfVersion holds details about calls to the SaveFileIfVersion method.
+		SaveFileIfVersion []struct {
+			// Name is the name argument value.
+			Name string
+			// Body is the body argument value.
+			Body io.Reader
+			// Version is the version argument value.
+			Version string
+		}
 	}
-	lockCopyFile   sync.RWMutex
-	lockCreateFile sync.RWMutex
-	lockDeleteFile sync.RWMutex
-	lockGetFile    sync.RWMutex
-	lockSaveFile   sync.RWMutex
+	lockCopyFile           sync.RWMutex
+	lockCreateFile         sync.RWMutex
+	lockDeleteFile         sync.RWMutex
+	lockGetFile            sync.RWMutex
+	lockGetFileInfo        sync.RWMutex
+	lockGetFileWithVersion sync.RWMutex
+	lockSaveFile           sync.RWMutex
+	lockSaveFileIfVersion  sync.RWMutex
 }
 
 // CopyFile calls CopyFileFunc.
@@ -227,6 +267,70 @@ func (mock *FileStoreMock) GetFileCalls() []struct {
 	return calls
 }
 
+// GetFileInfo calls GetFileInfoFunc.
+func (mock *FileStoreMock) GetFileInfo(name string) (*sitemap.FileInfo, error) {
+	if mock.GetFileInfoFunc == nil {
+		panic("FileStoreMock.GetFileInfoFunc: method is nil but FileStore.GetFileInfo was just called")
+	}
+	callInfo := struct {
+		Name string
+	}{
+		Name: name,
+	}
+	mock.lockGetFileInfo.Lock()
+	mock.calls.GetFileInfo = append(mock.calls.GetFileInfo, callInfo)
+	mock.lockGetFileInfo.Unlock()
+	return mock.GetFileInfoFunc(name)
+}
+
+// GetFileInfoCalls gets all the calls that were made to GetFileInfo.
+// Check the length with:
+//
+//	len(mockedFileStore.GetFileInfoCalls())
+func (mock *FileStoreMock) GetFileInfoCalls() []struct {
+	Name string
+} {
+	var calls []struct {
+		Name string
+	}
+	mock.lockGetFileInfo.RLock()
+	calls = mock.calls.GetFileInfo
+	mock.lockGetFileInfo.RUnlock()
+	return calls
+}
+
+// GetFileWithVersion calls GetFileWithVersionFunc.
+func (mock *FileStoreMock) GetFileWithVersion(name string) (io.ReadCloser, string, error) {
+	if mock.GetFileWithVersionFunc == nil {
+		panic("FileStoreMock.GetFileWithVersionFunc: method is nil but FileStore.GetFileWithVersion was just called")
+	}
+	callInfo := struct {
+		Name string
+	}{
+		Name: name,
+	}
+	mock.lockGetFileWithVersion.Lock()
+	mock.calls.GetFileWithVersion = append(mock.calls.GetFileWithVersion, callInfo)
+	mock.lockGetFileWithVersion.Unlock()
+	return mock.GetFileWithVersionFunc(name)
+}
+
+// GetFileWithVersionCalls gets all the calls that were made to GetFileWithVersion.
+// Check the length with:
+//
+//	len(mockedFileStore.GetFileWithVersionCalls())
+func (mock *FileStoreMock) GetFileWithVersionCalls() []struct {
+	Name string
+} {
+	var calls []struct {
+		Name string
+	}
+	mock.lockGetFileWithVersion.RLock()
+	calls = mock.calls.GetFileWithVersion
+	mock.lockGetFileWithVersion.RUnlock()
+	return calls
+}
+
 // SaveFile calls SaveFileFunc.
 func (mock *FileStoreMock) SaveFile(name string, body io.Reader) error {
 	if mock.SaveFileFunc == nil {
@@ -262,3 +366,43 @@ func (mock *FileStoreMock) SaveFileCalls() []struct {
 	mock.lockSaveFile.RUnlock()
 	return calls
 }
+
+// SaveFileIfVersion calls SaveFileIfVersionFunc.
+func (mock *FileStoreMock) SaveFileIfVersion(name string, body io.Reader, version string) error {
+	if mock.SaveFileIfVersionFunc == nil {
+		panic("FileStoreMock.SaveFileIfVersionFunc: method is nil but FileStore.SaveFileIfVersion was just called")
+	}
+	callInfo := struct {
+		Name    string
+		Body    io.Reader
+		Version string
+	}{
+		Name:    name,
+		Body:    body,
+		Version: version,
+	}
+	mock.lockSaveFileIfVersion.Lock()
+	mock.calls.SaveFileIfVersion = append(mock.calls.SaveFileIfVersion, callInfo)
+	mock.lockSaveFileIfVersion.Unlock()
+	return mock.SaveFileIfVersionFunc(name, body, version)
+}
+
+// SaveFileIfVersionCalls gets all the calls that were made to SaveFileIfVersion.
+// Check the length with:
+//
+//	len(mockedFileStore.SaveFileIfVersionCalls())
+func (mock *FileStoreMock) SaveFileIfVersionCalls() []struct {
+	Name    string
+	Body    io.Reader
+	Version string
+} {
+	var calls []struct {
+		Name    string
+		Body    io.Reader
+		Version string
+	}
+	mock.lockSaveFileIfVersion.RLock()
+	calls = mock.calls.SaveFileIfVersion
+	mock.lockSaveFileIfVersion.RUnlock()
+	return calls
+}
@@ -0,0 +1,88 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"sync"
+)
+
+// Ensure, that RobotsRulesMock does implement sitemap.RobotsRules.
+// If this is not the case, regenerate this file with moq.
+var _ sitemap.RobotsRules = &RobotsRulesMock{}
+
+// RobotsRulesMock is a mock implementation of sitemap.RobotsRules.
+//
+//	func TestSomethingThatUsesRobotsRules(t *testing.T) {
+//
+//		// make and configure a mocked sitemap.RobotsRules
+//		mockedRobotsRules := &RobotsRulesMock{
+//			AllowedFunc: func(lang config.Language, userAgent string, path string) bool {
+//				panic("mock out the Allowed method")
+//			},
+//		}
+//
+//		// use mockedRobotsRules in code that requires sitemap.RobotsRules
+//		// and then make assertions.
+//
+//	}
+type RobotsRulesMock struct {
+	// AllowedFunc mocks the Allowed method.
+	AllowedFunc func(lang config.Language, userAgent string, path string) bool
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Allowed holds details about calls to the Allowed method.
+		Allowed []struct {
+			// Lang is the lang argument value.
+			Lang config.Language
+			// UserAgent is the userAgent argument value.
+			UserAgent string
+			// Path is the path argument value.
+			Path string
+		}
+	}
+	lockAllowed sync.RWMutex
+}
+
+// Allowed calls AllowedFunc.
+func (mock *RobotsRulesMock) Allowed(lang config.Language, userAgent string, path string) bool {
+	if mock.AllowedFunc == nil {
+		panic("RobotsRulesMock.AllowedFunc: method is nil but RobotsRules.Allowed was just called")
+	}
+	callInfo := struct {
+		Lang      config.Language
+		UserAgent string
+		Path      string
+	}{
+		Lang:      lang,
+		UserAgent: userAgent,
+		Path:      path,
+	}
+	mock.lockAllowed.Lock()
+	mock.calls.Allowed = append(mock.calls.Allowed, callInfo)
+	mock.lockAllowed.Unlock()
+	return mock.AllowedFunc(lang, userAgent, path)
+}
+
+// AllowedCalls gets all the calls that were made to Allowed.
+// Check the length with:
+//
+//	len(mockedRobotsRules.AllowedCalls())
+func (mock *RobotsRulesMock) AllowedCalls() []struct {
+	Lang      config.Language
+	UserAgent string
+	Path      string
+} {
+	var calls []struct {
+		Lang      config.Language
+		UserAgent string
+		Path      string
+	}
+	mock.lockAllowed.RLock()
+	calls = mock.calls.Allowed
+	mock.lockAllowed.RUnlock()
+	return calls
+}
@@ -5,6 +5,7 @@ package mock
 
 import (
 	"github.com/ONSdigital/dp-sitemap/sitemap"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"io"
 	"sync"
@@ -23,12 +24,21 @@ var _ sitemap.S3Client = &S3ClientMock{}
 //			BucketNameFunc: func() string {
 //				panic("mock out the BucketName method")
 //			},
+//			DeleteFunc: func(key string) error {
+//				panic("mock out the Delete method")
+//			},
 //			GetFunc: func(key string) (io.ReadCloser, *int64, error) {
 //				panic("mock out the Get method")
 //			},
+//			HeadFunc: func(key string) (*s3.HeadObjectOutput, error) {
+//				panic("mock out the Head method")
+//			},
 //			UploadFunc: func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
 //				panic("mock out the Upload method")
 //			},
+//			ValidateBucketFunc: func() error {
+//				panic("mock out the ValidateBucket method")
+//			},
 //		}
 //
 //		// use mockedS3Client in code that requires sitemap.S3Client
@@ -39,22 +49,41 @@ type S3ClientMock struct {
 	// BucketNameFunc mocks the BucketName method.
 	BucketNameFunc func() string
 
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(key string) error
+
 	// GetFunc mocks the Get method.
 	GetFunc func(key string) (io.ReadCloser, *int64, error)
 
+	// HeadFunc mocks the Head method.
+	HeadFunc func(key string) (*s3.HeadObjectOutput, error)
+
 	// UploadFunc mocks the Upload method.
 	UploadFunc func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error)
 
+	// ValidateBucketFunc mocks the ValidateBucket method.
+	ValidateBucketFunc func() error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// BucketName holds details about calls to the BucketName method.
 		BucketName []struct {
 		}
+		// Delete holds details about calls to the Delete method.
+		Delete []struct {
+			// Key is the key argument value.
+			Key string
+		}
 		// Get holds details about calls to the Get method.
 		Get []struct {
 			// Key is the key argument value.
 			Key string
 		}
+		// Head holds details about calls to the Head method.
+		Head []struct {
+			// Key is the key argument value.
+			Key string
+		}
 		// Upload holds details about calls to the Upload method.
 		Upload []struct {
 			// Input is the input argument value.
@@ -62,10 +91,16 @@ type S3ClientMock struct {
 			// Options is the options argument value.
 			Options []func(*s3manager.Uploader)
 		}
+		// ValidateBucket holds details about calls to the ValidateBucket method.
+		ValidateBucket []struct {
+		}
 	}
-	lockBucketName sync.RWMutex
-	lockGet        sync.RWMutex
-	lockUpload     sync.RWMutex
+	lockBucketName     sync.RWMutex
+	lockDelete         sync.RWMutex
+	lockGet            sync.RWMutex
+	lockHead           sync.RWMutex
+	lockUpload         sync.RWMutex
+	lockValidateBucket sync.RWMutex
 }
 
 // BucketName calls BucketNameFunc.
@@ -95,6 +130,38 @@ func (mock *S3ClientMock) BucketNameCalls() []struct {
 	return calls
 }
 
+// Delete calls DeleteFunc.
+func (mock *S3ClientMock) Delete(key string) error {
+	if mock.DeleteFunc == nil {
+		panic("S3ClientMock.DeleteFunc: method is nil but S3Client.Delete was just called")
+	}
+	callInfo := struct {
+		Key string
+	}{
+		Key: key,
+	}
+	mock.lockDelete.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, callInfo)
+	mock.lockDelete.Unlock()
+	return mock.DeleteFunc(key)
+}
+
+// DeleteCalls gets all the calls that were made to Delete.
+// Check the length with:
+//
+//	len(mockedS3Client.DeleteCalls())
+func (mock *S3ClientMock) DeleteCalls() []struct {
+	Key string
+} {
+	var calls []struct {
+		Key string
+	}
+	mock.lockDelete.RLock()
+	calls = mock.calls.Delete
+	mock.lockDelete.RUnlock()
+	return calls
+}
+
 // Get calls GetFunc.
 func (mock *S3ClientMock) Get(key string) (io.ReadCloser, *int64, error) {
 	if mock.GetFunc == nil {
@@ -127,6 +194,38 @@ func (mock *S3ClientMock) GetCalls() []struct {
 	return calls
 }
 
+// Head calls HeadFunc.
+func (mock *S3ClientMock) Head(key string) (*s3.HeadObjectOutput, error) {
+	if mock.HeadFunc == nil {
+		panic("S3ClientMock.HeadFunc: method is nil but S3Client.Head was just called")
+	}
+	callInfo := struct {
+		Key string
+	}{
+		Key: key,
+	}
+	mock.lockHead.Lock()
+	mock.calls.Head = append(mock.calls.Head, callInfo)
+	mock.lockHead.Unlock()
+	return mock.HeadFunc(key)
+}
+
+// HeadCalls gets all the calls that were made to Head.
+// Check the length with:
+//
+//	len(mockedS3Client.HeadCalls())
+func (mock *S3ClientMock) HeadCalls() []struct {
+	Key string
+} {
+	var calls []struct {
+		Key string
+	}
+	mock.lockHead.RLock()
+	calls = mock.calls.Head
+	mock.lockHead.RUnlock()
+	return calls
+}
+
 // Upload calls UploadFunc.
 func (mock *S3ClientMock) Upload(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
 	if mock.UploadFunc == nil {
@@ -162,3 +261,30 @@ func (mock *S3ClientMock) UploadCalls() []struct {
 	mock.lockUpload.RUnlock()
 	return calls
 }
+
+// ValidateBucket calls ValidateBucketFunc.
+func (mock *S3ClientMock) ValidateBucket() error {
+	if mock.ValidateBucketFunc == nil {
+		panic("S3ClientMock.ValidateBucketFunc: method is nil but S3Client.ValidateBucket was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockValidateBucket.Lock()
+	mock.calls.ValidateBucket = append(mock.calls.ValidateBucket, callInfo)
+	mock.lockValidateBucket.Unlock()
+	return mock.ValidateBucketFunc()
+}
+
+// ValidateBucketCalls gets all the calls that were made to ValidateBucket.
+// Check the length with:
+//
+//	len(mockedS3Client.ValidateBucketCalls())
+func (mock *S3ClientMock) ValidateBucketCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockValidateBucket.RLock()
+	calls = mock.calls.ValidateBucket
+	mock.lockValidateBucket.RUnlock()
+	return calls
+}
@@ -0,0 +1,21 @@
+package sitemap
+
+import (
+	"context"
+	"io"
+)
+
+type DefaultRemover struct{}
+
+// Remove writes the old sitemap, without the entries for the given location, to a new temporary sitemap file
+func (r *DefaultRemover) Remove(ctx context.Context, oldSitemap io.Reader, loc string) (fileName string, size int, err error) {
+	return rewriteSitemap(ctx, oldSitemap, func(urls []URL) []URL {
+		kept := urls[:0]
+		for i := range urls {
+			if urls[i].Loc != loc {
+				kept = append(kept, urls[i])
+			}
+		}
+		return kept
+	})
+}
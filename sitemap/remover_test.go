@@ -0,0 +1,70 @@
+package sitemap_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRemover(t *testing.T) {
+	Convey("When old sitemap contains the url to remove", t, func() {
+		oldSitemap := strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+		<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+		  <url>
+			<loc>a</loc>
+			<lastmod>b</lastmod>
+			<xhtml:link rel="A" hreflang="B" href="C"></xhtml:link>
+		  </url>
+		  <url>
+			<loc>c</loc>
+			<lastmod>d</lastmod>
+		  </url>
+		</urlset>`)
+
+		r := &sitemap.DefaultRemover{}
+		filename, size, err := r.Remove(context.Background(), oldSitemap, "c")
+		defer func() {
+			removeErr := os.Remove(filename)
+			So(removeErr, ShouldBeNil)
+		}()
+
+		Convey("Remover should return with no error", func() {
+			So(err, ShouldBeNil)
+		})
+		Convey("Sitemap size should be correct", func() {
+			So(size, ShouldEqual, 1)
+		})
+		Convey("Sitemap should no longer include the removed url", func() {
+			sitemapContent, err := os.ReadFile(filename)
+			So(err, ShouldBeNil)
+			So(string(sitemapContent), ShouldEqual, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>a</loc>
+    <lastmod>b</lastmod>
+    <xhtml:link rel="A" hreflang="B" href="C"></xhtml:link>
+  </url>
+</urlset>`)
+		})
+	})
+
+	Convey("When old sitemap does not contain the url to remove", t, func() {
+		oldSitemap := strings.NewReader(`<urlset><url><loc>a</loc><lastmod>b</lastmod></url></urlset>`)
+
+		r := &sitemap.DefaultRemover{}
+		filename, size, err := r.Remove(context.Background(), oldSitemap, "c")
+		defer func() {
+			removeErr := os.Remove(filename)
+			So(removeErr, ShouldBeNil)
+		}()
+
+		Convey("Sitemap should be unchanged", func() {
+			So(err, ShouldBeNil)
+			So(size, ShouldEqual, 1)
+		})
+	})
+}
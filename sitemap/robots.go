@@ -0,0 +1,188 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dp-sitemap/config"
+	"github.com/ONSdigital/dp-sitemap/metrics"
+	"github.com/ONSdigital/dp-sitemap/robotseo"
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+//go:generate moq -out mock/robotsrules.go -pkg mock . RobotsRules
+
+// Robots check modes
+const (
+	RobotsCheckWarn = "warn"
+	RobotsCheckDrop = "drop"
+)
+
+// maxBlockedExamples is the number of blocked urls of a sitemap logged
+const maxBlockedExamples = 10
+
+// ErrInvalidRobotsCheck is returned when the robots check mode is neither warn nor drop
+var ErrInvalidRobotsCheck = errors.New("invalid robots check mode")
+
+// robotsCheckUserAgents are the user agents sitemap urls must be allowed for
+var robotsCheckUserAgents = []string{"*", "Googlebot"}
+
+// RobotsRules tell whether the robots rules of a language allow a user agent to crawl a path
+type RobotsRules interface {
+	Allowed(lang config.Language, userAgent, path string) bool
+}
+
+// RobotsCheck finds the sitemap urls disallowed by the robots rules for all user agents or Googlebot, which search
+// engines report as errors, and drops them from the sitemaps or only warns about them
+type RobotsCheck struct {
+	rules RobotsRules
+	drop  bool
+}
+
+// NewRobotsCheck returns a check of the sitemap urls against rules, dropping the disallowed ones if mode is drop or
+// warning about them if it is warn. There is no check if mode is empty.
+func NewRobotsCheck(rules RobotsRules, mode string) (*RobotsCheck, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case RobotsCheckWarn, RobotsCheckDrop:
+		return &RobotsCheck{rules: rules, drop: mode == RobotsCheckDrop}, nil
+	default:
+		return nil, fmt.Errorf("%w %q, expected %s or %s", ErrInvalidRobotsCheck, mode, RobotsCheckWarn, RobotsCheckDrop)
+	}
+}
+
+// blocked returns whether the robots rules of a language disallow the url loc for any of the checked user agents
+func (c *RobotsCheck) blocked(lang config.Language, loc string) bool {
+	path, err := robotseo.URLPath(loc)
+	if err != nil {
+		return false
+	}
+	for _, userAgent := range robotsCheckUserAgents {
+		if !c.rules.Allowed(lang, userAgent, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// report logs and counts the blocked urls of a sitemap
+func (c *RobotsCheck) report(ctx context.Context, lang config.Language, sitemap string, blocked []string) {
+	if len(blocked) == 0 {
+		return
+	}
+	metrics.RobotsBlockedURLs.WithLabelValues(lang.String(), sitemap).Add(float64(len(blocked)))
+	examples := blocked
+	if len(examples) > maxBlockedExamples {
+		examples = examples[:maxBlockedExamples]
+	}
+	logData := log.Data{"lang": lang, "sitemap": sitemap, "blocked": len(blocked), "examples": examples}
+	if c.drop {
+		log.Warn(ctx, "dropped sitemap urls disallowed by the robots rules", logData)
+		return
+	}
+	log.Warn(ctx, "sitemap urls disallowed by the robots rules", logData)
+}
+
+// filterURLs returns the urls of a sitemap of a language without the blocked ones if they are dropped, along with
+// the number of blocked urls
+func (c *RobotsCheck) filterURLs(ctx context.Context, lang config.Language, sitemap string, urls []URL) ([]URL, int) {
+	kept := make([]URL, 0, len(urls))
+	var blocked []string
+	for i := range urls {
+		if c.blocked(lang, urls[i].Loc) {
+			blocked = append(blocked, urls[i].Loc)
+			if c.drop {
+				continue
+			}
+		}
+		kept = append(kept, urls[i])
+	}
+	c.report(ctx, lang, sitemap, blocked)
+	return kept, len(blocked)
+}
+
+// FilterPage returns the versions of a page in each language, without the ones disallowed by the robots rules of
+// their language if they are dropped, nor the alternate links to them. It returns urls unchanged if c is nil.
+func (c *RobotsCheck) FilterPage(ctx context.Context, sitemap string, urls map[config.Language]*URL) map[config.Language]*URL {
+	if c == nil {
+		return urls
+	}
+	kept := make(map[config.Language]*URL, len(urls))
+	dropped := map[string]bool{}
+	for lang, u := range urls {
+		if u == nil {
+			continue
+		}
+		filtered, _ := c.filterURLs(ctx, lang, sitemap, []URL{*u})
+		if len(filtered) == 0 {
+			dropped[u.Loc] = true
+			continue
+		}
+		kept[lang] = &filtered[0]
+	}
+	for _, u := range kept {
+		if u.Alternate != nil && dropped[u.Alternate.Link] {
+			u.Alternate = nil
+		}
+	}
+	return kept
+}
+
+// checkRobots checks the urls of the generated sitemaps against the robots rules, replacing their temporary files
+// with ones without the blocked urls if they are dropped, and returns the number of blocked urls of each language
+func (g *Generator) checkRobots(ctx context.Context, sitemaps Files) (URLCounts, error) {
+	counts := URLCounts{}
+	for lang, fileName := range sitemaps {
+		filtered, blocked, err := g.robots.filterFile(lang, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s sitemap urls against the robots rules: %w", lang, err)
+		}
+		g.robots.report(ctx, lang, "full", blocked)
+		counts[lang] = len(blocked)
+		if filtered == fileName {
+			continue
+		}
+		if err = os.Remove(fileName); err != nil {
+			log.Error(ctx, "failed to remove temporary sitemap file", err, log.Data{"filename": fileName})
+		}
+		sitemaps[lang] = filtered
+	}
+	return counts, nil
+}
+
+// filterFile returns the blocked urls of a sitemap file of a language. If they are dropped, it writes the other urls to
+// a new temporary file and returns its name, otherwise it returns fileName.
+func (c *RobotsCheck) filterFile(lang config.Language, fileName string) (filtered string, blocked []string, err error) {
+	if c.drop {
+		filtered, err = rewriteFile(fileName, func(u URL, w *sitemapWriter) error {
+			if c.blocked(lang, u.Loc) {
+				blocked = append(blocked, u.Loc)
+				return nil
+			}
+			return w.Write(u)
+		}, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		return filtered, blocked, nil
+	}
+
+	in, err := os.Open(fileName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open sitemap: %w", err)
+	}
+	defer in.Close()
+	err = eachURL(in, func(u URL) error {
+		if c.blocked(lang, u.Loc) {
+			blocked = append(blocked, u.Loc)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return fileName, blocked, nil
+}
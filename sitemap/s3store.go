@@ -1,19 +1,31 @@
 package sitemap
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
+// awsCodeNotFound is the error code returned by s3 HEAD requests for missing objects
+const awsCodeNotFound = "NotFound"
+
 //go:generate moq -out mock/s3client.go -pkg mock . S3Client
 
 type S3Client interface {
 	Upload(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error)
 	Get(key string) (io.ReadCloser, *int64, error)
+	Head(key string) (*s3.HeadObjectOutput, error)
+	Delete(key string) error
 	BucketName() string
+	ValidateBucket() error
 }
 
 type S3Store struct {
@@ -47,6 +59,61 @@ func (s *S3Store) GetFile(name string) (body io.ReadCloser, err error) {
 	return file, nil
 }
 
+func (s *S3Store) GetFileInfo(name string) (*FileInfo, error) {
+	head, err := s.client.Head(name)
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == awsCodeNotFound {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to get file info from s3: %w", err)
+	}
+	return &FileInfo{
+		Size:    aws.Int64Value(head.ContentLength),
+		ModTime: aws.TimeValue(head.LastModified),
+		ETag:    aws.StringValue(head.ETag),
+	}, nil
+}
+
+// GetFileWithVersion returns the content of a file along with its version (ETag),
+// which can be passed to SaveFileIfVersion to only overwrite the file if it has not changed since
+func (s *S3Store) GetFileWithVersion(name string) (body io.ReadCloser, version string, err error) {
+	// the version is read before the content, so that a concurrent change
+	// in between makes a conditional save fail rather than go unnoticed
+	info, err := s.GetFileInfo(name)
+	if err != nil {
+		return nil, "", err
+	}
+	body, _, err = s.client.Get(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get file from s3: %w", err)
+	}
+	return body, info.ETag, nil
+}
+
+// SaveFileIfVersion saves a file only if its version (ETag) in the bucket is still the given one,
+// or if it does not exist when version is empty. ErrVersionConflict is returned otherwise.
+func (s *S3Store) SaveFileIfVersion(name string, body io.Reader, version string) error {
+	condition := map[string]string{"If-None-Match": "*"}
+	if version != "" {
+		condition = map[string]string{"If-Match": version}
+	}
+	bucket := s.client.BucketName()
+	_, err := s.client.Upload(&s3manager.UploadInput{
+		Body:   body,
+		Bucket: &bucket,
+		Key:    &name,
+	}, s3manager.WithUploaderRequestOptions(request.WithSetRequestHeaders(condition)))
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && (reqErr.StatusCode() == http.StatusPreconditionFailed || reqErr.StatusCode() == http.StatusConflict) {
+		return ErrVersionConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload file to s3: %w", err)
+	}
+	return nil
+}
+
 func (s *S3Store) CopyFile(_ io.Reader, _ io.Writer) error {
 	return nil
 }
@@ -55,6 +122,22 @@ func (s *S3Store) CreateFile(_ string) (io.ReadWriteCloser, error) {
 	return nil, nil
 }
 
-func (s *S3Store) DeleteFile(_ string) error {
+func (s *S3Store) DeleteFile(name string) error {
+	if err := s.client.Delete(name); err != nil {
+		return fmt.Errorf("failed to delete file from s3: %w", err)
+	}
 	return nil
 }
+
+// CheckWritable checks that the bucket exists and that name can be written to it, deleting it afterwards
+func (s *S3Store) CheckWritable(name string) error {
+	err := s.client.ValidateBucket()
+	if err != nil {
+		return fmt.Errorf("failed to validate s3 bucket: %w", err)
+	}
+	err = s.SaveFile(name, strings.NewReader(""))
+	if err != nil {
+		return err
+	}
+	return s.DeleteFile(name)
+}
@@ -3,11 +3,17 @@ package sitemap_test
 import (
 	"errors"
 	"io"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ONSdigital/dp-sitemap/sitemap"
 	"github.com/ONSdigital/dp-sitemap/sitemap/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -122,4 +128,238 @@ func TestS3Store(t *testing.T) {
 			So(s3.GetCalls()[0].Key, ShouldEqual, fileKey)
 		})
 	})
+
+	Convey("When s3 head returns not found", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.HeadFunc = func(key string) (*s3.HeadObjectOutput, error) {
+			return nil, awserr.New("NotFound", "not found", nil)
+		}
+
+		s := sitemap.NewS3Store(s3Client)
+		info, err := s.GetFileInfo(fileKey)
+
+		Convey("S3Store should return a not found error", func() {
+			So(err, ShouldEqual, sitemap.ErrFileNotFound)
+			So(info, ShouldBeNil)
+		})
+	})
+
+	Convey("When s3 head fails", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.HeadFunc = func(key string) (*s3.HeadObjectOutput, error) {
+			return nil, errors.New("s3 head error")
+		}
+
+		s := sitemap.NewS3Store(s3Client)
+		_, err := s.GetFileInfo(fileKey)
+
+		Convey("S3Store should return correct error", func() {
+			So(err.Error(), ShouldContainSubstring, "failed to get file info from s3")
+			So(err.Error(), ShouldContainSubstring, "s3 head error")
+		})
+	})
+
+	Convey("When s3 head succeeds", t, func() {
+		modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		s3Client := &mock.S3ClientMock{}
+		s3Client.HeadFunc = func(key string) (*s3.HeadObjectOutput, error) {
+			return &s3.HeadObjectOutput{
+				ContentLength: aws.Int64(12),
+				LastModified:  aws.Time(modTime),
+				ETag:          aws.String(`"abc"`),
+			}, nil
+		}
+
+		s := sitemap.NewS3Store(s3Client)
+		info, err := s.GetFileInfo(fileKey)
+
+		Convey("S3Store should return the file info", func() {
+			So(err, ShouldBeNil)
+			So(info.Size, ShouldEqual, 12)
+			So(info.ModTime, ShouldEqual, modTime)
+			So(info.ETag, ShouldEqual, `"abc"`)
+		})
+		Convey("S3Store should pass correct file key to s3 head", func() {
+			So(s3Client.HeadCalls()[0].Key, ShouldEqual, fileKey)
+		})
+	})
+
+	Convey("When the s3 bucket is not valid", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.ValidateBucketFunc = func() error { return errors.New("no such bucket") }
+
+		s := sitemap.NewS3Store(s3Client)
+		err := s.CheckWritable(fileKey)
+
+		Convey("CheckWritable should return correct error without uploading", func() {
+			So(err.Error(), ShouldContainSubstring, "failed to validate s3 bucket")
+			So(err.Error(), ShouldContainSubstring, "no such bucket")
+			So(s3Client.UploadCalls(), ShouldHaveLength, 0)
+		})
+	})
+
+	Convey("When the s3 bucket is writable", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.ValidateBucketFunc = func() error { return nil }
+		s3Client.BucketNameFunc = func() string { return bucket }
+		s3Client.UploadFunc = func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+			return &s3manager.UploadOutput{}, nil
+		}
+		s3Client.DeleteFunc = func(key string) error { return nil }
+
+		s := sitemap.NewS3Store(s3Client)
+		err := s.CheckWritable(fileKey)
+
+		Convey("CheckWritable should upload the check file", func() {
+			So(err, ShouldBeNil)
+			So(s3Client.UploadCalls(), ShouldHaveLength, 1)
+			So(*s3Client.UploadCalls()[0].Input.Key, ShouldEqual, fileKey)
+		})
+		Convey("CheckWritable should delete the check file afterwards", func() {
+			So(s3Client.DeleteCalls(), ShouldHaveLength, 1)
+			So(s3Client.DeleteCalls()[0].Key, ShouldEqual, fileKey)
+		})
+	})
+
+	Convey("When the check file can not be deleted from the s3 bucket", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.ValidateBucketFunc = func() error { return nil }
+		s3Client.BucketNameFunc = func() string { return bucket }
+		s3Client.UploadFunc = func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+			return &s3manager.UploadOutput{}, nil
+		}
+		s3Client.DeleteFunc = func(key string) error { return errors.New("access denied") }
+
+		s := sitemap.NewS3Store(s3Client)
+		err := s.CheckWritable(fileKey)
+
+		Convey("CheckWritable should return correct error", func() {
+			So(err.Error(), ShouldContainSubstring, "failed to delete file from s3")
+		})
+	})
+
+	Convey("When a file is read with its version", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.HeadFunc = func(key string) (*s3.HeadObjectOutput, error) {
+			return &s3.HeadObjectOutput{ETag: aws.String(`"v1"`)}, nil
+		}
+		s3Client.GetFunc = func(key string) (io.ReadCloser, *int64, error) {
+			return io.NopCloser(strings.NewReader("file content")), nil, nil
+		}
+
+		s := sitemap.NewS3Store(s3Client)
+		body, version, err := s.GetFileWithVersion(fileKey)
+
+		Convey("S3Store should return the content and the ETag", func() {
+			So(err, ShouldBeNil)
+			content, err := io.ReadAll(body)
+			So(err, ShouldBeNil)
+			So(string(content), ShouldEqual, "file content")
+			So(version, ShouldEqual, `"v1"`)
+		})
+	})
+
+	Convey("When a file that does not exist is read with its version", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.HeadFunc = func(key string) (*s3.HeadObjectOutput, error) {
+			return nil, awserr.New("NotFound", "not found", nil)
+		}
+
+		s := sitemap.NewS3Store(s3Client)
+		_, _, err := s.GetFileWithVersion(fileKey)
+
+		Convey("S3Store should return a not found error", func() {
+			So(err, ShouldEqual, sitemap.ErrFileNotFound)
+			So(s3Client.GetCalls(), ShouldHaveLength, 0)
+		})
+	})
+
+	Convey("Given a file saved if its version is unchanged", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.BucketNameFunc = func() string { return bucket }
+		var headers http.Header
+		var uploadErr error
+		s3Client.UploadFunc = func(input *s3manager.UploadInput, options ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
+			headers = uploadHeaders(options)
+			return &s3manager.UploadOutput{}, uploadErr
+		}
+		s := sitemap.NewS3Store(s3Client)
+
+		Convey("When a version is given", func() {
+			err := s.SaveFileIfVersion(fileKey, strings.NewReader("file content"), `"v1"`)
+
+			Convey("Then the upload is conditional on the version", func() {
+				So(err, ShouldBeNil)
+				So(headers.Get("If-Match"), ShouldEqual, `"v1"`)
+				So(headers.Get("If-None-Match"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When no version is given", func() {
+			err := s.SaveFileIfVersion(fileKey, strings.NewReader("file content"), "")
+
+			Convey("Then the upload is conditional on the file not existing", func() {
+				So(err, ShouldBeNil)
+				So(headers.Get("If-None-Match"), ShouldEqual, "*")
+				So(headers.Get("If-Match"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the version has changed", func() {
+			uploadErr = awserr.NewRequestFailure(awserr.New("PreconditionFailed", "precondition failed", nil), http.StatusPreconditionFailed, "")
+			err := s.SaveFileIfVersion(fileKey, strings.NewReader("file content"), `"v1"`)
+
+			Convey("Then a version conflict is returned", func() {
+				So(err, ShouldEqual, sitemap.ErrVersionConflict)
+			})
+		})
+
+		Convey("When the upload fails", func() {
+			uploadErr = errors.New("uploader error")
+			err := s.SaveFileIfVersion(fileKey, strings.NewReader("file content"), `"v1"`)
+
+			Convey("Then the upload error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "failed to upload file to s3")
+				So(err.Error(), ShouldContainSubstring, "uploader error")
+			})
+		})
+	})
+
+	Convey("When a file is deleted", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.DeleteFunc = func(key string) error { return nil }
+
+		s := sitemap.NewS3Store(s3Client)
+		err := s.DeleteFile(fileKey)
+
+		Convey("S3Store should delete the object of the file", func() {
+			So(err, ShouldBeNil)
+			So(s3Client.DeleteCalls(), ShouldHaveLength, 1)
+			So(s3Client.DeleteCalls()[0].Key, ShouldEqual, fileKey)
+		})
+	})
+
+	Convey("When s3 delete fails", t, func() {
+		s3Client := &mock.S3ClientMock{}
+		s3Client.DeleteFunc = func(key string) error { return errors.New("s3 delete error") }
+
+		s := sitemap.NewS3Store(s3Client)
+		err := s.DeleteFile(fileKey)
+
+		Convey("S3Store should return correct error", func() {
+			So(err.Error(), ShouldContainSubstring, "failed to delete file from s3")
+			So(err.Error(), ShouldContainSubstring, "s3 delete error")
+		})
+	})
+}
+
+// uploadHeaders returns the headers set on upload requests by the given uploader options
+func uploadHeaders(options []func(*s3manager.Uploader)) http.Header {
+	uploader := &s3manager.Uploader{}
+	for _, opt := range options {
+		opt(uploader)
+	}
+	req := &request.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	req.ApplyOptions(uploader.RequestOptions...)
+	return req.HTTPRequest.Header
 }
@@ -3,7 +3,6 @@ package sitemap
 import "embed"
 
 //go:embed static/sitemap_en.json
-//go:embed static/sitemap_cy.json
 
 var folder embed.FS
 
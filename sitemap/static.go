@@ -2,69 +2,396 @@ package sitemap
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ONSdigital/dp-sitemap/config"
+	"gopkg.in/yaml.v3"
 )
 
+// staticDateFormats are the formats of the release dates of static pages, which are converted to W3C dates
+var staticDateFormats = []string{"2006-01-02", "02-01-2006"}
+
+// staticLanguages are the languages of the static sitemaps
+var staticLanguages = []config.Language{config.English, config.Welsh}
+
+// staticSitemapExtensions are the extensions of the supported static page list formats, in order of precedence
+var staticSitemapExtensions = []string{".json", ".yaml", ".yml", ".csv"}
+
 type StaticURL struct {
-	URL         string `json:"url"`
-	ReleaseDate string `json:"releaseDate"`
-	HasAltLang  bool   `json:"hasAltLang"`
+	URL         string                         `json:"url" yaml:"url"`
+	ReleaseDate string                         `json:"releaseDate" yaml:"releaseDate"`
+	HasAltLang  bool                           `json:"hasAltLang" yaml:"hasAltLang"`
+	Languages   map[config.Language]StaticPage `json:"languages,omitempty" yaml:"languages,omitempty"`
+}
+
+// StaticPage is the page of a static sitemap entry in one language, with a path overriding the url of the entry,
+// or excluded from the sitemap of the language
+type StaticPage struct {
+	Path    string `json:"path,omitempty" yaml:"path,omitempty"`
+	Exclude bool   `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// paths returns the path of the page of the entry in each language it is listed in. Its url is the path in lang, and
+// in the alternate language too if it has one, unless overridden or excluded by its languages.
+func (s *StaticURL) paths(lang config.Language) map[config.Language]string {
+	paths := make(map[config.Language]string, len(staticLanguages))
+	if s.URL != "" {
+		paths[lang] = s.URL
+		if s.HasAltLang {
+			paths[alternateLanguage(lang)] = s.URL
+		}
+	}
+	for pageLang, page := range s.Languages {
+		switch {
+		case page.Exclude:
+			delete(paths, pageLang)
+		case page.Path != "":
+			paths[pageLang] = page.Path
+		}
+	}
+	return paths
 }
 
-func LoadStaticSitemap(cfg *config.Config, oldSitemapName, staticSitemapName, dpOnsURLHostName, dpOnsURLHostNameAlt, altLang string, store FileStore) error {
+// StaticSitemaps returns the sitemap of each language of the static pages listed in staticSitemapName, a JSON, YAML or
+// CSV file depending on its extension, whose urls are english paths. All the invalid entries of the list are reported
+// in the returned error. The urls are checked against the robots rules unless robots is nil.
+func StaticSitemaps(ctx context.Context, cfg *config.Config, staticSitemapName string, robots *RobotsCheck) (map[config.Language][]byte, error) {
 	var b []byte
 	var err error
 	if cfg.Debug {
-		b, err = GetStaticSitemap(staticSitemapName)
+		b, err = GetStaticSitemap(filepath.Base(staticSitemapName))
 	} else {
 		b, err = os.ReadFile(staticSitemapName)
 	}
 	if err != nil {
-		panic("can't find file " + staticSitemapName)
+		return nil, fmt.Errorf("failed to read static sitemap %s: %w", staticSitemapName, err)
+	}
+
+	content, err := parseStaticURLs(staticSitemapName, b)
+	if err != nil {
+		return nil, err
 	}
 
-	var content []StaticURL
+	urls := staticURLs(content, config.English, staticHostNames(cfg))
+	sitemaps := make(map[config.Language][]byte, len(staticLanguages))
+	for _, lang := range staticLanguages {
+		if robots != nil {
+			urls[lang], _ = robots.filterURLs(ctx, lang, "static", urls[lang])
+		}
+		// move old sitemap urls to new sitemap
+		sitemapWriter := Urlset{
+			Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+			Xhtml: "http://www.w3.org/1999/xhtml",
+			URL:   urls[lang],
+		}
+
+		marshaledContent, marshalErr := xml.MarshalIndent(sitemapWriter, "", "  ")
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		sitemaps[lang] = append([]byte(xml.Header), marshaledContent...)
+	}
+	return sitemaps, nil
+}
 
-	err = json.Unmarshal(b, &content)
+// LoadStaticSitemap saves the sitemaps of the static pages listed in staticSitemapName to the files of their language,
+// checking their urls against the robots rules unless robots is nil
+func LoadStaticSitemap(ctx context.Context, cfg *config.Config, staticSitemapName string, sitemapNames Files, store FileStore, robots *RobotsCheck) error {
+	sitemaps, err := StaticSitemaps(ctx, cfg, staticSitemapName, robots)
 	if err != nil {
-		return fmt.Errorf("unable to read json: %w", err)
+		return err
+	}
+	for _, lang := range staticLanguages {
+		name, ok := sitemapNames[lang]
+		if !ok {
+			continue
+		}
+		if err = store.SaveFile(name, bytes.NewReader(sitemaps[lang])); err != nil {
+			return fmt.Errorf("failed to save %s static sitemap: %w", lang, err)
+		}
 	}
+	return nil
+}
 
-	// move old sitemap urls to new sitemap
-	sitemapWriter := Urlset{
-		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
-		Xhtml: "http://www.w3.org/1999/xhtml",
+// StaticSitemapFile returns the static page list of a language in dir, in the first supported format found,
+// or the JSON one if there is none
+func StaticSitemapFile(dir string, lang config.Language) string {
+	name := filepath.Join(dir, "sitemap_"+lang.String())
+	for _, ext := range staticSitemapExtensions {
+		if _, err := os.Stat(name + ext); err == nil {
+			return name + ext
+		}
 	}
+	return name + staticSitemapExtensions[0]
+}
 
-	// range through static content
-	for _, item := range content {
-		var newURL URL
-		newURL.Loc = dpOnsURLHostName + item.URL
-		newURL.Lastmod = item.ReleaseDate
-		newURL.Alternate = &AlternateURL{}
-		if item.HasAltLang {
-			newURL.Alternate.Rel = "alternate"
-			newURL.Alternate.Link = dpOnsURLHostNameAlt + item.URL
-			newURL.Alternate.Lang = altLang
+// parseStaticURLs decodes the static page list in b, in the format given by the extension of name, and validates its
+// entries. Release dates are converted to W3C dates.
+func parseStaticURLs(name string, b []byte) ([]StaticURL, error) {
+	var (
+		content   []StaticURL
+		positions []string
+		err       error
+	)
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".json":
+		if err = json.Unmarshal(b, &content); err != nil {
+			return nil, fmt.Errorf("unable to read json %s: %w", name, err)
+		}
+	case ".yaml", ".yml":
+		if err = yaml.Unmarshal(b, &content); err != nil {
+			return nil, fmt.Errorf("unable to read yaml %s: %w", name, err)
+		}
+	case ".csv":
+		if content, positions, err = readStaticCSV(b); err != nil {
+			return nil, fmt.Errorf("unable to read csv %s: %w", name, err)
 		}
-		sitemapWriter.URL = append(sitemapWriter.URL, newURL)
+	default:
+		return nil, fmt.Errorf("unsupported static sitemap format %q of %s, expected .json, .yaml, .yml or .csv", ext, name)
 	}
 
-	marshaledContent, err := xml.MarshalIndent(sitemapWriter, "", "  ")
+	var errs []error
+	for i := range content {
+		position := fmt.Sprintf("entry %d", i+1)
+		if positions != nil {
+			position = positions[i]
+		}
+		item := &content[i]
+		for _, err = range validateStaticPaths(item) {
+			errs = append(errs, fmt.Errorf("%s: %w", position, err))
+		}
+		date, ok := staticDate(item.ReleaseDate)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: invalid release date %q, expected YYYY-MM-DD or DD-MM-YYYY", position, item.ReleaseDate))
+		}
+		item.ReleaseDate = date
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid static sitemap %s:\n%w", name, errors.Join(errs...))
+	}
+	return content, nil
+}
+
+// readStaticCSV decodes a static page list with a header row naming its url, releaseDate and optional hasAltLang,
+// <lang>Path and <lang>Exclude columns, along with the line of each entry
+func readStaticCSV(b []byte) (content []StaticURL, lines []string, err error) {
+	reader := csv.NewReader(bytes.NewReader(b))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
 	if err != nil {
-		return err
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.TrimSpace(column)] = i
+	}
+	for _, column := range []string{"url", "releaseDate"} {
+		if _, ok := columns[column]; !ok {
+			return nil, nil, fmt.Errorf("missing %s column", column)
+		}
+	}
+
+	for {
+		record, readErr := reader.Read()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, nil, readErr
+		}
+		line, _ := reader.FieldPos(0)
+		item := StaticURL{
+			URL:         strings.TrimSpace(record[columns["url"]]),
+			ReleaseDate: strings.TrimSpace(record[columns["releaseDate"]]),
+		}
+		if item.HasAltLang, err = csvBool(record, columns, "hasAltLang"); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		for _, lang := range staticLanguages {
+			var page StaticPage
+			if i, ok := columns[lang.String()+"Path"]; ok {
+				page.Path = strings.TrimSpace(record[i])
+			}
+			if page.Exclude, err = csvBool(record, columns, lang.String()+"Exclude"); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if page != (StaticPage{}) {
+				if item.Languages == nil {
+					item.Languages = map[config.Language]StaticPage{}
+				}
+				item.Languages[lang] = page
+			}
+		}
+		content = append(content, item)
+		lines = append(lines, fmt.Sprintf("line %d", line))
+	}
+	return content, lines, nil
+}
+
+// csvBool returns the boolean in the column of a csv record, false if the column is missing or empty
+func csvBool(record []string, columns map[string]int, column string) (bool, error) {
+	i, ok := columns[column]
+	if !ok || strings.TrimSpace(record[i]) == "" {
+		return false, nil
 	}
-	header := []byte(xml.Header)
-	header = append(header, marshaledContent...)
-	reader := bytes.NewReader(header)
-	err = store.SaveFile(oldSitemapName, reader)
+	value, err := strconv.ParseBool(strings.TrimSpace(record[i]))
 	if err != nil {
-		return err
+		return false, fmt.Errorf("invalid %s %q", column, record[i])
+	}
+	return value, nil
+}
+
+// validateStaticPaths checks that the url and language paths of a static entry are paths relative to the host names,
+// in known languages
+func validateStaticPaths(item *StaticURL) []error {
+	var errs []error
+	if item.URL == "" {
+		hasPath := false
+		for _, page := range item.Languages {
+			hasPath = hasPath || page.Path != ""
+		}
+		if !hasPath {
+			errs = append(errs, errors.New("url is missing"))
+		}
+	} else if err := validateStaticURL(item.URL); err != nil {
+		errs = append(errs, err)
+	}
+	languages := make([]config.Language, 0, len(item.Languages))
+	for pageLang := range item.Languages {
+		languages = append(languages, pageLang)
+	}
+	slices.Sort(languages)
+	for _, pageLang := range languages {
+		if !slices.Contains(staticLanguages, pageLang) {
+			errs = append(errs, fmt.Errorf("unknown language %q", string(pageLang)))
+			continue
+		}
+		if path := item.Languages[pageLang].Path; path != "" {
+			if err := validateStaticURL(path); err != nil {
+				errs = append(errs, fmt.Errorf("%s %w", pageLang, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validateStaticURL checks that the url of a static page is a path relative to the host names
+func validateStaticURL(staticURL string) error {
+	parsed, err := url.Parse(staticURL)
+	if err != nil {
+		return fmt.Errorf("invalid url %q", staticURL)
+	}
+	if parsed.IsAbs() || parsed.Host != "" || strings.HasPrefix(staticURL, "/") {
+		return fmt.Errorf("url %q is not relative to the host name, it must not have a scheme, host or leading slash", staticURL)
 	}
 	return nil
 }
+
+// staticDate returns the W3C date of a static page release date, and whether it is valid
+func staticDate(releaseDate string) (string, bool) {
+	for _, format := range staticDateFormats {
+		if date, err := time.Parse(format, releaseDate); err == nil {
+			return date.Format("2006-01-02"), true
+		}
+	}
+	return releaseDate, false
+}
+
+// staticURLs returns the sitemap entries of each language of static pages whose urls are paths in lang. Pages listed in
+// both languages have the other one as alternate.
+func staticURLs(content []StaticURL, lang config.Language, hostNames map[config.Language]string) map[config.Language][]URL {
+	urls := make(map[config.Language][]URL, len(staticLanguages))
+	for i := range content {
+		paths := content[i].paths(lang)
+		for _, pageLang := range staticLanguages {
+			path, ok := paths[pageLang]
+			if !ok {
+				continue
+			}
+			newURL := URL{
+				Loc:     hostNames[pageLang] + path,
+				Lastmod: content[i].ReleaseDate,
+			}
+			altLang := alternateLanguage(pageLang)
+			if altPath, ok := paths[altLang]; ok {
+				newURL.Alternate = &AlternateURL{
+					Rel:  "alternate",
+					Link: hostNames[altLang] + altPath,
+					Lang: altLang.String(),
+				}
+			}
+			urls[pageLang] = append(urls[pageLang], newURL)
+		}
+	}
+	return urls
+}
+
+// staticHostNames returns the host name of each language of the static pages
+func staticHostNames(cfg *config.Config) map[config.Language]string {
+	return map[config.Language]string{
+		config.English: cfg.DpOnsURLHostNameEn,
+		config.Welsh:   cfg.DpOnsURLHostNameCy,
+	}
+}
+
+// alternateLanguage returns the other language of the sitemaps
+func alternateLanguage(lang config.Language) config.Language {
+	if lang == config.Welsh {
+		return config.English
+	}
+	return config.Welsh
+}
+
+// StaticPages are the pages that are not in the search index but must be in the full sitemaps, listed in a
+// sitemap_en JSON, YAML or CSV file whose entries give the pages of both languages
+type StaticPages struct {
+	cfg *config.Config
+	dir string
+}
+
+// NewStaticPages returns the static pages listed in dir, or embedded in the service if dir is empty
+func NewStaticPages(cfg *config.Config, dir string) *StaticPages {
+	return &StaticPages{
+		cfg: cfg,
+		dir: dir,
+	}
+}
+
+// URLs returns the sitemap entries of the static pages of a language, with W3C lastmod dates. They are taken from the
+// english static page list, whose entries give the pages of both languages.
+func (p *StaticPages) URLs(lang config.Language) ([]URL, error) {
+	name := "sitemap_" + config.English.String() + staticSitemapExtensions[0]
+	var (
+		b   []byte
+		err error
+	)
+	if p.dir == "" {
+		b, err = GetStaticSitemap(name)
+	} else {
+		name = StaticSitemapFile(p.dir, config.English)
+		b, err = os.ReadFile(name)
+		name = filepath.Base(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static sitemap %s: %w", name, err)
+	}
+
+	content, err := parseStaticURLs(name, b)
+	if err != nil {
+		return nil, err
+	}
+	return staticURLs(content, config.English, staticHostNames(p.cfg))[lang], nil
+}
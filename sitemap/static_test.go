@@ -1,8 +1,10 @@
 package sitemap
 
 import (
+	"context"
 	"encoding/xml"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ONSdigital/dp-sitemap/config"
@@ -12,47 +14,333 @@ import (
 
 func TestLoadStaticSitemap(t *testing.T) {
 	Convey("given we have static sitemap file", t, func() {
-		oldSitemapName := "test_sitemap_en"
+		oldSitemapNames := Files{config.English: "test_sitemap_en", config.Welsh: "test_sitemap_cy"}
 		staticSitemapName := "sitemap_en.json"
-		Convey("when loading english static sitemap", func() {
+		Convey("when loading the static sitemaps of both languages", func() {
 			store := LocalStore{}
 			cfg, _ := config.Get()
-			err := LoadStaticSitemap(cfg, oldSitemapName, staticSitemapName, cfg.DpOnsURLHostNameEn, cfg.DpOnsURLHostNameCy, "cy", &store)
+			err := LoadStaticSitemap(context.Background(), cfg, staticSitemapName, oldSitemapNames, &store, nil)
 			Convey("There should be no error", func() {
 				So(err, ShouldBeNil)
 			})
-			Convey("And the file should exist", func() {
-				_, err = os.Stat(oldSitemapName)
-				So(err, ShouldBeNil)
+			Convey("And the files should hold the pages of each language with their alternate", func() {
+				for lang, expected := range map[config.Language]*UrlsetReader{config.English: expectedURLSetEnglish(), config.Welsh: expectedURLSetWelsh()} {
+					content, err := os.ReadFile(oldSitemapNames[lang])
+					So(err, ShouldBeNil)
+					var urlset UrlsetReader
+					So(xml.Unmarshal(content, &urlset), ShouldBeNil)
+					So(&urlset, ShouldResemble, expected)
+				}
 			})
-			Convey("And when we delete it, it should not exist", func() {
-				err = os.Remove(oldSitemapName)
-				So(err, ShouldBeNil)
+			Convey("And when we delete them, they should not exist", func() {
+				for _, oldSitemapName := range oldSitemapNames {
+					err = os.Remove(oldSitemapName)
+					So(err, ShouldBeNil)
+					_, err = os.Stat(oldSitemapName)
+					So(err, ShouldNotBeNil)
+				}
+			})
+		})
+	})
+
+	Convey("given a static sitemap file with per-language paths", t, func() {
+		dir := t.TempDir()
+		staticSitemapName := filepath.Join(dir, "sitemap_en.yaml")
+		So(os.WriteFile(staticSitemapName, []byte(`
+- url: same
+  releaseDate: 2023-01-01
+  hasAltLang: true
+- url: english
+  releaseDate: 2023-01-02
+  languages:
+    cy:
+      path: cymraeg
+- url: english-only
+  releaseDate: 2023-01-03
+- releaseDate: 2023-01-04
+  languages:
+    cy:
+      path: cymraeg-yn-unig
+- url: hidden-in-welsh
+  releaseDate: 2023-01-05
+  hasAltLang: true
+  languages:
+    cy:
+      exclude: true
+`), 0o600), ShouldBeNil)
+		cfg := &config.Config{
+			DpOnsURLHostNameEn: "https://www.ons.gov.uk/",
+			DpOnsURLHostNameCy: "https://cy.ons.gov.uk/",
+		}
+		Convey("when its sitemaps are built", func() {
+			sitemaps, err := StaticSitemaps(context.Background(), cfg, staticSitemapName, nil)
+			So(err, ShouldBeNil)
+			Convey("Then each language lists its own paths, paired with their alternate", func() {
+				urls := map[config.Language][]URL{}
+				for lang, content := range sitemaps {
+					var urlset UrlsetReader
+					So(xml.Unmarshal(content, &urlset), ShouldBeNil)
+					for i := range urlset.URL {
+						urls[lang] = append(urls[lang], urlset.URL[i].URL())
+					}
+				}
+				So(urls[config.English], ShouldResemble, []URL{
+					{Loc: "https://www.ons.gov.uk/same", Lastmod: "2023-01-01", Alternate: &AlternateURL{Rel: "alternate", Lang: "cy", Link: "https://cy.ons.gov.uk/same"}},
+					{Loc: "https://www.ons.gov.uk/english", Lastmod: "2023-01-02", Alternate: &AlternateURL{Rel: "alternate", Lang: "cy", Link: "https://cy.ons.gov.uk/cymraeg"}},
+					{Loc: "https://www.ons.gov.uk/english-only", Lastmod: "2023-01-03"},
+					{Loc: "https://www.ons.gov.uk/hidden-in-welsh", Lastmod: "2023-01-05"},
+				})
+				So(urls[config.Welsh], ShouldResemble, []URL{
+					{Loc: "https://cy.ons.gov.uk/same", Lastmod: "2023-01-01", Alternate: &AlternateURL{Rel: "alternate", Lang: "en", Link: "https://www.ons.gov.uk/same"}},
+					{Loc: "https://cy.ons.gov.uk/cymraeg", Lastmod: "2023-01-02", Alternate: &AlternateURL{Rel: "alternate", Lang: "en", Link: "https://www.ons.gov.uk/english"}},
+					{Loc: "https://cy.ons.gov.uk/cymraeg-yn-unig", Lastmod: "2023-01-04"},
+				})
+			})
+		})
+	})
+
+	Convey("given a static sitemap file with invalid entries", t, func() {
+		dir := t.TempDir()
+		staticSitemapName := filepath.Join(dir, "sitemap_en.json")
+		So(os.WriteFile(staticSitemapName, []byte(`[{"url": "https://www.ons.gov.uk/a", "releaseDate": "2023-01-01"}]`), 0o600), ShouldBeNil)
+		Convey("when loading it", func() {
+			store := LocalStore{}
+			cfg := &config.Config{}
+			oldSitemapName := filepath.Join(dir, "test_sitemap_en")
+			err := LoadStaticSitemap(context.Background(), cfg, staticSitemapName, Files{config.English: oldSitemapName}, &store, nil)
+			Convey("Then an error is returned and no sitemap is written", func() {
+				So(err.Error(), ShouldContainSubstring, "is not relative to the host name")
 				_, err = os.Stat(oldSitemapName)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+		Convey("when loading a missing file", func() {
+			err := LoadStaticSitemap(context.Background(), &config.Config{}, filepath.Join(dir, "sitemap_cy.json"), Files{config.Welsh: filepath.Join(dir, "test_sitemap_cy")}, &LocalStore{}, nil)
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "failed to read static sitemap")
+			})
+		})
+	})
+}
+
+func TestParseStaticURLs(t *testing.T) {
+	expected := []StaticURL{
+		{URL: "a", ReleaseDate: "2023-02-01"},
+		{URL: "b/c", ReleaseDate: "2023-03-15", HasAltLang: true},
+		{URL: "d", ReleaseDate: "2023-04-01", Languages: map[config.Language]StaticPage{config.Welsh: {Path: "e"}}},
+		{URL: "f", ReleaseDate: "2023-05-01", HasAltLang: true, Languages: map[config.Language]StaticPage{config.Welsh: {Exclude: true}}},
+	}
+
+	Convey("Given the same static pages in each format", t, func() {
+		files := map[string]string{
+			"sitemap_en.json": `[
+  {"url": "a", "releaseDate": "2023-02-01"},
+  {"url": "b/c", "releaseDate": "15-03-2023", "hasAltLang": true},
+  {"url": "d", "releaseDate": "2023-04-01", "languages": {"cy": {"path": "e"}}},
+  {"url": "f", "releaseDate": "2023-05-01", "hasAltLang": true, "languages": {"cy": {"exclude": true}}}
+]`,
+			"sitemap_en.yaml": `
+- url: a
+  releaseDate: 2023-02-01
+- url: b/c
+  releaseDate: 15-03-2023
+  hasAltLang: true
+- url: d
+  releaseDate: 2023-04-01
+  languages:
+    cy:
+      path: e
+- url: f
+  releaseDate: 2023-05-01
+  hasAltLang: true
+  languages:
+    cy:
+      exclude: true
+`,
+			"sitemap_en.csv": "url,releaseDate,hasAltLang,cyPath,cyExclude\na,2023-02-01,,,\nb/c,15-03-2023,true,,\nd,2023-04-01,,e,\nf,2023-05-01,true,,true\n",
+		}
+
+		for name, content := range files {
+			Convey("When "+name+" is parsed", func() {
+				urls, err := parseStaticURLs(name, []byte(content))
+
+				Convey("Then its entries are returned with W3C release dates", func() {
+					So(err, ShouldBeNil)
+					So(urls, ShouldResemble, expected)
+				})
+			})
+		}
+	})
+
+	Convey("Given static pages with several invalid entries", t, func() {
+		content := "url,releaseDate\nhttps://www.ons.gov.uk/a,2023-02-01\n/b,2023-02-30\nc,2023-02-01\n,2023-02-01\n"
+
+		Convey("When they are parsed", func() {
+			_, err := parseStaticURLs("sitemap_en.csv", []byte(content))
+
+			Convey("Then every problem is reported in a single error", func() {
 				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, `invalid static sitemap sitemap_en.csv:
+line 2: url "https://www.ons.gov.uk/a" is not relative to the host name, it must not have a scheme, host or leading slash
+line 3: url "/b" is not relative to the host name, it must not have a scheme, host or leading slash
+line 3: invalid release date "2023-02-30", expected YYYY-MM-DD or DD-MM-YYYY
+line 5: url is missing`)
 			})
 		})
 	})
 
-	Convey("given we have static sitemap file sitemap_cy.json", t, func() {
-		oldSitemapName := "test_sitemap_cy"
-		staticSitemapName := "sitemap_cy.json"
-		Convey("when loading welsh static sitemap", func() {
-			store := LocalStore{}
-			cfg, _ := config.Get()
-			err := LoadStaticSitemap(cfg, oldSitemapName, staticSitemapName, cfg.DpOnsURLHostNameCy, cfg.DpOnsURLHostNameEn, "en", &store)
-			Convey("There should be no error", func() {
+	Convey("Given static pages with invalid language paths", t, func() {
+		content := `[
+  {"releaseDate": "2023-02-01", "languages": {"cy": {"exclude": true}}},
+  {"url": "a", "releaseDate": "2023-02-01", "languages": {"fr": {"path": "b"}}},
+  {"url": "a", "releaseDate": "2023-02-01", "languages": {"cy": {"path": "https://cy.ons.gov.uk/a"}}}
+]`
+
+		Convey("When they are parsed", func() {
+			_, err := parseStaticURLs("sitemap_en.json", []byte(content))
+
+			Convey("Then every problem is reported in a single error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, `invalid static sitemap sitemap_en.json:
+entry 1: url is missing
+entry 2: unknown language "fr"
+entry 3: cy url "https://cy.ons.gov.uk/a" is not relative to the host name, it must not have a scheme, host or leading slash`)
+			})
+		})
+	})
+
+	Convey("Given static pages in an unsupported format", t, func() {
+		Convey("When they are parsed", func() {
+			_, err := parseStaticURLs("sitemap_en.txt", []byte("a"))
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, `unsupported static sitemap format ".txt"`)
+			})
+		})
+	})
+
+	Convey("Given a csv file without a releaseDate column", t, func() {
+		Convey("When it is parsed", func() {
+			_, err := parseStaticURLs("sitemap_en.csv", []byte("url\na\n"))
+
+			Convey("Then an error is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "missing releaseDate column")
+			})
+		})
+	})
+}
+
+func TestStaticPages(t *testing.T) {
+	cfg := &config.Config{
+		DpOnsURLHostNameEn: "https://www.ons.gov.uk/",
+		DpOnsURLHostNameCy: "https://cy.ons.gov.uk/",
+	}
+
+	Convey("Given the embedded static pages", t, func() {
+		pages := NewStaticPages(cfg, "")
+
+		Convey("When the welsh pages are read", func() {
+			urls, err := pages.URLs(config.Welsh)
+
+			Convey("Then they are on the welsh host with an english alternate and a W3C lastmod", func() {
 				So(err, ShouldBeNil)
+				So(urls, ShouldNotBeEmpty)
+				So(urls[0].Loc, ShouldStartWith, "https://cy.ons.gov.uk/")
+				So(urls[0].Lastmod, ShouldEqual, "2023-01-01")
+				So(urls[0].Alternate.Lang, ShouldEqual, "en")
+				So(urls[0].Alternate.Link, ShouldStartWith, "https://www.ons.gov.uk/")
 			})
-			Convey("And the file should exist", func() {
-				_, err = os.Stat(oldSitemapName)
+		})
+	})
+
+	Convey("Given static pages in a directory", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "sitemap_en.json"), []byte(`[
+  {"url": "a", "releaseDate": "2023-02-01", "hasAltLang": false},
+  {"url": "b", "releaseDate": "15-03-2023", "hasAltLang": true}
+]`), 0o600), ShouldBeNil)
+		pages := NewStaticPages(cfg, dir)
+
+		Convey("When the english pages are read", func() {
+			urls, err := pages.URLs(config.English)
+
+			Convey("Then the pages of the directory are returned", func() {
 				So(err, ShouldBeNil)
+				So(urls, ShouldResemble, []URL{
+					{Loc: "https://www.ons.gov.uk/a", Lastmod: "2023-02-01"},
+					{Loc: "https://www.ons.gov.uk/b", Lastmod: "2023-03-15", Alternate: &AlternateURL{Rel: "alternate", Lang: "cy", Link: "https://cy.ons.gov.uk/b"}},
+				})
 			})
-			Convey("And when we delete it, it should not exist", func() {
-				err = os.Remove(oldSitemapName)
+		})
+
+		Convey("When the english pages are listed in yaml instead", func() {
+			So(os.Remove(filepath.Join(dir, "sitemap_en.json")), ShouldBeNil)
+			So(os.WriteFile(filepath.Join(dir, "sitemap_en.yml"), []byte("- url: a\n  releaseDate: 2023-02-01\n"), 0o600), ShouldBeNil)
+			urls, err := pages.URLs(config.English)
+
+			Convey("Then the yaml pages are returned", func() {
 				So(err, ShouldBeNil)
-				_, err = os.Stat(oldSitemapName)
-				So(err, ShouldNotBeNil)
+				So(urls, ShouldResemble, []URL{{Loc: "https://www.ons.gov.uk/a", Lastmod: "2023-02-01"}})
+			})
+		})
+
+		Convey("When the welsh pages are read", func() {
+			urls, err := pages.URLs(config.Welsh)
+
+			Convey("Then they are taken from the english pages", func() {
+				So(err, ShouldBeNil)
+				So(urls, ShouldResemble, []URL{
+					{Loc: "https://cy.ons.gov.uk/b", Lastmod: "2023-03-15", Alternate: &AlternateURL{Rel: "alternate", Lang: "en", Link: "https://www.ons.gov.uk/b"}},
+				})
+			})
+		})
+	})
+
+	Convey("Given static pages with a welsh path and a welsh exclusion", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "sitemap_en.yaml"), []byte(`
+- url: aboutus/foi
+  releaseDate: 2023-01-01
+  languages:
+    cy:
+      path: amdanomni/rhyddidgwybodaeth
+- url: news/statements
+  releaseDate: 2023-01-01
+  hasAltLang: true
+  languages:
+    cy:
+      exclude: true
+`), 0o600), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "sitemap_cy.json"), []byte(`[{"url": "stale", "releaseDate": "2023-01-01"}]`), 0o600), ShouldBeNil)
+		pages := NewStaticPages(cfg, dir)
+
+		Convey("When the pages of each language are read", func() {
+			en, err := pages.URLs(config.English)
+			So(err, ShouldBeNil)
+			cy, err := pages.URLs(config.Welsh)
+			So(err, ShouldBeNil)
+
+			Convey("Then the welsh page is at its welsh path, with the english page as alternate", func() {
+				So(en[0], ShouldResemble, URL{Loc: "https://www.ons.gov.uk/aboutus/foi", Lastmod: "2023-01-01", Alternate: &AlternateURL{Rel: "alternate", Lang: "cy", Link: "https://cy.ons.gov.uk/amdanomni/rhyddidgwybodaeth"}})
+				So(cy, ShouldResemble, []URL{
+					{Loc: "https://cy.ons.gov.uk/amdanomni/rhyddidgwybodaeth", Lastmod: "2023-01-01", Alternate: &AlternateURL{Rel: "alternate", Lang: "en", Link: "https://www.ons.gov.uk/aboutus/foi"}},
+				})
+			})
+			Convey("Then the page excluded from welsh is only in the english pages", func() {
+				So(en, ShouldHaveLength, 2)
+				So(en[1], ShouldResemble, URL{Loc: "https://www.ons.gov.uk/news/statements", Lastmod: "2023-01-01"})
+			})
+		})
+	})
+
+	Convey("Given a directory without static pages", t, func() {
+		pages := NewStaticPages(cfg, t.TempDir())
+
+		Convey("When the welsh pages are read", func() {
+			_, err := pages.URLs(config.Welsh)
+
+			Convey("Then an error naming the english list is returned", func() {
+				So(err.Error(), ShouldContainSubstring, "failed to read static sitemap sitemap_en.json")
 			})
 		})
 	})
@@ -66,7 +354,7 @@ func expectedURLSetEnglish() *UrlsetReader {
 			{
 				XMLName: xml.Name{Space: "http://www.sitemaps.org/schemas/sitemap/0.9", Local: "url"},
 				Loc:     "https://dp.aws.onsdigital.uk/economy/environmentalaccounts/articles/testarticle1",
-				Lastmod: "01-01-2023",
+				Lastmod: "2023-01-01",
 				Alternate: &AlternateURLReader{
 					XMLName: xml.Name{Space: "http://www.w3.org/1999/xhtml", Local: "link"},
 					Rel:     "alternate",
@@ -77,7 +365,7 @@ func expectedURLSetEnglish() *UrlsetReader {
 			{
 				XMLName: xml.Name{Space: "http://www.sitemaps.org/schemas/sitemap/0.9", Local: "url"},
 				Loc:     "https://dp.aws.onsdigital.uk/economy/environmentalaccounts/articles/testarticle2",
-				Lastmod: "01-01-2023",
+				Lastmod: "2023-01-01",
 				Alternate: &AlternateURLReader{
 					XMLName: xml.Name{Space: "http://www.w3.org/1999/xhtml", Local: "link"},
 					Rel:     "alternate",
@@ -88,7 +376,7 @@ func expectedURLSetEnglish() *UrlsetReader {
 			{
 				XMLName: xml.Name{Space: "http://www.sitemaps.org/schemas/sitemap/0.9", Local: "url"},
 				Loc:     "https://dp.aws.onsdigital.uk/economy/environmentalaccounts/articles/testarticle3",
-				Lastmod: "01-01-2023",
+				Lastmod: "2023-01-01",
 				Alternate: &AlternateURLReader{
 					XMLName: xml.Name{Space: "http://www.w3.org/1999/xhtml", Local: "link"},
 					Rel:     "alternate",
@@ -108,7 +396,7 @@ func expectedURLSetWelsh() *UrlsetReader {
 			{
 				XMLName: xml.Name{Space: "http://www.sitemaps.org/schemas/sitemap/0.9", Local: "url"},
 				Loc:     "https://cy.dp.aws.onsdigital.uk/economy/environmentalaccounts/articles/testarticle1",
-				Lastmod: "01-01-2023",
+				Lastmod: "2023-01-01",
 				Alternate: &AlternateURLReader{
 					XMLName: xml.Name{Space: "http://www.w3.org/1999/xhtml", Local: "link"},
 					Rel:     "alternate",
@@ -119,7 +407,7 @@ func expectedURLSetWelsh() *UrlsetReader {
 			{
 				XMLName: xml.Name{Space: "http://www.sitemaps.org/schemas/sitemap/0.9", Local: "url"},
 				Loc:     "https://cy.dp.aws.onsdigital.uk/economy/environmentalaccounts/articles/testarticle2",
-				Lastmod: "01-01-2023",
+				Lastmod: "2023-01-01",
 				Alternate: &AlternateURLReader{
 					XMLName: xml.Name{Space: "http://www.w3.org/1999/xhtml", Local: "link"},
 					Rel:     "alternate",
@@ -130,7 +418,7 @@ func expectedURLSetWelsh() *UrlsetReader {
 			{
 				XMLName: xml.Name{Space: "http://www.sitemaps.org/schemas/sitemap/0.9", Local: "url"},
 				Loc:     "https://cy.dp.aws.onsdigital.uk/economy/environmentalaccounts/articles/testarticle3",
-				Lastmod: "01-01-2023",
+				Lastmod: "2023-01-01",
 				Alternate: &AlternateURLReader{
 					XMLName: xml.Name{Space: "http://www.w3.org/1999/xhtml", Local: "link"},
 					Rel:     "alternate",
@@ -0,0 +1,121 @@
+package sitemap
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// urlsetHeader starts a sitemap file, before its url entries
+	urlsetHeader = xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">` + "\n"
+	// urlsetFooter ends a sitemap file, after its url entries
+	urlsetFooter = "\n" + `</urlset>`
+)
+
+// sitemapWriter writes a sitemap one url entry at a time
+type sitemapWriter struct {
+	buffered *bufio.Writer
+	enc      *xml.Encoder
+}
+
+// newSitemapWriter writes the header of a sitemap to w and returns a writer of its url entries
+func newSitemapWriter(w io.Writer) (*sitemapWriter, error) {
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.WriteString(urlsetHeader); err != nil {
+		return nil, fmt.Errorf("failed to write sitemap header: %w", err)
+	}
+	enc := xml.NewEncoder(buffered)
+	enc.Indent("", "  ")
+	return &sitemapWriter{buffered: buffered, enc: enc}, nil
+}
+
+// Write writes the url entry u
+func (w *sitemapWriter) Write(u URL) error {
+	if err := w.enc.Encode(u); err != nil {
+		return fmt.Errorf("failed to encode sitemap: %w", err)
+	}
+	return nil
+}
+
+// Close writes the footer of the sitemap, after its last url entry
+func (w *sitemapWriter) Close() error {
+	if _, err := w.buffered.WriteString(urlsetFooter); err != nil {
+		return fmt.Errorf("failed to write sitemap footer: %w", err)
+	}
+	if err := w.buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write sitemap: %w", err)
+	}
+	return nil
+}
+
+// eachURL calls read with each url entry of the sitemap r, decoding it as a stream
+func eachURL(r io.Reader, read func(u URL) error) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode sitemap: %w", err)
+		}
+		el, ok := token.(xml.StartElement)
+		if !ok || el.Name.Local != "url" {
+			continue
+		}
+		var u URLReader
+		if err = decoder.DecodeElement(&u, &el); err != nil {
+			return fmt.Errorf("failed to decode sitemap: %w", err)
+		}
+		if err = read(u.URL()); err != nil {
+			return err
+		}
+	}
+}
+
+// rewriteFile writes the url entries of the sitemap file fileName, as changed by rewrite, to a new temporary
+// sitemap file and returns its name. rewrite is called with each url entry and writes the ones kept to w, and
+// finish, if given, may write more entries after the last one.
+func rewriteFile(fileName string, rewrite func(u URL, w *sitemapWriter) error, finish func(w *sitemapWriter) error) (rewritten string, err error) {
+	in, err := os.Open(fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to open sitemap: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp("", "sitemap-rewritten")
+	if err != nil {
+		return "", fmt.Errorf("failed to create rewritten sitemap file: %w", err)
+	}
+	rewritten = out.Name()
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(rewritten)
+		}
+	}()
+
+	w, err := newSitemapWriter(out)
+	if err != nil {
+		return rewritten, err
+	}
+	err = eachURL(in, func(u URL) error {
+		return rewrite(u, w)
+	})
+	if err != nil {
+		return rewritten, err
+	}
+	if finish != nil {
+		if err = finish(w); err != nil {
+			return rewritten, err
+		}
+	}
+	if err = w.Close(); err != nil {
+		return rewritten, err
+	}
+	return rewritten, nil
+}
@@ -0,0 +1,101 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// DefaultUpdateAttempts is the number of times a file is updated before giving up,
+// when it keeps being changed concurrently by other processes
+const DefaultUpdateAttempts = 10
+
+// Update writes a changed copy of the current sitemap to a temporary file, returning its name and number of URLs
+type Update func(current io.Reader) (fileName string, size int, err error)
+
+// UpdateFile changes the sitemap name of store with update and returns its new number of URLs. The
+// sitemap may be updated by several processes at once, so it is only saved if it has not changed since
+// it was read, and the update is retried from the new sitemap otherwise, up to attempts times. A missing
+// sitemap is updated from an empty one, while any other failure to read it fails the update.
+func UpdateFile(ctx context.Context, store FileStore, name string, attempts int, update Update) (int, error) {
+	for attempt := 1; ; attempt++ {
+		size, err := updateFileVersion(ctx, store, name, update)
+		if !errors.Is(err, ErrVersionConflict) || attempt >= attempts {
+			return size, err
+		}
+		log.Info(ctx, "sitemap changed while being updated, retrying", log.Data{"attempt": attempt, "filename": name})
+		backoff := time.NewTimer(rand.N(time.Duration(attempt) * 10 * time.Millisecond))
+		select {
+		case <-ctx.Done():
+			backoff.Stop()
+			return 0, fmt.Errorf("sitemap update cancelled: %w", ctx.Err())
+		case <-backoff.C:
+		}
+	}
+}
+
+// updateFileVersion changes the sitemap name of store with update, failing with ErrVersionConflict
+// if the sitemap is changed by another process in the meantime
+func updateFileVersion(ctx context.Context, store FileStore, name string, update Update) (int, error) {
+	currentSitemap, version, err := store.GetFileWithVersion(name)
+	if errors.Is(err, ErrFileNotFound) {
+		currentSitemap, err = io.NopCloser(strings.NewReader("")), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current sitemap: %w", err)
+	}
+	defer func() {
+		closeErr := currentSitemap.Close()
+		if closeErr != nil {
+			log.Error(ctx, "failed to close current sitemap file", closeErr)
+		}
+	}()
+
+	fileName, size, err := update(currentSitemap)
+	if err != nil {
+		return 0, err
+	}
+	err = saveTempFile(ctx, fileName, func(file io.Reader) error {
+		return store.SaveFileIfVersion(name, file, version)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// saveTempFile saves the temporary sitemap file with save, removing it afterwards
+func saveTempFile(ctx context.Context, fileName string, save func(file io.Reader) error) error {
+	defer func() {
+		err := os.Remove(fileName)
+		if err != nil {
+			log.Error(ctx, "failed to remove temporary sitemap file", err, log.Data{"filename": fileName})
+			return
+		}
+		log.Info(ctx, "removed temporary sitemap file", log.Data{"filename": fileName})
+	}()
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open publishing sitemap: %w", err)
+	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil {
+			log.Error(ctx, "failed to close publishing sitemap file", closeErr)
+		}
+	}()
+
+	err = save(file)
+	if err != nil {
+		return fmt.Errorf("failed to save publishing sitemap file: %w", err)
+	}
+	return nil
+}
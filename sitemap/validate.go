@@ -0,0 +1,274 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Limits of a single sitemap file, as defined by sitemaps.org
+const (
+	MaxSitemapURLs      = 50000
+	MaxSitemapSize      = 50 * 1024 * 1024
+	maxSitemapLocLength = 2048
+)
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Severities of a validation issue. Only errors make a sitemap invalid.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// lastmodFormats are the W3C datetime formats allowed for lastmod
+var lastmodFormats = []string{
+	"2006-01-02",
+	"2006-01-02T15:04Z07:00",
+	time.RFC3339,
+	time.RFC3339Nano,
+}
+
+var hreflangPattern = regexp.MustCompile(`^([a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*|x-default)$`)
+
+// ValidationIssue is a problem found in a sitemap, about a given URL if Loc is set
+type ValidationIssue struct {
+	File     string `json:"file"`
+	Loc      string `json:"loc,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	if i.Loc == "" {
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", i.File, i.Loc, i.Severity, i.Message)
+}
+
+// ValidationReport holds the outcome of the validation of a set of sitemaps
+type ValidationReport struct {
+	Files  int               `json:"files"`
+	URLs   int               `json:"urls"`
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// Errors returns the number of issues making the sitemaps invalid
+func (r *ValidationReport) Errors() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+// validationURL is a url entry of a sitemap, with all its alternate links
+type validationURL struct {
+	Loc     string               `xml:"loc"`
+	Lastmod string               `xml:"lastmod"`
+	Links   []AlternateURLReader `xml:"link"`
+}
+
+// validatedURL is where a url was found, with the targets of its alternate links
+type validatedURL struct {
+	file       string
+	alternates []string
+}
+
+// SitemapValidator checks sitemaps against the sitemaps.org protocol and the hreflang rules. The
+// alternate links of each URL must point back to it, so all the sitemaps referring to each other
+// should be validated by the same validator before getting the report.
+type SitemapValidator struct {
+	hosts  map[string]bool
+	files  int
+	urls   map[string]*validatedURL
+	order  []string
+	issues []ValidationIssue
+}
+
+// NewSitemapValidator returns a validator accepting only absolute URLs on the given host names,
+// such as "https://www.ons.gov.uk/"
+func NewSitemapValidator(hostNames ...string) (*SitemapValidator, error) {
+	hosts := map[string]bool{}
+	for _, hostName := range hostNames {
+		u, err := url.Parse(hostName)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid sitemap host name %q", hostName)
+		}
+		hosts[u.Scheme+"://"+u.Host] = true
+	}
+	return &SitemapValidator{
+		hosts: hosts,
+		urls:  map[string]*validatedURL{},
+	}, nil
+}
+
+// Validate checks the sitemap read from r, reported under the given file name
+func (v *SitemapValidator) Validate(file string, r io.Reader) {
+	v.files++
+	counter := &countingReader{r: r}
+	decoder := xml.NewDecoder(counter)
+
+	count := 0
+	seenURLset := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			v.fail(file, "", fmt.Sprintf("malformed sitemap: %s", err))
+			return
+		}
+		el, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case !seenURLset:
+			seenURLset = true
+			if el.Name.Local != "urlset" || el.Name.Space != sitemapNamespace {
+				v.fail(file, "", fmt.Sprintf("root element must be urlset in the %s namespace", sitemapNamespace))
+				return
+			}
+		case el.Name.Local == "url":
+			var u validationURL
+			if err = decoder.DecodeElement(&u, &el); err != nil {
+				v.fail(file, "", fmt.Sprintf("malformed sitemap: %s", err))
+				return
+			}
+			count++
+			v.validateURL(file, &u)
+		}
+	}
+
+	if !seenURLset {
+		v.fail(file, "", "sitemap is empty")
+	}
+	if count > MaxSitemapURLs {
+		v.fail(file, "", fmt.Sprintf("sitemap has %d urls, more than the maximum of %d", count, MaxSitemapURLs))
+	}
+	if counter.n > MaxSitemapSize {
+		v.fail(file, "", fmt.Sprintf("sitemap is %d bytes, more than the maximum of %d", counter.n, MaxSitemapSize))
+	}
+}
+
+// Report checks that the alternate links point back to the URLs linking to them and returns all the issues found
+func (v *SitemapValidator) Report() *ValidationReport {
+	issues := append([]ValidationIssue{}, v.issues...)
+	for _, loc := range v.order {
+		u := v.urls[loc]
+		for _, alternate := range u.alternates {
+			target, ok := v.urls[alternate]
+			if !ok {
+				issues = append(issues, ValidationIssue{File: u.file, Loc: loc, Severity: SeverityWarning, Message: fmt.Sprintf("alternate %s is not in the validated sitemaps", alternate)})
+				continue
+			}
+			if !slices.Contains(target.alternates, loc) {
+				issues = append(issues, ValidationIssue{File: u.file, Loc: loc, Severity: SeverityError, Message: fmt.Sprintf("alternate %s does not link back", alternate)})
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].File < issues[j].File })
+	return &ValidationReport{
+		Files:  v.files,
+		URLs:   len(v.order),
+		Issues: issues,
+	}
+}
+
+// validateURL checks a url entry and records it for the checks across sitemaps
+func (v *SitemapValidator) validateURL(file string, u *validationURL) {
+	if msg := v.checkURL(u.Loc); msg != "" {
+		v.fail(file, u.Loc, "loc "+msg)
+	}
+	if len(u.Loc) > maxSitemapLocLength {
+		v.fail(file, u.Loc, fmt.Sprintf("loc is longer than %d characters", maxSitemapLocLength))
+	}
+	if u.Lastmod != "" && lastmodTime(u.Lastmod).IsZero() {
+		v.fail(file, u.Loc, fmt.Sprintf("lastmod %q is not a W3C datetime", u.Lastmod))
+	}
+
+	validated := &validatedURL{file: file}
+	langs := map[string]bool{}
+	for _, link := range u.Links {
+		if link.Rel == "" && link.Lang == "" && link.Link == "" {
+			v.fail(file, u.Loc, "empty xhtml:link")
+			continue
+		}
+		if link.Rel != "alternate" {
+			v.fail(file, u.Loc, fmt.Sprintf("xhtml:link rel must be alternate, not %q", link.Rel))
+		}
+		if !hreflangPattern.MatchString(link.Lang) {
+			v.fail(file, u.Loc, fmt.Sprintf("xhtml:link hreflang %q is not a valid language code", link.Lang))
+		} else if langs[link.Lang] {
+			v.fail(file, u.Loc, fmt.Sprintf("several xhtml:link for hreflang %q", link.Lang))
+		}
+		langs[link.Lang] = true
+		if msg := v.checkURL(link.Link); msg != "" {
+			v.fail(file, u.Loc, "xhtml:link href "+msg)
+			continue
+		}
+		if link.Link != u.Loc {
+			validated.alternates = append(validated.alternates, link.Link)
+		}
+	}
+
+	if previous, ok := v.urls[u.Loc]; ok {
+		v.fail(file, u.Loc, fmt.Sprintf("duplicate loc, also in %s", previous.file))
+		return
+	}
+	if u.Loc != "" {
+		v.urls[u.Loc] = validated
+		v.order = append(v.order, u.Loc)
+	}
+}
+
+// checkURL returns why loc is not an absolute URL on one of the host names, or an empty string if it is
+func (v *SitemapValidator) checkURL(loc string) string {
+	if loc == "" {
+		return "is empty"
+	}
+	u, err := url.Parse(loc)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Sprintf("%q is not an absolute URL", loc)
+	}
+	if len(v.hosts) > 0 && !v.hosts[u.Scheme+"://"+u.Host] {
+		return fmt.Sprintf("%q is not on a configured host name", loc)
+	}
+	return ""
+}
+
+func (v *SitemapValidator) fail(file, loc, msg string) {
+	v.issues = append(v.issues, ValidationIssue{File: file, Loc: loc, Severity: SeverityError, Message: msg})
+}
+
+// lastmodTime returns the time of a W3C lastmod, or the zero time if it is not in one of the W3C datetime formats
+func lastmodTime(lastmod string) time.Time {
+	for _, format := range lastmodFormats {
+		if t, err := time.Parse(format, lastmod); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
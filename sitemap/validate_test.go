@@ -0,0 +1,142 @@
+package sitemap_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dp-sitemap/sitemap"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	validEnglishSitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>https://www.ons.gov.uk/a</loc>
+    <lastmod>2023-01-02</lastmod>
+    <xhtml:link rel="alternate" hreflang="cy" href="https://cy.ons.gov.uk/a"></xhtml:link>
+  </url>
+  <url>
+    <loc>https://www.ons.gov.uk/b</loc>
+    <lastmod>2023-01-02T03:04:05Z</lastmod>
+  </url>
+</urlset>`
+	validWelshSitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url>
+    <loc>https://cy.ons.gov.uk/a</loc>
+    <lastmod>2023-01-02T03:04:05.123+01:00</lastmod>
+    <xhtml:link rel="alternate" hreflang="en" href="https://www.ons.gov.uk/a"></xhtml:link>
+  </url>
+</urlset>`
+)
+
+func TestSitemapValidator(t *testing.T) {
+	Convey("Given a validator for the english and welsh host names", t, func() {
+		v, err := sitemap.NewSitemapValidator("https://www.ons.gov.uk/", "https://cy.ons.gov.uk/")
+		So(err, ShouldBeNil)
+
+		messages := func(report *sitemap.ValidationReport) []string {
+			list := []string{}
+			for _, issue := range report.Issues {
+				list = append(list, issue.String())
+			}
+			return list
+		}
+
+		Convey("When valid sitemaps linking to each other are validated", func() {
+			v.Validate("en.xml", strings.NewReader(validEnglishSitemap))
+			v.Validate("cy.xml", strings.NewReader(validWelshSitemap))
+			report := v.Report()
+
+			Convey("Then no issue is reported", func() {
+				So(report.Issues, ShouldBeEmpty)
+				So(report.Errors(), ShouldEqual, 0)
+				So(report.Files, ShouldEqual, 2)
+				So(report.URLs, ShouldEqual, 3)
+			})
+		})
+
+		Convey("When a sitemap is validated without the sitemap of its alternates", func() {
+			v.Validate("en.xml", strings.NewReader(validEnglishSitemap))
+			report := v.Report()
+
+			Convey("Then a warning is reported", func() {
+				So(report.Errors(), ShouldEqual, 0)
+				So(messages(report), ShouldResemble, []string{
+					"en.xml: https://www.ons.gov.uk/a: warning: alternate https://cy.ons.gov.uk/a is not in the validated sitemaps",
+				})
+			})
+		})
+
+		Convey("When a sitemap with invalid urls is validated", func() {
+			v.Validate("en.xml", strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+  <url><loc>/relative</loc></url>
+  <url><loc>https://example.com/a</loc></url>
+  <url><loc>https://www.ons.gov.uk/a</loc><lastmod>01-01-2023</lastmod><xhtml:link></xhtml:link></url>
+  <url><loc>https://www.ons.gov.uk/a</loc></url>
+  <url>
+    <loc>https://www.ons.gov.uk/b</loc>
+    <xhtml:link rel="canonical" hreflang="welsh" href="https://cy.ons.gov.uk/b"></xhtml:link>
+  </url>
+</urlset>`))
+			v.Validate("cy.xml", strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://cy.ons.gov.uk/b</loc></url>
+</urlset>`))
+			report := v.Report()
+
+			Convey("Then every problem is reported as an error", func() {
+				So(report.Errors(), ShouldEqual, 8)
+				So(messages(report), ShouldResemble, []string{
+					`en.xml: /relative: error: loc "/relative" is not an absolute URL`,
+					`en.xml: https://example.com/a: error: loc "https://example.com/a" is not on a configured host name`,
+					`en.xml: https://www.ons.gov.uk/a: error: lastmod "01-01-2023" is not a W3C datetime`,
+					"en.xml: https://www.ons.gov.uk/a: error: empty xhtml:link",
+					"en.xml: https://www.ons.gov.uk/a: error: duplicate loc, also in en.xml",
+					`en.xml: https://www.ons.gov.uk/b: error: xhtml:link rel must be alternate, not "canonical"`,
+					`en.xml: https://www.ons.gov.uk/b: error: xhtml:link hreflang "welsh" is not a valid language code`,
+					"en.xml: https://www.ons.gov.uk/b: error: alternate https://cy.ons.gov.uk/b does not link back",
+				})
+			})
+		})
+
+		Convey("When a sitemap with too many urls is validated", func() {
+			var b strings.Builder
+			b.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for i := 0; i <= sitemap.MaxSitemapURLs; i++ {
+				fmt.Fprintf(&b, "<url><loc>https://www.ons.gov.uk/%d</loc></url>", i)
+			}
+			b.WriteString(`</urlset>`)
+			v.Validate("en.xml", strings.NewReader(b.String()))
+
+			Convey("Then an error is reported", func() {
+				So(messages(v.Report()), ShouldResemble, []string{
+					"en.xml: error: sitemap has 50001 urls, more than the maximum of 50000",
+				})
+			})
+		})
+
+		Convey("When a file that is not a sitemap is validated", func() {
+			v.Validate("index.xml", strings.NewReader(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></sitemapindex>`))
+			v.Validate("broken.xml", strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url>`))
+			v.Validate("empty.xml", strings.NewReader(""))
+
+			Convey("Then errors are reported", func() {
+				So(messages(v.Report()), ShouldResemble, []string{
+					"broken.xml: error: malformed sitemap: XML syntax error on line 1: unexpected EOF",
+					"empty.xml: error: sitemap is empty",
+					"index.xml: error: root element must be urlset in the http://www.sitemaps.org/schemas/sitemap/0.9 namespace",
+				})
+			})
+		})
+	})
+
+	Convey("Given an invalid host name", t, func() {
+		_, err := sitemap.NewSitemapValidator("www.ons.gov.uk")
+
+		Convey("Then no validator is created", func() {
+			So(err.Error(), ShouldContainSubstring, `invalid sitemap host name "www.ons.gov.uk"`)
+		})
+	})
+}